@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/SumonRayy/ping-pong-go/pkg/pingpong"
+	"gopkg.in/yaml.v3"
+)
+
+// runConfig implements the `pingpong config` subcommands
+func runConfig(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "print":
+			return runConfigPrint(args[1:])
+		case "migrate":
+			return runConfigMigrate(args[1:])
+		}
+	}
+	fmt.Fprintln(os.Stderr, "usage: pingpong config print [--format yaml|json] [flags]")
+	fmt.Fprintln(os.Stderr, "       pingpong config migrate [--dry-run] [targets.yaml]")
+	return 2
+}
+
+// runConfigPrint implements `pingpong config print`, which shows the
+// effective configuration after the .env file, environment variables, flags,
+// targets file and defaults are merged, with secrets redacted
+func runConfigPrint(args []string) int {
+	flags := flag.NewFlagSet("config print", flag.ExitOnError)
+	format := flags.String("format", "yaml", "Output format: yaml or json")
+	config, opts := loadConfig(flags, args)
+	settings.report(opts.strict)
+
+	effective := pingpong.NewService(config).EffectiveConfig()
+	switch *format {
+	case "yaml":
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(effective); err != nil {
+			fmt.Fprintf(os.Stderr, "config: %v\n", err)
+			return 1
+		}
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(effective); err != nil {
+			fmt.Fprintf(os.Stderr, "config: %v\n", err)
+			return 1
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "config: unknown format %q, expected yaml or json\n", *format)
+		return 2
+	}
+	return 0
+}
+
+// runConfigMigrate implements `pingpong config migrate`, which upgrades a
+// targets file written for an older release to the current schema version in
+// place, keeping its comments
+func runConfigMigrate(args []string) int {
+	flags := flag.NewFlagSet("config migrate", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "Print the migrated file instead of replacing it")
+	flags.Parse(args)
+	path := getEnv("TARGETS_FILE")
+	if flags.NArg() > 0 {
+		path = flags.Arg(0)
+	}
+	if path == "" || flags.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "config migrate: expected one targets file, or TARGETS_FILE")
+		return 2
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		data, err = migrateTargetsFile(path, data, *dryRun)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config migrate: %v\n", err)
+		return 1
+	}
+	if *dryRun {
+		os.Stdout.Write(data)
+	}
+	return 0
+}
+
+// migrateTargetsFile migrates the content of the targets file at path,
+// replacing the file unless dryRun is set, and returns the migrated content
+func migrateTargetsFile(path string, data []byte, dryRun bool) ([]byte, error) {
+	migrated, version, err := pingpong.MigrateTargetsFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if version == pingpong.TargetsFileVersion {
+		fmt.Fprintf(os.Stderr, "%s is already at version %d\n", path, version)
+		return migrated, nil
+	}
+	if dryRun {
+		return migrated, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, migrated, info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "Migrated %s from version %d to %d\n", path, version, pingpong.TargetsFileVersion)
+	return migrated, nil
+}
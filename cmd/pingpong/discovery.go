@@ -0,0 +1,91 @@
+package main
+
+import "github.com/SumonRayy/ping-pong-go/pkg/pingpong"
+
+// buildDiscovery creates the target discoverers configured through
+// environment variables
+func buildDiscovery() []pingpong.Discoverer {
+	var discovery []pingpong.Discoverer
+
+	url, files := getEnv("PROMETHEUS_SD_URL"), splitList(getEnv("PROMETHEUS_SD_FILES"))
+	if url != "" || len(files) > 0 {
+		sd := &pingpong.PrometheusSD{
+			URL:    url,
+			Files:  files,
+			Scheme: getEnv("PROMETHEUS_SD_SCHEME"),
+			Path:   getEnv("PROMETHEUS_SD_PATH"),
+		}
+		if token := getEnv("PROMETHEUS_SD_TOKEN"); token != "" {
+			sd.Headers = map[string]string{"Authorization": "Bearer " + token}
+		}
+		discovery = append(discovery, sd)
+	}
+
+	if url := getEnv("TRAEFIK_API_URL"); url != "" {
+		discovery = append(discovery, &pingpong.TraefikDiscovery{URL: url, Path: getEnv("DISCOVERY_PATH")})
+	}
+
+	if url := getEnv("CADDY_ADMIN_URL"); url != "" {
+		discovery = append(discovery, &pingpong.CaddyDiscovery{URL: url, Path: getEnv("DISCOVERY_PATH")})
+	}
+
+	if url := getEnv("NGINX_API_URL"); url != "" {
+		discovery = append(discovery, &pingpong.NginxDiscovery{
+			URL:        url,
+			APIVersion: getEnvIntOrDefault("NGINX_API_VERSION", 0),
+			Path:       getEnv("DISCOVERY_PATH"),
+		})
+	}
+
+	if services := splitList(getEnv("MDNS_SERVICES")); len(services) > 0 {
+		discovery = append(discovery, &pingpong.MDNSDiscovery{Services: services, Path: getEnv("DISCOVERY_PATH")})
+	}
+
+	tags, targetGroups := parseLabels(getEnv("AWS_DISCOVERY_TAGS")), splitList(getEnv("AWS_TARGET_GROUPS"))
+	if len(tags) > 0 || len(targetGroups) > 0 {
+		region := getEnv("AWS_REGION")
+		if region == "" {
+			settings.problem("AWS discovery needs AWS_REGION")
+		}
+		discovery = append(discovery, &pingpong.AWSDiscovery{
+			Region:          region,
+			Tags:            tags,
+			TargetGroupARNs: targetGroups,
+			Port:            getEnvIntOrDefault("DISCOVERY_PORT", 0),
+			Path:            getEnv("DISCOVERY_PATH"),
+			PublicIP:        getEnvBool("AWS_DISCOVERY_PUBLIC_IP"),
+		})
+	}
+
+	if groups := splitList(getEnv("GCP_INSTANCE_GROUPS")); len(groups) > 0 {
+		gcp := &pingpong.GCPDiscovery{
+			Project:        getEnv("GCP_PROJECT"),
+			Zone:           getEnv("GCP_ZONE"),
+			InstanceGroups: groups,
+			Labels:         parseLabels(getEnv("GCP_DISCOVERY_LABELS")),
+			Port:           getEnvIntOrDefault("DISCOVERY_PORT", 0),
+			Path:           getEnv("DISCOVERY_PATH"),
+		}
+		if token := getEnv("GCP_TOKEN"); token != "" {
+			gcp.TokenSource = pingpong.StaticGCPToken(token)
+		}
+		discovery = append(discovery, gcp)
+	}
+
+	if group := getEnv("AZURE_RESOURCE_GROUP"); group != "" {
+		azure := &pingpong.AzureDiscovery{
+			SubscriptionID: getEnv("AZURE_SUBSCRIPTION_ID"),
+			ResourceGroup:  group,
+			ScaleSets:      splitList(getEnv("AZURE_SCALE_SETS")),
+			Tags:           parseLabels(getEnv("AZURE_DISCOVERY_TAGS")),
+			Port:           getEnvIntOrDefault("DISCOVERY_PORT", 0),
+			Path:           getEnv("DISCOVERY_PATH"),
+		}
+		if token := getEnv("AZURE_ARM_TOKEN"); token != "" {
+			azure.TokenSource = pingpong.StaticAzureToken(token)
+		}
+		discovery = append(discovery, azure)
+	}
+
+	return discovery
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/SumonRayy/ping-pong-go/pkg/pingpong"
+)
+
+// runLearn implements `pingpong learn`, which observes a target and prints
+// suggested thresholds as a .env snippet
+func runLearn(args []string) int {
+	flags := flag.NewFlagSet("learn", flag.ExitOnError)
+	url := flags.String("url", getEnv("SERVER_URL"), "URL of the target to observe")
+	duration := durationFlag(flags, "duration", 10*time.Minute, "How long to observe the target")
+	interval := durationFlag(flags, "interval", time.Second, "Time between checks while learning")
+	flags.Parse(args)
+
+	if *url == "" {
+		fmt.Fprintln(os.Stderr, "learn: --url is required")
+		return 2
+	}
+
+	// Stop early on interrupt and suggest from what has been seen so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+
+	fmt.Fprintf(os.Stderr, "Observing %s every %s for %s (Ctrl-C to stop early)...\n", *url, *interval, *duration)
+	suggestion, err := pingpong.Learn(ctx, pingpong.Target{URL: *url}, *interval)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "learn: %v\n", err)
+		return 1
+	}
+	fmt.Print(suggestion.Env())
+	return 0
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -31,6 +32,42 @@ func (l *ColorLogger) Warn(format string, args ...interface{}) {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "learn":
+			os.Exit(runLearn(os.Args[2:]))
+		case "simulate":
+			os.Exit(runSimulate(os.Args[2:]))
+		case "tui":
+			os.Exit(runTUI(os.Args[2:]))
+		case "config":
+			os.Exit(runConfig(os.Args[2:]))
+		case "targets":
+			os.Exit(runTargets(os.Args[2:]))
+		case "wait":
+			os.Exit(runWait(os.Args[2:]))
+		case "mock":
+			os.Exit(runMock(os.Args[2:]))
+		case "state":
+			os.Exit(runState(os.Args[2:]))
+		}
+	}
+	os.Exit(run())
+}
+
+// options are command-line settings used by run rather than passed on to
+// the service
+type options struct {
+	force    bool
+	strict   bool
+	lockFile string
+	local    *mockSettings // Mock target served with --local, nil otherwise
+}
+
+// loadConfig builds the service configuration from the .env file, the flags
+// defined on flags and parsed from args, environment variables and defaults.
+// Flags take precedence over the environment.
+func loadConfig(flags *flag.FlagSet, args []string) (pingpong.Config, options) {
 	// Load environment variables from .env file if it exists
 	if _, err := os.Stat(".env"); err == nil {
 		if err := godotenv.Load(); err != nil {
@@ -39,39 +76,273 @@ func main() {
 	}
 
 	// Parse command line flags
-	serverURL := flag.String("server-url", "", "Server URL to ping")
-	pingInterval := flag.String("ping-interval", "", "Ping interval in milliseconds")
-	ownURL := flag.String("own-url", "", "Own health check URL")
-	maxRetries := flag.Int("max-retries", 0, "Maximum number of retries")
-	maxConsecutiveFails := flag.Int("max-consecutive-fails", 0, "Maximum number of consecutive failures before shutdown")
-	flag.Parse()
+	serverURL := flags.String("server-url", "", "Server URL to ping")
+	pingInterval := flags.String("ping-interval", "", "Ping interval, e.g. 2s (plain numbers are milliseconds)")
+	ownURL := flags.String("own-url", "", "Own health check URL")
+	ownCheck := flags.String("own-check", "", "When the own health check is called: after_success, always or disabled")
+	maxRetries := flags.Int("max-retries", 0, "Maximum number of retries")
+	maxConsecutiveFails := flags.Int("max-consecutive-fails", 0, "Consecutive failures after which a target is parked, or the service shuts down with a single target")
+	startupHealthy := flags.Bool("startup-healthy", false, "Report healthy until the first check of each target completes")
+	startupGrace := flags.String("startup-grace", "", "Report healthy for this long after startup while targets have no success yet")
+	stateFile := flags.String("state-file", "", "File persisting target state such as the last success across restarts")
+	lockFile := flags.String("lock-file", "", "Lock file preventing duplicate instances (\"auto\" derives one from the server URL)")
+	instanceID := flags.String("instance-id", "", "Instance ID attached to results and status output")
+	labels := flags.String("labels", "", "Comma-separated key=value labels, e.g. region=eu-west,host=web-1")
+	timeout := flags.String("timeout", "", "Timeout for each ping attempt, e.g. 10s")
+	softTimeout := flags.String("soft-timeout", "", "Successful pings slower than this mark the target degraded, e.g. 2s")
+	expectedBody := flags.String("expected-body", "", "String the response body must contain")
+	retryOn := flags.String("retry-on", "", "Comma-separated reason=true|false overrides for which failures are retried, e.g. http_4xx=true")
+	resetAfterSuccesses := flags.Int("reset-after-successes", 0, "Consecutive successes required to reset the failure streak")
+	resetQuietPeriod := flags.String("reset-quiet-period", "", "Reset the failure streak once no failure has been seen for this long")
+	failureThreshold := flags.Int("failure-threshold", 0, "Consecutive failed cycles before the target is considered down")
+	webhookURL := flags.String("webhook-url", "", "Webhook URL receiving result and state change events")
+	webhookCloudEvents := flags.String("webhook-cloudevents", "", "Send webhook events as CloudEvents in \"structured\" or \"binary\" mode")
+	severity := flags.String("severity", "", "Severity of the target: info, warning or critical")
+	harFile := flags.String("har", "", "HAR file whose requests are replayed as a multi-step check")
+	targets := flags.String("targets", "", "Comma-separated additional target URLs, each checked independently")
+	targetsFile := flags.String("targets-file", "", "YAML file of targets with shared defaults and groups")
+	maxConcurrentChecks := flags.Int("max-concurrent-checks", 0, "Maximum number of target checks running at once")
+	checkTimeout := flags.String("check-timeout", "", "Upper bound on one check of a target, including retries, e.g. 30s")
+	peers := flags.String("peers", "", "Comma-separated /status URLs of instances in other regions to compare with")
+	errorRatioChange := flags.Float64("error-ratio-change", 0, "Alert when the share of 4xx/5xx responses rises by this much, e.g. 0.1 for 10 points")
+	force := flags.Bool("force", false, "Start even if another instance holds the lock file")
+	strict := flags.Bool("strict", false, "Refuse to start on invalid values, misspelled environment variables and flags conflicting with the environment")
+	local := flags.Bool("local", false, "Serve a mock target on MOCK_ADDR (default localhost:8081) following MOCK_SCRIPT")
+	flags.Parse(args)
 
 	// Set environment variables from flags if provided
 	if *serverURL != "" {
-		os.Setenv("SERVER_URL", *serverURL)
+		setFlagEnv("SERVER_URL", *serverURL)
 	}
 	if *pingInterval != "" {
-		os.Setenv("PING_INTERVAL", *pingInterval)
+		setFlagEnvAs("PING_INTERVAL", *pingInterval, pingpong.ParseDuration)
 	}
 	if *ownURL != "" {
-		os.Setenv("OWN_URL", *ownURL)
+		setFlagEnv("OWN_URL", *ownURL)
+	}
+	if *ownCheck != "" {
+		setFlagEnv("OWN_CHECK", *ownCheck)
 	}
 	if *maxRetries > 0 {
-		os.Setenv("MAX_RETRIES", strconv.Itoa(*maxRetries))
+		setFlagEnvAs("MAX_RETRIES", strconv.Itoa(*maxRetries), strconv.Atoi)
 	}
 	if *maxConsecutiveFails > 0 {
-		os.Setenv("MAX_CONSECUTIVE_FAILS", strconv.Itoa(*maxConsecutiveFails))
+		setFlagEnvAs("MAX_CONSECUTIVE_FAILS", strconv.Itoa(*maxConsecutiveFails), strconv.Atoi)
+	}
+	if *instanceID != "" {
+		setFlagEnv("INSTANCE_ID", *instanceID)
+	}
+	if *labels != "" {
+		setFlagEnv("LABELS", *labels)
+	}
+	if *timeout != "" {
+		setFlagEnvAs("TIMEOUT", *timeout, pingpong.ParseDuration)
+	}
+	if *softTimeout != "" {
+		setFlagEnvAs("SOFT_TIMEOUT", *softTimeout, pingpong.ParseDuration)
+	}
+	if *expectedBody != "" {
+		setFlagEnv("EXPECTED_BODY", *expectedBody)
+	}
+	if *retryOn != "" {
+		setFlagEnv("RETRY_ON", *retryOn)
+	}
+	if *resetAfterSuccesses > 0 {
+		setFlagEnvAs("RESET_AFTER_SUCCESSES", strconv.Itoa(*resetAfterSuccesses), strconv.Atoi)
+	}
+	if *resetQuietPeriod != "" {
+		setFlagEnvAs("RESET_QUIET_PERIOD", *resetQuietPeriod, pingpong.ParseDuration)
+	}
+	if *failureThreshold > 0 {
+		setFlagEnvAs("FAILURE_THRESHOLD", strconv.Itoa(*failureThreshold), strconv.Atoi)
+	}
+	if *webhookURL != "" {
+		setFlagEnv("WEBHOOK_URL", *webhookURL)
+	}
+	if *webhookCloudEvents != "" {
+		setFlagEnv("WEBHOOK_CLOUDEVENTS", *webhookCloudEvents)
+	}
+	if *severity != "" {
+		setFlagEnv("SEVERITY", *severity)
+	}
+	if *harFile != "" {
+		setFlagEnv("HAR_FILE", *harFile)
+	}
+	if *targets != "" {
+		setFlagEnv("TARGETS", *targets)
+	}
+	if *targetsFile != "" {
+		setFlagEnv("TARGETS_FILE", *targetsFile)
+	}
+	if *maxConcurrentChecks > 0 {
+		setFlagEnvAs("MAX_CONCURRENT_CHECKS", strconv.Itoa(*maxConcurrentChecks), strconv.Atoi)
+	}
+	if *checkTimeout != "" {
+		setFlagEnvAs("CHECK_TIMEOUT", *checkTimeout, pingpong.ParseDuration)
+	}
+	if *peers != "" {
+		setFlagEnv("PEERS", *peers)
+	}
+	if *errorRatioChange > 0 {
+		setFlagEnvAs("ERROR_RATIO_CHANGE", strconv.FormatFloat(*errorRatioChange, 'g', -1, 64), func(value string) (float64, error) {
+			return strconv.ParseFloat(value, 64)
+		})
+	}
+	if *startupHealthy {
+		setFlagEnvAs("STARTUP_HEALTHY", "true", strconv.ParseBool)
+	}
+	if *startupGrace != "" {
+		setFlagEnvAs("STARTUP_GRACE", *startupGrace, pingpong.ParseDuration)
+	}
+	if *stateFile != "" {
+		setFlagEnv("STATE_FILE", *stateFile)
+	}
+	if *lockFile != "" {
+		setFlagEnv("LOCK_FILE", *lockFile)
+	}
+
+	// Load a recorded multi-step check, which names the target unless SERVER_URL is set
+	defaultServerURL := "http://localhost:8081/health"
+	var steps []pingpong.Step
+	if path := getEnv("HAR_FILE"); path != "" {
+		var err error
+		if steps, err = pingpong.LoadHAR(path); err != nil {
+			log.Fatalf("Error loading HAR file: %v", err)
+		}
+		defaultServerURL = ""
+	}
+
+	// Discovered targets replace the default target
+	discovery := buildDiscovery()
+	if len(discovery) > 0 {
+		defaultServerURL = ""
+	}
+
+	// Targets from a targets file replace the default target too
+	notifiers, namedNotifiers := buildNotifiers()
+	configuredTargets := parseTargets(getEnv("TARGETS"))
+	if path := getEnv("TARGETS_FILE"); path != "" {
+		file, err := pingpong.ReadTargetsFile(path)
+		if err != nil {
+			log.Fatalf("Error loading targets file: %v", err)
+		}
+		if file.Version < pingpong.TargetsFileVersion {
+			settings.problem("%s is a version %d targets file: run pingpong config migrate to upgrade it to version %d",
+				path, file.Version, pingpong.TargetsFileVersion)
+		}
+		fileTargets, err := file.Resolve(namedNotifiers)
+		if err != nil {
+			log.Fatalf("Error loading targets file: %s: %v", path, err)
+		}
+		configuredTargets = append(configuredTargets, fileTargets...)
+		defaultServerURL = ""
+	}
+
+	// Remediations apply to every configured target, not just SERVER_URL
+	remediations := buildRemediations()
+	for i := range configuredTargets {
+		configuredTargets[i].Remediations = remediations
 	}
 
 	// Get configuration from environment variables
 	config := pingpong.Config{
-		ServerURL:           getEnvOrDefault("SERVER_URL", "http://localhost:8081/health"),
+		ServerURL:           getEnvOrDefault("SERVER_URL", defaultServerURL),
+		Steps:               steps,
+		Targets:             configuredTargets,
+		MaxConcurrentChecks: getEnvIntOrDefault("MAX_CONCURRENT_CHECKS", 0),
+		MaxTargets:          getEnvIntOrDefault("MAX_TARGETS", 0),
+		MaxHistory:          getEnvIntOrDefault("MAX_HISTORY", 0),
+		MaxQueuedEvents:     getEnvIntOrDefault("MAX_QUEUED_EVENTS", 0),
+		CheckTimeout:        getEnvDurationOrDefault("CHECK_TIMEOUT", 0),
+		Discovery:           discovery,
+		DiscoveryInterval:   getEnvDurationOrDefault("DISCOVERY_INTERVAL", time.Minute),
 		OwnURL:              getEnvOrDefault("OWN_URL", "http://localhost:8080/health"),
-		PingInterval:        time.Duration(getEnvIntOrDefault("PING_INTERVAL", 2000)) * time.Millisecond,
+		OwnCheck:            getEnvEnum("OWN_CHECK", pingpong.OwnCheckAfterSuccess, pingpong.OwnCheckAlways, pingpong.OwnCheckDisabled),
+		PingInterval:        getEnvDurationOrDefault("PING_INTERVAL", 2*time.Second),
 		MaxConsecutiveFails: getEnvIntOrDefault("MAX_CONSECUTIVE_FAILS", 3),
+		ShutdownOnMaxFails:  getEnvBool("SHUTDOWN_ON_MAX_FAILS"),
 		MaxRetries:          getEnvIntOrDefault("MAX_RETRIES", 3),
 		Logger:              &ColorLogger{},
+		InstanceID:          getEnv("INSTANCE_ID"),
+		Labels:              parseLabels(getEnv("LABELS")),
+		Timeout:             getEnvDurationOrDefault("TIMEOUT", 10*time.Second),
+		SoftTimeout:         getEnvDurationOrDefault("SOFT_TIMEOUT", 0),
+		ExpectedBody:        getEnv("EXPECTED_BODY"),
+		HashBody:            getEnvBool("HASH_BODY"),
+		RetryOn:             parseRetryOn(getEnv("RETRY_ON")),
+		FailureThreshold:    getEnvIntOrDefault("FAILURE_THRESHOLD", 1),
+		Severity:            getEnvEnum("SEVERITY", pingpong.SeverityInfo, pingpong.SeverityWarning, pingpong.SeverityCritical),
+		Sinks:               buildSinks(),
+		Notifiers:           notifiers,
+		NamedNotifiers:      namedNotifiers,
+		Remediations:        remediations,
+		Reporters:           buildReporters(),
+		StateFile:           getEnv("STATE_FILE"),
+		StartupHealthy:      getEnvBool("STARTUP_HEALTHY"),
+		StartupGrace:        getEnvDurationOrDefault("STARTUP_GRACE", 0),
+		RemovalPolicy:       getEnvEnum("REMOVAL_POLICY", pingpong.RemovalPurge, pingpong.RemovalRetain),
+		DisplayLocation:     getEnvLocation("DISPLAY_TZ"),
+		ErrorRatio:          buildErrorRatio(),
+		Watchdog:            buildWatchdog(),
+		Seed:                int64(getEnvIntOrDefault("RANDOM_SEED", 0)),
+		Jitter:              getEnvFraction("JITTER"),
+		Peers:               splitList(getEnv("PEERS")),
+		PeerInterval:        getEnvDurationOrDefault("PEER_INTERVAL", 30*time.Second),
+		RegionLabel:         getEnv("REGION_LABEL"),
+		AdminToken:          getEnv("ADMIN_TOKEN"),
+		ResetPolicy: pingpong.ResetPolicy{
+			Successes:   getEnvIntOrDefault("RESET_AFTER_SUCCESSES", 0),
+			QuietPeriod: getEnvDurationOrDefault("RESET_QUIET_PERIOD", 0),
+		},
+	}
+	opts := options{force: *force, strict: *strict || getEnvBool("STRICT_CONFIG"), lockFile: getEnv("LOCK_FILE")}
+	if *local || getEnvBool("LOCAL_MOCK") {
+		opts.local = envMockSettings()
+	}
+	return config, opts
+}
+
+func run() int {
+	config, opts := loadConfig(flag.CommandLine, os.Args[1:])
+
+	// Reject configuration mistakes in strict mode instead of falling back to defaults
+	settings.report(opts.strict)
+
+	// Remember why the service shut down so the exit code can reflect it
+	var shutdownReason pingpong.ShutdownReason
+	config.OnShutdown = func(reason pingpong.ShutdownReason) {
+		shutdownReason = reason
+	}
+
+	// Refuse to start a second instance with the same identity
+	if path := opts.lockFile; path != "" {
+		if path == "auto" {
+			path = pingpong.LockPath(config.InstanceID + " " + config.ServerURL)
+		}
+		lock, err := pingpong.AcquireLock(path, opts.force)
+		if err != nil {
+			log.Fatalf("Failed to acquire lock: %v (use --force to override)", err)
+		}
+		defer lock.Release() // Hence errors below return rather than exit
+	}
+
+	// Serve a mock target to demo alerting without a real service
+	if opts.local != nil {
+		mock, err := startMock(*opts.local)
+		if err != nil {
+			log.Printf("Failed to start mock target: %v", err)
+			return 1
+		}
+		defer mock.Close()
+	}
+
+	// Serve on sockets passed by systemd socket activation, if any
+	listener, err := pingpong.SystemdListener()
+	if err != nil {
+		log.Printf("Error using systemd sockets: %v", err)
+		return 1
 	}
+	config.Listener = listener
 
 	// Create and start the service
 	service := pingpong.NewService(config)
@@ -82,31 +353,201 @@ func main() {
 
 	// Start the service
 	if err := service.Start(ctx); err != nil {
-		log.Fatalf("Failed to start service: %v", err)
+		log.Printf("Failed to start service: %v", err)
+		return 1
 	}
 
-	// Wait for interrupt signal
-	<-ctx.Done()
+	// Wait for interrupt signal or for the service to give up on its own
+	select {
+	case <-ctx.Done():
+	case <-service.Done():
+	}
 
 	// Gracefully shutdown the service
 	if err := service.Stop(); err != nil {
 		log.Printf("Error during shutdown: %v", err)
 	}
+	switch shutdownReason {
+	case pingpong.ShutdownMaxFailures:
+		return 1
+	case pingpong.ShutdownWatchdog:
+		return 3
+	}
+	return 0
 }
 
 // Helper functions
 func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := getEnv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
 func getEnvIntOrDefault(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
+	if value := getEnv(key); value != "" {
+		intValue, err := strconv.Atoi(value)
+		if err == nil {
 			return intValue
 		}
+		settings.problem("invalid %s=%q: expected a whole number", key, value)
 	}
 	return defaultValue
 }
+
+// getEnvBool reports whether a flag-like environment variable is enabled
+func getEnvBool(key string) bool {
+	value := getEnv(key)
+	if value == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		settings.problem("invalid %s=%q: expected true or false", key, value)
+	}
+	return enabled
+}
+
+// getEnvFraction reads a number between 0 and 1 from the environment,
+// defaulting to 0
+func getEnvFraction(key string) float64 {
+	value := getEnv(key)
+	if value == "" {
+		return 0
+	}
+	fraction, err := strconv.ParseFloat(value, 64)
+	if err != nil || fraction < 0 || fraction > 1 {
+		settings.problem("invalid %s=%q: expected a number between 0 and 1", key, value)
+		return 0
+	}
+	return fraction
+}
+
+// getEnvDurationOrDefault reads a duration such as "2s" from the environment,
+// where a plain number is taken as milliseconds
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	if value := getEnv(key); value != "" {
+		duration, err := pingpong.ParseDuration(value)
+		if err == nil {
+			return duration
+		}
+		settings.problem("%s: %v", key, err)
+	}
+	return defaultValue
+}
+
+// getEnvLocation reads a time zone name such as "Europe/Berlin" from the
+// environment, returning nil when it is unset or invalid
+func getEnvLocation(key string) *time.Location {
+	value := getEnv(key)
+	if value == "" {
+		return nil
+	}
+	location, err := time.LoadLocation(value)
+	if err != nil {
+		settings.problem("invalid %s=%q: %v", key, value, err)
+		return nil
+	}
+	return location
+}
+
+// durationValue is a flag.Value parsed with pingpong.ParseDuration, so
+// duration flags accept plain milliseconds as well as "2s"
+type durationValue time.Duration
+
+func (d *durationValue) Set(value string) error {
+	parsed, err := pingpong.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = durationValue(parsed)
+	return nil
+}
+
+func (d *durationValue) String() string {
+	return time.Duration(*d).String()
+}
+
+// durationFlag defines a duration flag on flags like FlagSet.Duration
+func durationFlag(flags *flag.FlagSet, name string, value time.Duration, usage string) *time.Duration {
+	flags.Var((*durationValue)(&value), name, usage)
+	return &value
+}
+
+// parseLabels parses a comma-separated list of key=value pairs
+func parseLabels(value string) map[string]string {
+	if value == "" {
+		return nil
+	}
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		key, val, _ := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); key != "" {
+			labels[key] = strings.TrimSpace(val)
+		}
+	}
+	return labels
+}
+
+// parseTargets parses a comma-separated list of target URLs
+func parseTargets(value string) []pingpong.Target {
+	var targets []pingpong.Target
+	for _, url := range splitList(value) {
+		targets = append(targets, pingpong.Target{URL: url})
+	}
+	return targets
+}
+
+// buildErrorRatio returns the error ratio alert configured through
+// environment variables, or nil when it is disabled
+func buildErrorRatio() *pingpong.ErrorRatioAlert {
+	value := getEnv("ERROR_RATIO_CHANGE")
+	if value == "" {
+		return nil
+	}
+	change, err := strconv.ParseFloat(value, 64)
+	if err != nil || change <= 0 || change > 1 {
+		log.Fatalf("Invalid ERROR_RATIO_CHANGE %q: must be between 0 and 1", value)
+	}
+	return &pingpong.ErrorRatioAlert{
+		Window:     getEnvDurationOrDefault("ERROR_RATIO_WINDOW", 0),
+		Baseline:   getEnvDurationOrDefault("ERROR_RATIO_BASELINE", 0),
+		Change:     change,
+		MinSamples: getEnvIntOrDefault("ERROR_RATIO_MIN_SAMPLES", 0),
+	}
+}
+
+// buildWatchdog returns the watchdog configured through environment
+// variables, or nil when WATCHDOG_ACTION is unset. An invalid action is
+// fatal rather than silently leaving the watchdog off.
+func buildWatchdog() *pingpong.Watchdog {
+	action := pingpong.WatchdogAction(getEnv("WATCHDOG_ACTION"))
+	switch action {
+	case "":
+		return nil
+	case pingpong.WatchdogLog, pingpong.WatchdogRestart, pingpong.WatchdogExit:
+	default:
+		log.Fatalf("Invalid WATCHDOG_ACTION %q: must be log, restart or exit", action)
+	}
+	return &pingpong.Watchdog{
+		Multiple: getEnvIntOrDefault("WATCHDOG_MULTIPLE", 0),
+		Action:   action,
+	}
+}
+
+// parseRetryOn parses a comma-separated list of reason=true|false overrides
+func parseRetryOn(value string) map[pingpong.FailureReason]bool {
+	pairs := parseLabels(value)
+	if len(pairs) == 0 {
+		return nil
+	}
+	retryOn := make(map[pingpong.FailureReason]bool, len(pairs))
+	for reason, retry := range pairs {
+		enabled, err := strconv.ParseBool(retry)
+		if err != nil {
+			log.Fatalf("Invalid RETRY_ON value for %s: %q", reason, retry)
+		}
+		retryOn[pingpong.FailureReason(reason)] = enabled
+	}
+	return retryOn
+}
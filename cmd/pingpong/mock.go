@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/SumonRayy/ping-pong-go/pkg/pingpong"
+)
+
+// runMock implements `pingpong mock`, which serves a scripted mock target to
+// demo and test alerting end to end
+func runMock(args []string) int {
+	flags := flag.NewFlagSet("mock", flag.ExitOnError)
+	defaults := envMockSettings()
+	addr := flags.String("addr", defaults.addr, "Address to serve the mock target on")
+	script := flags.String("script", defaults.script, `Answers to give in a loop, e.g. "ok×5,500×3,timeout×2"`)
+	latency := durationFlag(flags, "latency", defaults.latency, "Delay before every answer")
+	flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server, err := startMock(mockSettings{addr: *addr, script: *script, latency: *latency})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mock: %v\n", err)
+		return 2
+	}
+	<-ctx.Done()
+	server.Close()
+	return 0
+}
+
+// mockSettings configure a mock target
+type mockSettings struct {
+	addr    string
+	script  string
+	latency time.Duration
+}
+
+// envMockSettings reads the mock target settings from MOCK_ADDR, MOCK_SCRIPT
+// and MOCK_LATENCY
+func envMockSettings() *mockSettings {
+	return &mockSettings{
+		addr:    getEnvOrDefault("MOCK_ADDR", "localhost:8081"),
+		script:  getEnvOrDefault("MOCK_SCRIPT", "ok"),
+		latency: getEnvDurationOrDefault("MOCK_LATENCY", 0),
+	}
+}
+
+// startMock serves a mock target in the background
+func startMock(mock mockSettings) (*http.Server, error) {
+	steps, err := pingpong.ParseMockScript(mock.script)
+	if err != nil {
+		return nil, fmt.Errorf("invalid script: %w", err)
+	}
+	listener, err := net.Listen("tcp", mock.addr)
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{Handler: pingpong.NewMockTarget(steps, mock.latency)}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "mock: %v\n", err)
+		}
+	}()
+	fmt.Fprintf(os.Stderr, "Mock target answering %q on http://%s (change it with POST /mock/control)\n", mock.script, listener.Addr())
+	return server, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"time"
+
+	"github.com/SumonRayy/ping-pong-go/pkg/pingpong"
+)
+
+// buildRemediations creates the remediation actions configured through
+// environment variables. They share the REMEDIATION_AFTER,
+// REMEDIATION_COOLDOWN and REMEDIATION_MAX_EXECUTIONS limits.
+func buildRemediations() []pingpong.Remediation {
+	var actions []pingpong.Action
+	var names []string
+
+	if url := getEnv("REMEDIATION_URL"); url != "" {
+		actions = append(actions, &pingpong.HTTPAction{URL: url})
+		names = append(names, "http")
+	}
+
+	if command := getEnv("REMEDIATION_COMMAND"); command != "" {
+		actions = append(actions, &pingpong.ExecAction{Command: []string{"sh", "-c", command}})
+		names = append(names, "command")
+	}
+
+	kubernetes := func(operation pingpong.KubernetesOperation) *pingpong.KubernetesAction {
+		return &pingpong.KubernetesAction{
+			Operation: operation,
+			APIServer: getEnv("K8S_API_SERVER"),
+			Token:     getEnv("K8S_TOKEN"),
+			Namespace: getEnv("REMEDIATION_K8S_NAMESPACE"),
+		}
+	}
+	if deployment := getEnv("REMEDIATION_K8S_DEPLOYMENT"); deployment != "" {
+		action := kubernetes(pingpong.KubernetesRestartDeployment)
+		action.Deployment = deployment
+		actions = append(actions, action)
+		names = append(names, "restart-deployment")
+	}
+	pod, selector := getEnv("REMEDIATION_K8S_POD"), getEnv("REMEDIATION_K8S_POD_SELECTOR")
+	if pod != "" || selector != "" {
+		action := kubernetes(pingpong.KubernetesDeletePod)
+		action.Pod = pod
+		action.LabelSelector = selector
+		actions = append(actions, action)
+		names = append(names, "delete-pod")
+	}
+	if node := getEnv("REMEDIATION_K8S_NODE"); node != "" {
+		action := kubernetes(pingpong.KubernetesCordonNode)
+		action.Node = node
+		actions = append(actions, action)
+		names = append(names, "cordon-node")
+	}
+
+	remediations := make([]pingpong.Remediation, len(actions))
+	for i, action := range actions {
+		remediations[i] = pingpong.Remediation{
+			Name:          names[i],
+			Action:        action,
+			After:         getEnvIntOrDefault("REMEDIATION_AFTER", 0),
+			Cooldown:      getEnvDurationOrDefault("REMEDIATION_COOLDOWN", 5*time.Minute),
+			MaxExecutions: getEnvIntOrDefault("REMEDIATION_MAX_EXECUTIONS", 3),
+		}
+	}
+	return remediations
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/SumonRayy/ping-pong-go/pkg/pingpong"
+)
+
+// runSimulate implements `pingpong simulate`, which replays recorded history
+// against proposed thresholds and prints how many alerts and shutdowns they
+// would have caused
+func runSimulate(args []string) int {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	history := flags.String("history", getEnv("HISTORY_FILE"), "History file written with HISTORY_FILE")
+	maxFails := flags.Int("max-fails", getEnvIntOrDefault("MAX_CONSECUTIVE_FAILS", 3), "Consecutive failures before shutdown (0 never shuts down)")
+	failureThreshold := flags.Int("failure-threshold", getEnvIntOrDefault("FAILURE_THRESHOLD", 1), "Consecutive failed cycles before a target is considered down")
+	window := durationFlag(flags, "window", 0, "Count outages starting within this window of each other as one alert")
+	resetAfterSuccesses := flags.Int("reset-after-successes", getEnvIntOrDefault("RESET_AFTER_SUCCESSES", 0), "Consecutive successes required to reset the failure streak")
+	resetQuietPeriod := durationFlag(flags, "reset-quiet-period", getEnvDurationOrDefault("RESET_QUIET_PERIOD", 0), "Reset the failure streak once no failure has been seen for this long")
+	flags.Parse(args)
+
+	if *history == "" {
+		fmt.Fprintln(os.Stderr, "simulate: --history is required")
+		return 2
+	}
+	results, err := pingpong.LoadHistory(*history)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "simulate: %v\n", err)
+		return 1
+	}
+
+	report := pingpong.Simulation{
+		FailureThreshold:    *failureThreshold,
+		MaxConsecutiveFails: *maxFails,
+		Window:              *window,
+		ResetPolicy: pingpong.ResetPolicy{
+			Successes:   *resetAfterSuccesses,
+			QuietPeriod: *resetQuietPeriod,
+		},
+	}.Simulate(results)
+	fmt.Print(report)
+	return 0
+}
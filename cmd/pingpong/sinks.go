@@ -0,0 +1,244 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/SumonRayy/ping-pong-go/pkg/pingpong"
+)
+
+// buildSinks creates the event sinks configured through environment variables
+func buildSinks() []pingpong.Sink {
+	var sinks []pingpong.Sink
+
+	if path := getEnv("HISTORY_FILE"); path != "" {
+		sinks = append(sinks, &pingpong.HistorySink{Path: path})
+	}
+
+	if url := getEnv("WEBHOOK_URL"); url != "" {
+		sink := &pingpong.WebhookSink{
+			URL:         url,
+			CloudEvents: getEnvEnum("WEBHOOK_CLOUDEVENTS", pingpong.CloudEventsStructured, pingpong.CloudEventsBinary),
+			Secrets:     splitList(getEnv("WEBHOOK_SECRETS")),
+		}
+		if cert := getEnv("WEBHOOK_CLIENT_CERT"); cert != "" {
+			client, err := pingpong.NewMTLSClient(cert, getEnv("WEBHOOK_CLIENT_KEY"), getEnv("WEBHOOK_CA_FILE"))
+			if err != nil {
+				log.Fatalf("Invalid webhook client certificate: %v", err)
+			}
+			sink.Client = client
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if bus := getEnv("EVENTBRIDGE_BUS"); bus != "" {
+		region := getEnvOrDefault("EVENTBRIDGE_REGION", getEnv("AWS_REGION"))
+		if region == "" {
+			settings.problem("EVENTBRIDGE_BUS needs EVENTBRIDGE_REGION or AWS_REGION")
+		}
+		sinks = append(sinks, &pingpong.EventBridgeSink{
+			Region:       region,
+			EventBusName: bus,
+			Source:       getEnv("EVENTBRIDGE_SOURCE"),
+			DetailType:   getEnv("EVENTBRIDGE_DETAIL_TYPE"),
+		})
+	}
+
+	if topic := getEnv("PUBSUB_TOPIC"); topic != "" {
+		sink := &pingpong.PubSubSink{
+			Project:          getEnv("PUBSUB_PROJECT"),
+			Topic:            topic,
+			StateChangesOnly: getEnvBool("PUBSUB_STATE_CHANGES_ONLY"),
+		}
+		if token := getEnv("PUBSUB_TOKEN"); token != "" {
+			sink.TokenSource = pingpong.StaticGCPToken(token)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if resourceID := getEnv("AZURE_MONITOR_RESOURCE_ID"); resourceID != "" {
+		sink := &pingpong.AzureMonitorSink{
+			Region:     getEnv("AZURE_MONITOR_REGION"),
+			ResourceID: resourceID,
+		}
+		if token := getEnv("AZURE_TOKEN"); token != "" {
+			sink.TokenSource = pingpong.StaticAzureToken(token)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if endpoint := getEnv("EVENTGRID_ENDPOINT"); endpoint != "" {
+		sinks = append(sinks, &pingpong.EventGridSink{
+			Endpoint: endpoint,
+			Key:      getEnv("EVENTGRID_KEY"),
+		})
+	}
+
+	sha, deploymentID := getEnv("GITHUB_STATUS_SHA"), getEnvIntOrDefault("GITHUB_DEPLOYMENT_ID", 0)
+	if sha != "" || deploymentID != 0 {
+		owner, repo, _ := strings.Cut(getEnv("GITHUB_REPOSITORY"), "/")
+		sinks = append(sinks, &pingpong.GitHubStatusReporter{
+			Owner:        owner,
+			Repo:         repo,
+			Token:        getEnv("GITHUB_TOKEN"),
+			SHA:          sha,
+			DeploymentID: int64(deploymentID),
+			Window:       getEnvDurationOrDefault("GITHUB_STATUS_WINDOW", 0),
+			Targets:      splitList(getEnv("GITHUB_STATUS_TARGETS")),
+			APIURL:       getEnv("GITHUB_API_URL"),
+		})
+	}
+
+	if pageID := getEnv("STATUSPAGE_PAGE_ID"); pageID != "" {
+		sinks = append(sinks, &pingpong.StatuspageSink{
+			PageID:      pageID,
+			APIKey:      getEnv("STATUSPAGE_API_KEY"),
+			ComponentID: getEnv("STATUSPAGE_COMPONENT_ID"),
+		})
+	}
+
+	if url := getEnv("CACHET_URL"); url != "" {
+		sinks = append(sinks, &pingpong.CachetSink{
+			URL:         url,
+			Token:       getEnv("CACHET_TOKEN"),
+			ComponentID: getEnvIntOrDefault("CACHET_COMPONENT_ID", 0),
+			Incidents:   getEnvBool("CACHET_INCIDENTS"),
+		})
+	}
+
+	if url := getEnv("GATUS_URL"); url != "" {
+		sinks = append(sinks, &pingpong.GatusSink{
+			URL:   url,
+			Token: getEnv("GATUS_TOKEN"),
+			Key:   getEnv("GATUS_KEY"),
+		})
+	}
+
+	return sinks
+}
+
+// buildNotifiers creates the notifiers configured through environment
+// variables, also returned by name (twilio, ntfy, gotify, matrix, desktop) so a
+// targets file can refer to them
+func buildNotifiers() ([]pingpong.Sink, map[string]pingpong.Sink) {
+	var notifiers []pingpong.Sink
+	var names []string
+	degraded := getEnvBool("NOTIFY_DEGRADED")
+
+	if sid := getEnv("TWILIO_ACCOUNT_SID"); sid != "" {
+		notifiers = append(notifiers, &pingpong.TwilioNotifier{
+			AccountSID: sid,
+			AuthToken:  getEnv("TWILIO_AUTH_TOKEN"),
+			From:       getEnv("TWILIO_FROM"),
+			To:         splitList(getEnv("TWILIO_TO")),
+			Call:       getEnvBool("TWILIO_CALL"),
+			Degraded:   degraded,
+		})
+		names = append(names, "twilio")
+	}
+
+	if topic := getEnv("NTFY_TOPIC"); topic != "" {
+		notifiers = append(notifiers, &pingpong.NtfyNotifier{
+			ServerURL:  getEnv("NTFY_URL"),
+			Topic:      topic,
+			Token:      getEnv("NTFY_TOKEN"),
+			AdminURL:   getEnv("ADMIN_URL"),
+			AdminToken: getEnv("ADMIN_TOKEN"),
+			Degraded:   degraded,
+		})
+		names = append(names, "ntfy")
+	}
+
+	if url := getEnv("GOTIFY_URL"); url != "" {
+		notifiers = append(notifiers, &pingpong.GotifyNotifier{
+			ServerURL: url,
+			AppToken:  getEnv("GOTIFY_TOKEN"),
+			AdminURL:  getEnv("ADMIN_URL"),
+			Degraded:  degraded,
+		})
+		names = append(names, "gotify")
+	}
+
+	if room := getEnv("MATRIX_ROOM_ID"); room != "" {
+		notifiers = append(notifiers, &pingpong.MatrixNotifier{
+			HomeserverURL: getEnv("MATRIX_HOMESERVER_URL"),
+			AccessToken:   getEnv("MATRIX_ACCESS_TOKEN"),
+			RoomID:        room,
+			Degraded:      degraded,
+		})
+		names = append(names, "matrix")
+	}
+
+	if getEnvBool("DESKTOP_NOTIFICATIONS") {
+		notifiers = append(notifiers, &pingpong.DesktopNotifier{Degraded: degraded})
+		names = append(names, "desktop")
+	}
+
+	notifiers = digest(throttle(notifiers))
+	named := make(map[string]pingpong.Sink, len(notifiers))
+	for i, notifier := range notifiers {
+		named[names[i]] = notifier
+	}
+	return notifiers, named
+}
+
+// digest wraps every notifier in a DigestNotifier when DIGEST_WINDOW is set
+func digest(notifiers []pingpong.Sink) []pingpong.Sink {
+	window := getEnvDurationOrDefault("DIGEST_WINDOW", 0)
+	if window <= 0 {
+		return notifiers
+	}
+
+	digested := make([]pingpong.Sink, len(notifiers))
+	for i, notifier := range notifiers {
+		digested[i] = &pingpong.DigestNotifier{Notifier: notifier, Window: window, GroupBy: getEnv("DIGEST_GROUP_BY"), Degraded: getEnvBool("NOTIFY_DEGRADED")}
+	}
+	return digested
+}
+
+// throttle wraps every notifier with the rate limit and quiet hours from
+// NOTIFY_RATE_LIMIT and QUIET_HOURS
+func throttle(notifiers []pingpong.Sink) []pingpong.Sink {
+	limit := getEnvIntOrDefault("NOTIFY_RATE_LIMIT", 0)
+	var quietHours []pingpong.QuietHours
+	for _, window := range splitList(getEnv("QUIET_HOURS")) {
+		quiet, err := pingpong.ParseQuietHours(window)
+		if err != nil {
+			log.Fatalf("Invalid QUIET_HOURS: %v", err)
+		}
+		quietHours = append(quietHours, quiet)
+	}
+	if limit == 0 && len(quietHours) == 0 {
+		return notifiers
+	}
+
+	throttled := make([]pingpong.Sink, len(notifiers))
+	for i, notifier := range notifiers {
+		throttled[i] = &pingpong.ThrottledNotifier{Notifier: notifier, Limit: limit, QuietHours: quietHours}
+	}
+	return throttled
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// buildReporters creates the self-report receivers configured through
+// environment variables
+func buildReporters() []pingpong.Reporter {
+	var reporters []pingpong.Reporter
+	for _, url := range splitList(getEnv("REGISTRAR_URLS")) {
+		reporters = append(reporters, &pingpong.RegistrarReporter{
+			URL:   url,
+			Token: getEnv("REGISTRAR_TOKEN"),
+		})
+	}
+	return reporters
+}
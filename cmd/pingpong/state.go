@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/SumonRayy/ping-pong-go/pkg/pingpong"
+	"github.com/SumonRayy/ping-pong-go/pkg/pingpong/client"
+)
+
+// runState implements `pingpong state export` and `pingpong state import`,
+// which move the operational state of a running instance to another one
+func runState(args []string) int {
+	if len(args) == 0 || (args[0] != "export" && args[0] != "import") {
+		fmt.Fprintln(os.Stderr, "usage: pingpong state export|import [--api http://host:8080] state.json")
+		return 2
+	}
+	command := args[0]
+	flags := flag.NewFlagSet("state "+command, flag.ExitOnError)
+	url := flags.String("api", "http://localhost:8080", "Base URL of the running instance")
+	token := flags.String("token", getEnv("ADMIN_TOKEN"), "Admin API token")
+	flags.Parse(args[1:])
+	if flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "state %s: expected one file (- for stdout or stdin)\n", command)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	api := client.New(*url, *token)
+	var err error
+	if command == "export" {
+		err = exportState(ctx, api, flags.Arg(0))
+	} else {
+		err = importState(ctx, api, flags.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "state %s: %v\n", command, err)
+		return 1
+	}
+	return 0
+}
+
+// exportState writes the snapshot of a running instance to path, or stdout
+// for "-"
+func exportState(ctx context.Context, api *client.Client, path string) error {
+	snapshot, err := api.ExportState(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported the state of %d targets to %s\n", len(snapshot.Targets), path)
+	return nil
+}
+
+// importState applies the snapshot in path, or stdin for "-", to a running
+// instance
+func importState(ctx context.Context, api *client.Client, path string) error {
+	var snapshot pingpong.Snapshot
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	if err := json.NewDecoder(in).Decode(&snapshot); err != nil {
+		return fmt.Errorf("invalid snapshot: %w", err)
+	}
+	result, err := api.ImportState(ctx, snapshot)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Restored %d targets, added %d, kept %d for later\n", len(result.Restored), len(result.Added), len(result.Pending))
+	if len(result.Redacted) > 0 {
+		fmt.Fprintf(os.Stderr, "Secrets of %s were redacted on export: remove and add them again with their secrets\n", strings.Join(result.Redacted, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// configCheck records the environment variables the CLI reads and the
+// problems found in its configuration. Strict mode refuses to start when
+// there are any; otherwise they are logged as warnings and defaults apply.
+type configCheck struct {
+	read     map[string]bool
+	problems []string
+}
+
+var settings = &configCheck{read: make(map[string]bool)}
+
+// getEnv reads an environment variable and remembers that it is known
+func getEnv(key string) string {
+	settings.read[key] = true
+	return os.Getenv(key)
+}
+
+// problem records a configuration mistake
+func (c *configCheck) problem(format string, args ...interface{}) {
+	c.problems = append(c.problems, fmt.Sprintf(format, args...))
+}
+
+// setFlagEnv passes a flag value on through the environment, recording a
+// conflict when the environment already holds a different value
+func setFlagEnv(key, value string) {
+	setFlagEnvAs(key, value, func(value string) (string, error) { return value, nil })
+}
+
+// setFlagEnvAs is setFlagEnv for values compared once parsed, so that
+// MAX_RETRIES=03 agrees with --max-retries 3 and PING_INTERVAL=2000 with
+// --ping-interval 2s
+func setFlagEnvAs[T comparable](key, value string, parse func(string) (T, error)) {
+	if env := os.Getenv(key); env != "" && env != value {
+		parsedEnv, envErr := parse(env)
+		parsed, err := parse(value)
+		if envErr != nil || err != nil || parsedEnv != parsed {
+			settings.problem("flag value %q conflicts with %s=%q", value, key, env)
+		}
+	}
+	os.Setenv(key, value)
+}
+
+// getEnvEnum reads a value of a string enum from the environment, recording
+// a problem and returning "" so the default applies when it is not one of
+// allowed
+func getEnvEnum[T ~string](key string, allowed ...T) T {
+	value := T(getEnv(key))
+	if value == "" || slices.Contains(allowed, value) {
+		return value
+	}
+	names := make([]string, len(allowed))
+	for i, name := range allowed {
+		names[i] = string(name)
+	}
+	settings.problem("invalid %s=%q: expected one of %s", key, value, strings.Join(names, ", "))
+	return ""
+}
+
+// unknownEnv records environment variables that were never read but look
+// like a misspelling of one that was, such as PING_INTERVALL
+func (c *configCheck) unknownEnv() {
+	var unknown []string
+	for _, entry := range os.Environ() {
+		key, _, _ := strings.Cut(entry, "=")
+		if c.read[key] {
+			continue
+		}
+		if match := c.closest(key); match != "" {
+			unknown = append(unknown, fmt.Sprintf("unknown environment variable %s (did you mean %s?)", key, match))
+		}
+	}
+	sort.Strings(unknown)
+	c.problems = append(c.problems, unknown...)
+}
+
+// closest returns the known variable within a small edit distance of key
+func (c *configCheck) closest(key string) string {
+	limit := 1
+	if len(key) >= 8 {
+		limit = 2
+	}
+	best, bestDistance := "", limit+1
+	for known := range c.read {
+		if d := editDistance(key, known); d < bestDistance || (d == bestDistance && known < best) {
+			best, bestDistance = known, d
+		}
+	}
+	if bestDistance > limit {
+		return ""
+	}
+	return best
+}
+
+// report fails in strict mode when problems were found, and logs them as
+// warnings otherwise
+func (c *configCheck) report(strict bool) {
+	c.unknownEnv()
+	if len(c.problems) == 0 {
+		return
+	}
+	if strict {
+		log.Fatalf("Invalid configuration (strict mode):\n  %s", strings.Join(c.problems, "\n  "))
+	}
+	for _, problem := range c.problems {
+		log.Printf("Configuration warning: %s", problem)
+	}
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/SumonRayy/ping-pong-go/pkg/pingpong"
+	"github.com/SumonRayy/ping-pong-go/pkg/pingpong/client"
+	"gopkg.in/yaml.v3"
+)
+
+// runTargets implements `pingpong targets import`, which onboards a list of
+// URLs or a CSV inventory into a targets file or a running instance
+func runTargets(args []string) int {
+	if len(args) == 0 || args[0] != "import" {
+		fmt.Fprintln(os.Stderr, "usage: pingpong targets import [--into targets.yaml | --api http://host:8080] urls.txt")
+		return 2
+	}
+	flags := flag.NewFlagSet("targets import", flag.ExitOnError)
+	into := flags.String("into", "", "Targets file to merge the imported targets into, created if missing")
+	api := flags.String("api", "", "Base URL of a running instance to add the targets to through its admin API")
+	token := flags.String("token", getEnv("ADMIN_TOKEN"), "Admin API token")
+	flags.Parse(args[1:])
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "targets import: expected one file (- for stdin)")
+		return 2
+	}
+
+	entries, err := readTargetList(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "targets import: %v\n", err)
+		return 1
+	}
+
+	switch {
+	case *api != "":
+		err = postTargets(*api, *token, entries)
+	case *into != "":
+		err = mergeTargets(*into, entries)
+	default:
+		file := pingpong.TargetsFile{Version: pingpong.TargetsFileVersion}
+		file.Merge(entries)
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		err = encoder.Encode(file)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "targets import: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// readTargetList parses a target list from a file, or stdin for "-"
+func readTargetList(path string) ([]pingpong.TargetEntry, error) {
+	if path == "-" {
+		return pingpong.ParseTargetList(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return pingpong.ParseTargetList(f)
+}
+
+// mergeTargets adds entries to a targets file, skipping names and URLs it
+// already has and keeping its comments
+func mergeTargets(path string, entries []pingpong.TargetEntry) error {
+	perm := fs.FileMode(0o644)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	data, added, err := pingpong.MergeTargetsYAML(data, entries)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Added %d targets to %s (%d already present)\n", added, path, len(entries)-added)
+	return nil
+}
+
+// postTargets adds entries to a running instance through its admin API
+func postTargets(api, token string, entries []pingpong.TargetEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	result, err := client.New(api, token).AddTargets(ctx, entries)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Added %d targets to %s (%d already present)\n", len(result.Added), api, result.Skipped)
+	return nil
+}
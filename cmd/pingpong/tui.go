@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/SumonRayy/ping-pong-go/pkg/pingpong"
+	"github.com/fatih/color"
+)
+
+// tuiEventLimit is the number of recent events shown by the dashboard
+const tuiEventLimit = 10
+
+// tuiEvent is a change noticed between two polls of the status endpoint
+type tuiEvent struct {
+	time    time.Time
+	message string
+	color   *color.Color
+}
+
+// runTUI implements `pingpong tui`, a live terminal dashboard of a running
+// instance for operators who live in SSH sessions
+func runTUI(args []string) int {
+	flags := flag.NewFlagSet("tui", flag.ExitOnError)
+	url := flags.String("url", "http://localhost:8080", "Base URL of the pingpong instance to watch")
+	interval := durationFlag(flags, "interval", time.Second, "How often the dashboard is refreshed")
+	tz := flags.String("tz", getEnv("DISPLAY_TZ"), "Time zone of the times shown (default local)")
+	state := flags.String("state", "", "Only show targets in these comma-separated states, e.g. down,degraded")
+	search := flags.String("search", "", "Only show targets whose name or URL contains this")
+	sort := flags.String("sort", "", "Order targets by name, state, latency, uptime or last_change; prefix with - to reverse")
+	limit := flags.Int("limit", 0, "Show at most this many targets (default all)")
+	flags.Parse(args)
+
+	location := time.Local
+	if *tz != "" {
+		var err error
+		if location, err = time.LoadLocation(*tz); err != nil {
+			fmt.Fprintf(os.Stderr, "tui: invalid --tz: %v\n", err)
+			return 2
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Draw on the alternate screen and restore the terminal on exit
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	query := neturl.Values{}
+	for key, value := range map[string]string{"state": *state, "search": *search, "sort": *sort} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	if *limit > 0 {
+		query.Set("limit", strconv.Itoa(*limit))
+	}
+	statusURL := strings.TrimSuffix(*url, "/") + "/status"
+	if len(query) > 0 {
+		statusURL += "?" + query.Encode()
+	}
+	var previous *pingpong.Status
+	var events []tuiEvent
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		status, err := fetchStatus(ctx, client, statusURL)
+		if err == nil {
+			events = append(events, statusEvents(previous, status)...)
+			if len(events) > tuiEventLimit {
+				events = events[len(events)-tuiEventLimit:]
+			}
+			previous = &status
+		}
+		fmt.Print("\x1b[H\x1b[2J" + renderDashboard(*url, previous, err, events, location))
+
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetchStatus reads the status of an instance
+func fetchStatus(ctx context.Context, client *http.Client, url string) (pingpong.Status, error) {
+	var status pingpong.Status
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return status, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return status, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return status, fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return status, json.NewDecoder(resp.Body).Decode(&status)
+}
+
+// statusEvents returns the state changes and new failures between two
+// snapshots
+func statusEvents(previous *pingpong.Status, current pingpong.Status) []tuiEvent {
+	if previous == nil {
+		return nil
+	}
+	before := make(map[string]pingpong.TargetStatus, len(previous.Targets))
+	for _, target := range previous.Targets {
+		before[target.Name] = target
+	}
+
+	var events []tuiEvent
+	for _, target := range current.Targets {
+		old, ok := before[target.Name]
+		if !ok {
+			events = append(events, tuiEvent{time.Now(), target.Name + " added", color.New(color.FgCyan)})
+			continue
+		}
+		if target.State != old.State {
+			events = append(events, tuiEvent{time.Now(), fmt.Sprintf("%s is %s", target.Name, strings.ToUpper(string(target.State))), stateColor(target.State)})
+		}
+		// Report the first failure of a streak and changes of failure reason
+		result, last := target.LastResult, old.LastResult
+		if result != nil && !result.Success && (last == nil || last.Success || last.Reason != result.Reason) {
+			events = append(events, tuiEvent{result.Time, fmt.Sprintf("%s check failed (%s: %s)", target.Name, result.Reason, result.Error), color.New(color.FgRed)})
+		}
+		delete(before, target.Name)
+	}
+	for _, target := range previous.Targets {
+		if _, ok := before[target.Name]; ok {
+			events = append(events, tuiEvent{time.Now(), target.Name + " removed", color.New(color.FgCyan)})
+		}
+	}
+	return events
+}
+
+// renderDashboard renders one frame of the dashboard
+func renderDashboard(url string, status *pingpong.Status, err error, events []tuiEvent, location *time.Location) string {
+	var b strings.Builder
+	bold := color.New(color.Bold)
+	b.WriteString(bold.Sprint("pingpong") + " " + url)
+	if status != nil {
+		fmt.Fprintf(&b, "  instance %s  %s", status.InstanceID, stateColor(status.State).Sprint(strings.ToUpper(string(status.State))))
+		if status.SilencedUntil != nil {
+			fmt.Fprintf(&b, "  silenced until %s", status.SilencedUntil.In(location).Format("15:04"))
+		}
+	}
+	fmt.Fprintf(&b, "  %s\n", time.Now().In(location).Format("15:04:05 MST"))
+	if err != nil {
+		b.WriteString(color.New(color.FgRed).Sprintf("Error: %v\n", err))
+	}
+	b.WriteString("\n")
+
+	if status != nil {
+		b.WriteString(bold.Sprintf("%-32s %-9s %10s %6s %14s  %s\n", "TARGET", "STATE", "LATENCY", "FAILS", "LAST SUCCESS", "LAST ERROR"))
+		for _, target := range status.Targets {
+			latency, lastError := "-", ""
+			if target.LastResult != nil {
+				latency = target.LastResult.Latency.Round(time.Millisecond).String()
+				lastError = target.LastResult.Error
+			}
+			lastSuccess := "never"
+			if target.LastSuccess != nil {
+				lastSuccess = time.Since(*target.LastSuccess).Round(time.Second).String() + " ago"
+			}
+			fmt.Fprintf(&b, "%-32s %s %10s %6d %14s  %s\n",
+				clip(target.Name, 32), stateColor(target.State).Sprintf("%-9s", target.State), latency,
+				target.ConsecutiveFailures, lastSuccess, clip(lastError, 60))
+		}
+	}
+
+	b.WriteString("\n" + bold.Sprint("Recent events") + "\n")
+	if len(events) == 0 {
+		b.WriteString("none yet\n")
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		fmt.Fprintf(&b, "%s %s\n", events[i].time.In(location).Format("15:04:05"), events[i].color.Sprint(events[i].message))
+	}
+	b.WriteString("\nPress Ctrl-C to quit\n")
+	return b.String()
+}
+
+// stateColor returns the color a state is shown in
+func stateColor(state pingpong.State) *color.Color {
+	switch state {
+	case pingpong.StateUp:
+		return color.New(color.FgGreen)
+	case pingpong.StateDegraded:
+		return color.New(color.FgYellow)
+	case pingpong.StateDown:
+		return color.New(color.FgRed, color.Bold)
+	default:
+		return color.New(color.FgWhite)
+	}
+}
+
+// clip shortens s to at most n runes
+func clip(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
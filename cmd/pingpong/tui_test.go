@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SumonRayy/ping-pong-go/pkg/pingpong"
+)
+
+func TestStatusEvents(t *testing.T) {
+	now := time.Now()
+	failed := func(reason pingpong.FailureReason) *pingpong.Result {
+		return &pingpong.Result{Time: now, Reason: reason, Error: "boom"}
+	}
+	snapshot := func(targets ...pingpong.TargetStatus) *pingpong.Status {
+		return &pingpong.Status{Targets: targets}
+	}
+
+	tests := []struct {
+		name     string
+		previous *pingpong.Status
+		current  *pingpong.Status
+		want     []string
+	}{
+		{
+			name:    "first poll",
+			current: snapshot(pingpong.TargetStatus{Name: "api", State: pingpong.StateDown, LastResult: failed(pingpong.FailureDNS)}),
+		},
+		{
+			name:     "added and removed",
+			previous: snapshot(pingpong.TargetStatus{Name: "api"}, pingpong.TargetStatus{Name: "db"}, pingpong.TargetStatus{Name: "cache"}),
+			current:  snapshot(pingpong.TargetStatus{Name: "api"}, pingpong.TargetStatus{Name: "queue"}),
+			want:     []string{"queue added", "db removed", "cache removed"},
+		},
+		{
+			name:     "state change",
+			previous: snapshot(pingpong.TargetStatus{Name: "api", State: pingpong.StateUp}),
+			current:  snapshot(pingpong.TargetStatus{Name: "api", State: pingpong.StateDegraded}),
+			want:     []string{"api is DEGRADED"},
+		},
+		{
+			name:     "first failure of a streak",
+			previous: snapshot(pingpong.TargetStatus{Name: "api", State: pingpong.StateUp, LastResult: &pingpong.Result{Success: true}}),
+			current:  snapshot(pingpong.TargetStatus{Name: "api", State: pingpong.StateUp, LastResult: failed(pingpong.FailureDNS)}),
+			want:     []string{"api check failed (dns_error: boom)"},
+		},
+		{
+			name:     "repeated failure",
+			previous: snapshot(pingpong.TargetStatus{Name: "api", State: pingpong.StateDown, LastResult: failed(pingpong.FailureDNS)}),
+			current:  snapshot(pingpong.TargetStatus{Name: "api", State: pingpong.StateDown, LastResult: failed(pingpong.FailureDNS)}),
+		},
+		{
+			name:     "failure reason change",
+			previous: snapshot(pingpong.TargetStatus{Name: "api", State: pingpong.StateDown, LastResult: failed(pingpong.FailureDNS)}),
+			current:  snapshot(pingpong.TargetStatus{Name: "api", State: pingpong.StateDown, LastResult: failed(pingpong.FailureConnect)}),
+			want:     []string{"api check failed (connect_error: boom)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, event := range statusEvents(tt.previous, *tt.current) {
+				got = append(got, event.message)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected events %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
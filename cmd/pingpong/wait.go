@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/SumonRayy/ping-pong-go/pkg/pingpong"
+)
+
+// runWait implements `pingpong wait`, which checks a target until it is
+// healthy, for scripts that must wait for a dependency. It exits 0 as soon as
+// a check succeeds and 1 once the timeout expires.
+func runWait(args []string) int {
+	flags := flag.NewFlagSet("wait", flag.ExitOnError)
+	url := flags.String("url", getEnv("SERVER_URL"), "URL of the target to wait for")
+	timeout := durationFlag(flags, "timeout", time.Minute, "How long to wait before giving up")
+	interval := durationFlag(flags, "interval", 2*time.Second, "Time between checks")
+	expectedBody := flags.String("expected-body", getEnv("EXPECTED_BODY"), "String the response body must contain")
+	quiet := flags.Bool("quiet", false, "Only report the outcome")
+	flags.Parse(args)
+
+	if *url == "" {
+		fmt.Fprintln(os.Stderr, "wait: --url is required")
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	target := pingpong.Target{URL: *url, ExpectedBody: *expectedBody}
+	start := time.Now()
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		_, err := pingpong.Check(ctx, target)
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "%s is healthy after %s\n", *url, time.Since(start).Round(time.Millisecond))
+			return 0
+		}
+		// Keep the reason of the last complete check rather than the timeout
+		if lastErr == nil || ctx.Err() == nil {
+			lastErr = err
+		}
+		if ctx.Err() == nil && !*quiet {
+			fmt.Fprintf(os.Stderr, "Attempt %d: %v\n", attempt, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprintf(os.Stderr, "%s is still unhealthy after %s: %v\n", *url, time.Since(start).Round(time.Millisecond), lastErr)
+			return 1
+		case <-time.After(*interval):
+		}
+	}
+}
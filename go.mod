@@ -5,6 +5,7 @@ go 1.23
 require (
 	github.com/fatih/color v1.15.0
 	github.com/joho/godotenv v1.5.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,273 @@
+// Package clientgen generates the Go client of the admin API from its
+// OpenAPI document
+package clientgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Spec is the subset of an OpenAPI 3 document the generator understands
+type Spec struct {
+	Paths map[string]map[string]Operation `json:"paths"`
+}
+
+// Operation is an OpenAPI operation
+type Operation struct {
+	OperationID string      `json:"operationId"`
+	Summary     string      `json:"summary"`
+	Parameters  []Parameter `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema Schema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Content map[string]struct {
+			Schema Schema `json:"schema"`
+		} `json:"content"`
+		Headers map[string]Header `json:"headers"`
+	} `json:"responses"`
+}
+
+// Parameter is an OpenAPI query parameter
+type Parameter struct {
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description"`
+	Schema      Schema `json:"schema"`
+}
+
+// Header is an OpenAPI response header
+type Header struct {
+	Description string `json:"description"`
+	Schema      Schema `json:"schema"`
+}
+
+// Schema is the subset of a JSON schema needed to name Go types
+type Schema struct {
+	Ref                  string  `json:"$ref"`
+	Type                 string  `json:"type"`
+	Format               string  `json:"format"`
+	Items                *Schema `json:"items"`
+	AdditionalProperties *Schema `json:"additionalProperties"`
+}
+
+// methodOrder fixes the order of operations sharing a path
+var methodOrder = []string{"get", "post", "put", "patch", "delete"}
+
+// Generate returns the Go source of the client operations described by
+// spec. Schemas referenced by name map to the types of the pingpong package.
+func Generate(spec []byte) ([]byte, error) {
+	var doc Spec
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, path := range paths {
+		for _, method := range methodOrder {
+			op, ok := doc.Paths[path][method]
+			if !ok {
+				continue
+			}
+			code, err := generateOperation(strings.ToUpper(method), path, op)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+			b.WriteString(code)
+		}
+	}
+
+	code := b.String()
+	var out bytes.Buffer
+	out.WriteString("// Code generated by clientgen from the admin API's OpenAPI document. DO NOT EDIT.\n\n")
+	out.WriteString("package client\n\nimport (\n\t\"context\"\n")
+	if strings.Contains(code, "url.Values") {
+		out.WriteString("\t\"net/url\"\n")
+	}
+	if strings.Contains(code, "strconv.") {
+		out.WriteString("\t\"strconv\"\n")
+	}
+	if strings.Contains(code, "time.Time") {
+		out.WriteString("\t\"time\"\n")
+	}
+	out.WriteString("\n\t\"github.com/SumonRayy/ping-pong-go/pkg/pingpong\"\n)\n")
+	out.WriteString(code)
+	return format.Source(out.Bytes())
+}
+
+// generateOperation returns the client method of one operation
+func generateOperation(method, path string, op Operation) (string, error) {
+	if op.OperationID == "" {
+		return "", fmt.Errorf("missing operationId")
+	}
+	name := strings.ToUpper(op.OperationID[:1]) + op.OperationID[1:]
+
+	var b strings.Builder
+	args := []string{"ctx context.Context"}
+	query := "nil"
+	var setQuery []string
+	if len(op.Parameters) > 0 {
+		// Query parameters are passed as a struct, leaving out zero values
+		fmt.Fprintf(&b, "\n// %sParams are the query parameters of %s\ntype %sParams struct {\n", name, name, name)
+		for _, param := range op.Parameters {
+			if param.In != "query" {
+				return "", fmt.Errorf("unsupported parameter location %q", param.In)
+			}
+			field := exportedIdentifier(param.Name)
+			fieldType := goType(param.Schema)
+			fmt.Fprintf(&b, "\t%s %s // %s\n", field, fieldType, param.Description)
+			value := "params." + field
+			switch fieldType {
+			case "string":
+				setQuery = append(setQuery, fmt.Sprintf("\tif %s != \"\" {\n\t\tquery.Set(%q, %s)\n\t}\n", value, param.Name, value))
+			case "int":
+				setQuery = append(setQuery, fmt.Sprintf("\tif %s != 0 {\n\t\tquery.Set(%q, strconv.Itoa(%s))\n\t}\n", value, param.Name, value))
+			case "bool":
+				setQuery = append(setQuery, fmt.Sprintf("\tif %s {\n\t\tquery.Set(%q, \"true\")\n\t}\n", value, param.Name))
+			default:
+				return "", fmt.Errorf("unsupported type %s of parameter %s", fieldType, param.Name)
+			}
+		}
+		b.WriteString("}\n")
+		args = append(args, "params "+name+"Params")
+		query = "query"
+	}
+
+	body := "nil"
+	if op.RequestBody != nil {
+		schema, ok := op.RequestBody.Content["application/json"]
+		if !ok {
+			return "", fmt.Errorf("request body is not JSON")
+		}
+		args = append(args, "body "+goType(schema.Schema))
+		body = "body"
+	}
+
+	var response *Schema
+	var headers map[string]Header
+	for status, r := range op.Responses {
+		if strings.HasPrefix(status, "2") {
+			if content, ok := r.Content["application/json"]; ok {
+				response = &content.Schema
+			}
+			headers = r.Headers
+		}
+	}
+
+	// The result, then response headers, are returned before the error;
+	// failed calls return their zero values
+	var results, zeros, values []string
+	out := "nil"
+	if response != nil {
+		out = "&out"
+		if response.Ref != "" {
+			results, zeros, values = append(results, "*"+goType(*response)), append(zeros, "nil"), append(values, "&out")
+		} else {
+			results, zeros, values = append(results, goType(*response)), append(zeros, "out"), append(values, "out")
+		}
+	}
+	headerNames := make([]string, 0, len(headers))
+	for header := range headers {
+		headerNames = append(headerNames, header)
+	}
+	sort.Strings(headerNames)
+	var readHeaders []string
+	for _, header := range headerNames {
+		variable := goIdentifier(strings.ToLower(strings.TrimPrefix(header, "X-")))
+		switch goType(headers[header].Schema) {
+		case "string":
+			results, zeros = append(results, "string"), append(zeros, `""`)
+			readHeaders = append(readHeaders, fmt.Sprintf("\t%s := header.Get(%q)\n", variable, header))
+		case "int":
+			results, zeros = append(results, "int"), append(zeros, "0")
+			readHeaders = append(readHeaders, fmt.Sprintf("\t%s, err := headerInt(header, %q)\n\tif err != nil {\n\t\treturn %s\n\t}\n",
+				variable, header, strings.Join(append(slices.Clone(zeros), "err"), ", ")))
+		default:
+			return "", fmt.Errorf("unsupported type of header %s", header)
+		}
+		values = append(values, variable)
+	}
+	results = append(results, "error")
+
+	fmt.Fprintf(&b, "\n// %s calls %s %s: %s\n", name, method, path, op.Summary)
+	for _, header := range headerNames {
+		fmt.Fprintf(&b, "// It also returns the %s header: %s\n", header, headers[header].Description)
+	}
+	signature := results[0]
+	if len(results) > 1 {
+		signature = "(" + strings.Join(results, ", ") + ")"
+	}
+	fmt.Fprintf(&b, "func (c *Client) %s(%s) %s {\n", name, strings.Join(args, ", "), signature)
+	if setQuery != nil {
+		b.WriteString("\tquery := url.Values{}\n")
+		b.WriteString(strings.Join(setQuery, ""))
+	}
+	if response != nil {
+		fmt.Fprintf(&b, "\tvar out %s\n", goType(*response))
+	}
+	call := fmt.Sprintf("c.do(ctx, %q, %q, %s, %s, %s)", method, path, query, body, out)
+	switch {
+	case len(values) == 0:
+		fmt.Fprintf(&b, "\t_, err := %s\n\treturn err\n", call)
+	case readHeaders == nil:
+		fmt.Fprintf(&b, "\tif _, err := %s; err != nil {\n\t\treturn %s\n\t}\n", call, strings.Join(append(zeros, "err"), ", "))
+		fmt.Fprintf(&b, "\treturn %s\n", strings.Join(append(values, "nil"), ", "))
+	default:
+		fmt.Fprintf(&b, "\theader, err := %s\n\tif err != nil {\n\t\treturn %s\n\t}\n", call, strings.Join(append(zeros, "err"), ", "))
+		b.WriteString(strings.Join(readHeaders, ""))
+		fmt.Fprintf(&b, "\treturn %s\n", strings.Join(append(values, "nil"), ", "))
+	}
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// goType returns the Go type of a schema
+func goType(schema Schema) string {
+	switch {
+	case schema.Ref != "":
+		return "pingpong." + schema.Ref[strings.LastIndex(schema.Ref, "/")+1:]
+	case schema.Type == "array" && schema.Items != nil:
+		return "[]" + goType(*schema.Items)
+	case schema.Type == "object" && schema.AdditionalProperties != nil:
+		return "map[string]" + goType(*schema.AdditionalProperties)
+	case schema.Type == "string" && schema.Format == "date-time":
+		return "time.Time"
+	case schema.Type == "string":
+		return "string"
+	case schema.Type == "integer":
+		return "int"
+	case schema.Type == "number":
+		return "float64"
+	case schema.Type == "boolean":
+		return "bool"
+	}
+	return "any"
+}
+
+// exportedIdentifier turns a parameter name such as "max_age" into "MaxAge"
+func exportedIdentifier(name string) string {
+	identifier := goIdentifier(name)
+	return strings.ToUpper(identifier[:1]) + identifier[1:]
+}
+
+// goIdentifier turns a parameter name such as "max_age" into "maxAge"
+func goIdentifier(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' })
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
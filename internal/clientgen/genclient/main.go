@@ -0,0 +1,24 @@
+// Command genclient writes the admin API client operations generated from
+// pingpong.OpenAPI to the file named by its argument
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/SumonRayy/ping-pong-go/internal/clientgen"
+	"github.com/SumonRayy/ping-pong-go/pkg/pingpong"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		log.Fatal("usage: genclient <output file>")
+	}
+	source, err := clientgen.Generate(pingpong.OpenAPI())
+	if err != nil {
+		log.Fatalf("Failed to generate client: %v", err)
+	}
+	if err := os.WriteFile(os.Args[1], source, 0o644); err != nil {
+		log.Fatalf("Failed to write client: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package pingpong
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Silence pauses delivery to notifiers for the given duration. Sinks keep
+// receiving events.
+func (s *Service) Silence(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.silencedUntil = timestamp().Add(d)
+	s.logger.Warn("Notifications silenced until %s", s.displayTime(s.silencedUntil))
+}
+
+// Unsilence resumes delivery to notifiers
+func (s *Service) Unsilence() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.silencedUntil = time.Time{}
+	s.logger.Info("Notifications resumed")
+}
+
+// silenced reports whether notifications are silenced at time t
+func (s *Service) silenced(t time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return t.Before(s.silencedUntil)
+}
+
+// Acknowledge marks every open incident as acknowledged and emits an
+// acknowledged event for each. It returns false if there is no
+// unacknowledged incident.
+func (s *Service) Acknowledge() bool {
+	acknowledged := false
+	for _, t := range s.targetList() {
+		t.mu.Lock()
+		incidentID := t.incidentID
+		if incidentID == "" || t.acknowledged == incidentID {
+			t.mu.Unlock()
+			continue
+		}
+		t.acknowledged = incidentID
+		t.mu.Unlock()
+
+		s.logger.Info("Incident %s acknowledged", incidentID)
+		event := s.newEvent(t, EventAcknowledged, nil)
+		event.State = StateDown
+		event.IncidentID = incidentID
+		s.emit(event)
+		acknowledged = true
+	}
+	return acknowledged
+}
+
+// adminOnly wraps an admin API handler with method and token checks.
+// Without an AdminToken only local clients are served, since the admin API
+// adds targets that get probed and exposes their state.
+func (s *Service) adminOnly(methods string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !slices.Contains(strings.Split(methods, ", "), r.Method) {
+			w.Header().Set("Allow", methods)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if s.config.AdminToken == "" {
+			if !localRequest(r) {
+				http.Error(w, "Forbidden: set an admin token to use the admin API remotely", http.StatusForbidden)
+				return
+			}
+		} else {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AdminToken)) != 1 {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		handler(w, r)
+	}
+}
+
+// localRequest reports whether a request comes from the loopback interface
+// or over a Unix socket, whose access is controlled by file permissions
+func localRequest(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || ip.IsLoopback()
+}
+
+// SilenceResponse is the response of the silence operation
+type SilenceResponse struct {
+	SilencedUntil time.Time `json:"silenced_until"`
+}
+
+// silenceHandler silences notifications for ?duration (default 1h)
+func (s *Service) silenceHandler(w http.ResponseWriter, r *http.Request) {
+	d := time.Hour
+	if value := r.URL.Query().Get("duration"); value != "" {
+		var err error
+		if d, err = time.ParseDuration(value); err != nil || d <= 0 {
+			http.Error(w, "Invalid duration", http.StatusBadRequest)
+			return
+		}
+	}
+	s.Silence(d)
+	writeJSON(w, http.StatusOK, SilenceResponse{SilencedUntil: timestamp().Add(d)})
+}
+
+// unsilenceHandler resumes notifications
+func (s *Service) unsilenceHandler(w http.ResponseWriter, r *http.Request) {
+	s.Unsilence()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ackHandler acknowledges the open incident
+func (s *Service) ackHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.Acknowledge() {
+		http.Error(w, "No unacknowledged incident", http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddTargetsResponse is the response of the addTargets operation
+type AddTargetsResponse struct {
+	Added   []string `json:"added"`   // Names of the targets now being checked
+	Skipped int      `json:"skipped"` // Targets skipped because their name is already in use
+}
+
+// listTargetsHandler lists the targets with their effective settings,
+// selected and ordered by the TargetQuery of the request
+func (s *Service) listTargetsHandler(w http.ResponseWriter, r *http.Request) {
+	q, ok := targetQuery(w, r)
+	if !ok {
+		return
+	}
+	var statuses []TargetStatus
+	for _, t := range s.targetList() {
+		statuses = append(statuses, t.status())
+	}
+	statuses, total := q.Apply(statuses)
+
+	effective := make(map[string]EffectiveTarget)
+	for _, target := range s.EffectiveConfig().Targets {
+		effective[target.Name] = target
+	}
+	targets := make([]EffectiveTarget, 0, len(statuses))
+	for _, status := range statuses {
+		if target, ok := effective[status.Name]; ok {
+			targets = append(targets, target)
+		}
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	writeJSON(w, http.StatusOK, targets)
+}
+
+// addTargetsHandler adds targets from a JSON array of TargetEntry
+func (s *Service) addTargetsHandler(w http.ResponseWriter, r *http.Request) {
+	var entries []TargetEntry
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&entries); err != nil {
+		http.Error(w, "Invalid targets: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	specs, err := TargetsFile{Targets: entries}.Resolve(s.config.NamedNotifiers)
+	if err != nil {
+		http.Error(w, "Invalid targets: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	added, err := s.AddTargets(specs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeJSON(w, http.StatusOK, AddTargetsResponse{Added: added, Skipped: len(specs) - len(added)})
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
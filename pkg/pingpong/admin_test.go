@@ -0,0 +1,122 @@
+package pingpong
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newLocalRequest returns a request from the loopback interface, which the
+// admin API serves without a token
+func newLocalRequest(method, target string, body io.Reader) *http.Request {
+	r := httptest.NewRequest(method, target, body)
+	r.RemoteAddr = "127.0.0.1:40000"
+	return r
+}
+
+func TestService_SilenceNotifiers(t *testing.T) {
+	sink, notifier := &recordingSink{}, &recordingSink{}
+	service := NewService(Config{Sinks: []Sink{sink}, Notifiers: []Sink{notifier}, Logger: &TestLogger{}})
+
+	service.Silence(time.Hour)
+	service.deliver(Event{Type: EventStateChange, Time: time.Now()})
+	service.Unsilence()
+	service.deliver(Event{Type: EventStateChange, Time: time.Now()})
+
+	if len(sink.ofType(EventStateChange)) != 2 {
+		t.Errorf("Expected sinks to receive events while silenced")
+	}
+	if len(notifier.ofType(EventStateChange)) != 1 {
+		t.Errorf("Expected notifiers to skip events while silenced, got %d", len(notifier.ofType(EventStateChange)))
+	}
+}
+
+func TestService_AdminAPI(t *testing.T) {
+	notifier := &recordingSink{}
+	service := NewService(Config{MaxConsecutiveFails: 10, Notifiers: []Sink{notifier}, AdminToken: "secret", Logger: &TestLogger{}})
+	handler := service.adminOnly("POST", service.ackHandler)
+
+	req := httptest.NewRequest("POST", "/api/ack", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected %d without token, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	handler(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected %d without an open incident, got %d", http.StatusConflict, w.Code)
+	}
+
+	service.recordResult(service.targets[0], Result{Time: time.Now()})
+	w = httptest.NewRecorder()
+	handler(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected %d when acknowledging, got %d", http.StatusNoContent, w.Code)
+	}
+	if status := service.Status(); !status.Acknowledged {
+		t.Errorf("Expected status to report acknowledgement, got %+v", status)
+	}
+
+	service.shutdown(ShutdownStopped)
+	service.dispatchEvents()
+	if acks := notifier.ofType(EventAcknowledged); len(acks) != 1 || acks[0].IncidentID == "" {
+		t.Errorf("Expected one acknowledged event with incident ID, got %+v", acks)
+	}
+}
+
+func TestService_TargetsAPI(t *testing.T) {
+	oncall := &recordingSink{}
+	service := NewService(Config{ServerURL: "http://example.com", PingInterval: time.Second, Logger: &TestLogger{},
+		NamedNotifiers: map[string]Sink{"oncall": oncall}})
+	mux := http.NewServeMux()
+	service.registerAdminAPI(mux)
+	handler := mux.ServeHTTP
+
+	// Without an admin token, only local clients may add targets
+	body := `[{"url": "http://a.example.com", "interval": "5s"}, {"url": "http://example.com"}]`
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/api/targets", strings.NewReader(body)))
+	if w.Code != http.StatusForbidden || len(service.targetList()) != 1 {
+		t.Fatalf("Expected a remote client without a token to be refused, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, newLocalRequest("POST", "/api/targets", strings.NewReader(body)))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"skipped":1`) {
+		t.Fatalf("Expected one target to be added, got %d: %s", w.Code, w.Body)
+	}
+	if targets := service.targetList(); len(targets) != 2 || targets[1].Interval != 5*time.Second || targets[1].source != "api" {
+		t.Errorf("Expected the new target with its own interval, got %+v", targets)
+	}
+
+	// A renamed entry at a known URL is skipped, and notifiers are resolved by name
+	body = `[{"name": "renamed", "url": "http://a.example.com"}, {"url": "http://c.example.com", "notifiers": ["oncall"]}]`
+	w = httptest.NewRecorder()
+	handler(w, newLocalRequest("POST", "/api/targets", strings.NewReader(body)))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"skipped":1`) {
+		t.Fatalf("Expected the renamed entry to be skipped, got %d: %s", w.Code, w.Body)
+	}
+	if targets := service.targetList(); len(targets) != 3 || len(targets[2].Notifiers) != 1 || targets[2].Notifiers[0] != oncall {
+		t.Errorf("Expected the new target to alert the named notifier, got %+v", targets)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, newLocalRequest("POST", "/api/targets", strings.NewReader(`[{"url": "ftp://b.example.com"}]`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected %d for an invalid URL, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, newLocalRequest("GET", "/api/targets", nil))
+	var targets []EffectiveTarget
+	if err := json.Unmarshal(w.Body.Bytes(), &targets); err != nil || len(targets) != 3 {
+		t.Errorf("Expected all targets to be listed, got %s (%v)", w.Body, err)
+	}
+}
@@ -0,0 +1,233 @@
+package pingpong
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AWSDiscovery creates targets for running EC2 instances matching tag
+// filters and for the registered targets of ALB target groups, so
+// autoscaled fleets are monitored without manual edits
+type AWSDiscovery struct {
+	Region          string            // Required, also with overridden endpoints since requests are signed for it
+	Tags            map[string]string // EC2 tag filters; values may use * wildcards
+	TargetGroupARNs []string          // ALB target groups whose registered targets are checked
+	Port            int               // Port checked on tagged instances (default 80); target group targets use their registered port
+	Scheme          string            // Defaults to http
+	Path            string            // Defaults to /
+	PublicIP        bool              // Check the public instead of the private IP address
+	Credentials     AWSCredentials    // Defaults to the AWS_* environment variables
+	EC2Endpoint     string            // Overrides https://ec2.<region>.amazonaws.com
+	ELBEndpoint     string            // Overrides https://elasticloadbalancing.<region>.amazonaws.com
+	Client          *http.Client
+}
+
+// ec2Instance is an instance in a DescribeInstances response
+type ec2Instance struct {
+	ID               string `xml:"instanceId"`
+	PrivateIP        string `xml:"privateIpAddress"`
+	PublicIP         string `xml:"ipAddress"`
+	AvailabilityZone string `xml:"placement>availabilityZone"`
+	Tags             []struct {
+		Key   string `xml:"key"`
+		Value string `xml:"value"`
+	} `xml:"tagSet>item"`
+}
+
+// describeInstancesResponse is the DescribeInstances response
+type describeInstancesResponse struct {
+	Instances []ec2Instance `xml:"reservationSet>item>instancesSet>item"`
+	NextToken string        `xml:"nextToken"`
+}
+
+// describeTargetHealthResponse is the DescribeTargetHealth response
+type describeTargetHealthResponse struct {
+	Targets []struct {
+		ID   string `xml:"Target>Id"`
+		Port int    `xml:"Target>Port"`
+	} `xml:"DescribeTargetHealthResult>TargetHealthDescriptions>member"`
+}
+
+// Discover lists tagged instances and target group members
+func (d *AWSDiscovery) Discover(ctx context.Context) ([]Target, error) {
+	if d.Region == "" {
+		return nil, errors.New("no AWS region configured")
+	}
+	var targets []Target
+
+	if len(d.Tags) > 0 {
+		params := url.Values{}
+		names := make([]string, 0, len(d.Tags))
+		for name := range d.Tags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			params.Set(fmt.Sprintf("Filter.%d.Name", i+1), "tag:"+name)
+			params.Set(fmt.Sprintf("Filter.%d.Value.1", i+1), d.Tags[name])
+		}
+		instances, err := d.describeInstances(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		port := d.Port
+		if port == 0 {
+			port = 80
+		}
+		for _, instance := range instances {
+			if target, ok := d.instanceTarget(instance, port); ok {
+				targets = append(targets, target)
+			}
+		}
+	}
+
+	for _, arn := range d.TargetGroupARNs {
+		members, err := d.targetGroupTargets(ctx, arn)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, members...)
+	}
+	return targets, nil
+}
+
+// targetGroupTargets lists the registered targets of a target group,
+// resolving instance IDs to addresses
+func (d *AWSDiscovery) targetGroupTargets(ctx context.Context, arn string) ([]Target, error) {
+	body, err := d.call(ctx, "elasticloadbalancing", valueOr(d.ELBEndpoint, "https://elasticloadbalancing."+d.Region+".amazonaws.com/"), url.Values{
+		"Action":         {"DescribeTargetHealth"},
+		"Version":        {"2015-12-01"},
+		"TargetGroupArn": {arn},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var response describeTargetHealthResponse
+	if err := xml.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("parsing DescribeTargetHealth response: %w", err)
+	}
+
+	group := arn
+	if i := strings.Index(arn, ":targetgroup/"); i >= 0 {
+		group = strings.Split(arn[i+len(":targetgroup/"):], "/")[0]
+	}
+	ports := make(map[string]int)
+	params := url.Values{}
+	var targets []Target
+	for _, member := range response.Targets {
+		switch {
+		case strings.HasPrefix(member.ID, "i-"):
+			ports[member.ID] = member.Port
+			params.Set(fmt.Sprintf("InstanceId.%d", len(ports)), member.ID)
+		case strings.HasPrefix(member.ID, "arn:"):
+			// Lambda functions cannot be pinged directly
+		default:
+			targets = append(targets, Target{
+				URL:    backendURL(member.ID+":"+strconv.Itoa(member.Port), d.Scheme, d.Path),
+				Labels: map[string]string{"target_group": group},
+			})
+		}
+	}
+	if len(ports) == 0 {
+		return targets, nil
+	}
+
+	instances, err := d.describeInstances(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	for _, instance := range instances {
+		if target, ok := d.instanceTarget(instance, ports[instance.ID]); ok {
+			target.Labels["target_group"] = group
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+// describeInstances lists running instances matching params, following
+// pagination
+func (d *AWSDiscovery) describeInstances(ctx context.Context, params url.Values) ([]ec2Instance, error) {
+	params.Set("Action", "DescribeInstances")
+	params.Set("Version", "2016-11-15")
+	n := 1
+	for params.Has(fmt.Sprintf("Filter.%d.Name", n)) {
+		n++
+	}
+	params.Set(fmt.Sprintf("Filter.%d.Name", n), "instance-state-name")
+	params.Set(fmt.Sprintf("Filter.%d.Value.1", n), "running")
+
+	var instances []ec2Instance
+	for {
+		body, err := d.call(ctx, "ec2", valueOr(d.EC2Endpoint, "https://ec2."+d.Region+".amazonaws.com/"), params)
+		if err != nil {
+			return nil, err
+		}
+		var response describeInstancesResponse
+		if err := xml.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("parsing DescribeInstances response: %w", err)
+		}
+		instances = append(instances, response.Instances...)
+		if response.NextToken == "" {
+			return instances, nil
+		}
+		params.Set("NextToken", response.NextToken)
+	}
+}
+
+// instanceTarget creates the target for an instance, if it has an address
+func (d *AWSDiscovery) instanceTarget(instance ec2Instance, port int) (Target, bool) {
+	address := instance.PrivateIP
+	if d.PublicIP {
+		address = instance.PublicIP
+	}
+	if address == "" {
+		return Target{}, false
+	}
+	labels := map[string]string{"instance_id": instance.ID, "availability_zone": instance.AvailabilityZone}
+	for _, tag := range instance.Tags {
+		if tag.Key == "Name" {
+			labels["name"] = tag.Value
+		}
+	}
+	return Target{URL: backendURL(address+":"+strconv.Itoa(port), d.Scheme, d.Path), Labels: labels}, true
+}
+
+// call sends a signed AWS Query API request and returns the response body
+func (d *AWSDiscovery) call(ctx context.Context, service, endpoint string, params url.Values) ([]byte, error) {
+	body := []byte(params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signAWSRequest(req, body, d.Credentials.orEnv(), d.Region, service, time.Now())
+
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s returned status %d: %s", service, params.Get("Action"), resp.StatusCode, truncate(strings.TrimSpace(string(data)), 512))
+	}
+	return data, nil
+}
@@ -0,0 +1,79 @@
+package pingpong
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAWSDiscovery(t *testing.T) {
+	var instanceQueries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			http.Error(w, "unsigned", http.StatusForbidden)
+			return
+		}
+		switch r.Form.Get("Action") {
+		case "DescribeInstances":
+			instanceQueries = append(instanceQueries, r.Form.Encode())
+			if r.Form.Get("InstanceId.1") == "i-2" {
+				w.Write([]byte(`<DescribeInstancesResponse><reservationSet><item><instancesSet><item>
+					<instanceId>i-2</instanceId><privateIpAddress>10.0.1.2</privateIpAddress></item></instancesSet></item></reservationSet></DescribeInstancesResponse>`))
+				return
+			}
+			if r.Form.Get("NextToken") == "" {
+				w.Write([]byte(`<DescribeInstancesResponse><reservationSet><item><instancesSet><item>
+					<instanceId>i-1</instanceId><privateIpAddress>10.0.1.1</privateIpAddress><ipAddress>3.3.3.3</ipAddress>
+					<placement><availabilityZone>eu-west-1a</availabilityZone></placement>
+					<tagSet><item><key>Name</key><value>web-1</value></item></tagSet></item></instancesSet></item></reservationSet>
+					<nextToken>page2</nextToken></DescribeInstancesResponse>`))
+				return
+			}
+			w.Write([]byte(`<DescribeInstancesResponse><reservationSet><item><instancesSet><item>
+				<instanceId>i-3</instanceId></item></instancesSet></item></reservationSet></DescribeInstancesResponse>`))
+		case "DescribeTargetHealth":
+			w.Write([]byte(`<DescribeTargetHealthResponse><DescribeTargetHealthResult><TargetHealthDescriptions>
+				<member><Target><Id>i-2</Id><Port>8080</Port></Target></member>
+				<member><Target><Id>10.0.2.7</Id><Port>9000</Port></Target></member>
+				</TargetHealthDescriptions></DescribeTargetHealthResult></DescribeTargetHealthResponse>`))
+		}
+	}))
+	defer server.Close()
+
+	discovery := &AWSDiscovery{
+		Region:          "eu-west-1",
+		Tags:            map[string]string{"role": "web"},
+		TargetGroupARNs: []string{"arn:aws:elasticloadbalancing:eu-west-1:123:targetgroup/api/abc"},
+		Path:            "/health",
+		Credentials:     AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+		EC2Endpoint:     server.URL,
+		ELBEndpoint:     server.URL,
+	}
+	targets, err := discovery.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover returned error: %v", err)
+	}
+	want := []string{"http://10.0.1.1:80/health", "http://10.0.2.7:9000/health", "http://10.0.1.2:8080/health"}
+	if len(targets) != len(want) {
+		t.Fatalf("Expected %v, got %+v", want, targets)
+	}
+	for i, target := range targets {
+		if target.URL != want[i] {
+			t.Errorf("Target %d: expected %s, got %s", i, want[i], target.URL)
+		}
+	}
+	if targets[0].Labels["name"] != "web-1" || targets[2].Labels["target_group"] != "api" {
+		t.Errorf("Unexpected labels: %+v, %+v", targets[0].Labels, targets[2].Labels)
+	}
+	if !strings.Contains(instanceQueries[0], "Filter.1.Name=tag%3Arole") || !strings.Contains(instanceQueries[0], "Filter.2.Value.1=running") {
+		t.Errorf("Unexpected DescribeInstances query: %s", instanceQueries[0])
+	}
+
+	discovery.Region = ""
+	if _, err := discovery.Discover(context.Background()); err == nil || !strings.Contains(err.Error(), "region") {
+		t.Errorf("Expected an error without a region, got %v", err)
+	}
+}
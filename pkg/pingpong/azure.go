@@ -0,0 +1,219 @@
+package pingpong
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// azureIMDSTokenURL is the instance metadata endpoint issuing managed identity tokens
+const azureIMDSTokenURL = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+// AzureTokenSource returns an Azure AD access token
+type AzureTokenSource func(ctx context.Context) (string, error)
+
+// StaticAzureToken returns a token source that always returns token
+func StaticAzureToken(token string) AzureTokenSource {
+	return func(ctx context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+// AzureManagedIdentityToken returns a token source that fetches and caches
+// managed identity tokens for resource from the instance metadata service
+func AzureManagedIdentityToken(resource string) AzureTokenSource {
+	var mu sync.Mutex
+	var token string
+	var expiry time.Time
+
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if token != "" && time.Until(expiry) > time.Minute {
+			return token, nil
+		}
+
+		query := url.Values{"api-version": {"2018-02-01"}, "resource": {resource}}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, azureIMDSTokenURL+"?"+query.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata", "true")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch managed identity token: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("instance metadata service returned status %d", resp.StatusCode)
+		}
+
+		var body struct {
+			AccessToken string          `json:"access_token"`
+			ExpiresIn   json.RawMessage `json:"expires_in"` // A quoted number
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return "", fmt.Errorf("failed to decode managed identity token: %w", err)
+		}
+		seconds, _ := strconv.Atoi(strings.Trim(string(body.ExpiresIn), `"`))
+		token = body.AccessToken
+		expiry = time.Now().Add(time.Duration(seconds) * time.Second)
+		return token, nil
+	}
+}
+
+// AzureMonitorSink publishes availability and latency of every result as
+// Azure Monitor custom metrics on a resource
+type AzureMonitorSink struct {
+	Region      string           // Region of the resource, e.g. "westeurope"
+	ResourceID  string           // Full resource ID the metrics are attached to
+	Namespace   string           // Defaults to "Pingpong"
+	TokenSource AzureTokenSource // Defaults to AzureManagedIdentityToken("https://monitoring.azure.com/")
+	Endpoint    string           // Overrides https://<region>.monitoring.azure.com
+	Client      *http.Client
+
+	defaultToken AzureTokenSource // Created once, so concurrent sends share its cached token
+	tokenOnce    sync.Once
+}
+
+// azureMetric is a custom metric request body
+type azureMetric struct {
+	Time time.Time `json:"time"`
+	Data struct {
+		BaseData struct {
+			Metric    string              `json:"metric"`
+			Namespace string              `json:"namespace"`
+			DimNames  []string            `json:"dimNames"`
+			Series    []azureMetricSeries `json:"series"`
+		} `json:"baseData"`
+	} `json:"data"`
+}
+
+// azureMetricSeries is a single pre-aggregated metric series
+type azureMetricSeries struct {
+	DimValues []string `json:"dimValues"`
+	Min       float64  `json:"min"`
+	Max       float64  `json:"max"`
+	Sum       float64  `json:"sum"`
+	Count     int      `json:"count"`
+}
+
+// token returns the configured token source, or the default one
+func (a *AzureMonitorSink) token() AzureTokenSource {
+	if a.TokenSource != nil {
+		return a.TokenSource
+	}
+	a.tokenOnce.Do(func() {
+		a.defaultToken = AzureManagedIdentityToken("https://monitoring.azure.com/")
+	})
+	return a.defaultToken
+}
+
+// Send publishes the Availability and LatencyMs metrics for result events
+func (a *AzureMonitorSink) Send(ctx context.Context, event Event) error {
+	if event.Type != EventResult || event.Result == nil {
+		return nil
+	}
+	availability := 0.0
+	if event.Result.Success {
+		availability = 1
+	}
+	metrics := map[string]float64{
+		"Availability": availability,
+		"LatencyMs":    float64(event.Result.Latency.Milliseconds()),
+	}
+	for _, name := range []string{"Availability", "LatencyMs"} {
+		if err := a.post(ctx, event, name, metrics[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// post sends a single metric value
+func (a *AzureMonitorSink) post(ctx context.Context, event Event, name string, value float64) error {
+	var metric azureMetric
+	metric.Time = event.Time.UTC()
+	metric.Data.BaseData.Metric = name
+	metric.Data.BaseData.Namespace = valueOr(a.Namespace, "Pingpong")
+	metric.Data.BaseData.DimNames = []string{"Target", "Instance", "Reason"}
+	metric.Data.BaseData.Series = []azureMetricSeries{{
+		DimValues: []string{event.Target, event.InstanceID, valueOr(string(event.Result.Reason), "none")},
+		Min:       value,
+		Max:       value,
+		Sum:       value,
+		Count:     1,
+	}}
+	body, err := json.Marshal(metric)
+	if err != nil {
+		return fmt.Errorf("failed to encode metric: %w", err)
+	}
+
+	token, err := a.token()(ctx)
+	if err != nil {
+		return err
+	}
+	endpoint := valueOr(a.Endpoint, "https://"+a.Region+".monitoring.azure.com")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+a.ResourceID+"/metrics", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create metrics request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return doRequest(a.Client, req)
+}
+
+// EventGridSink publishes state change events to an Azure Event Grid topic
+// using the Event Grid event schema
+type EventGridSink struct {
+	Endpoint  string // Topic endpoint, e.g. https://<topic>.<region>-1.eventgrid.azure.net/api/events
+	Key       string // Topic access key sent as aeg-sas-key
+	AllEvents bool   // Also publish result events, not just state changes
+	Client    *http.Client
+}
+
+// eventGridEvent is an event in the Event Grid schema
+type eventGridEvent struct {
+	ID          string    `json:"id"`
+	EventType   string    `json:"eventType"`
+	Subject     string    `json:"subject"`
+	EventTime   time.Time `json:"eventTime"`
+	Data        Event     `json:"data"`
+	DataVersion string    `json:"dataVersion"`
+}
+
+// Send publishes the event to the topic
+func (e *EventGridSink) Send(ctx context.Context, event Event) error {
+	if event.Type != EventStateChange && !e.AllEvents {
+		return nil
+	}
+
+	body, err := json.Marshal([]eventGridEvent{{
+		ID:          event.ID,
+		EventType:   "Pingpong." + string(event.Type),
+		Subject:     event.Target,
+		EventTime:   event.Time.UTC(),
+		Data:        event,
+		DataVersion: "1.0",
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Event Grid request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("aeg-sas-key", e.Key)
+
+	return doRequest(e.Client, req)
+}
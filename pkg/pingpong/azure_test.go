@@ -0,0 +1,77 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAzureMonitorSink(t *testing.T) {
+	metrics := map[string]azureMetricSeries{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/subscriptions/s/resourceGroups/g/metrics" || r.Header.Get("Authorization") != "Bearer tok" {
+			t.Errorf("Unexpected request %s with auth %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var metric azureMetric
+		json.NewDecoder(r.Body).Decode(&metric)
+		metrics[metric.Data.BaseData.Metric] = metric.Data.BaseData.Series[0]
+	}))
+	defer server.Close()
+
+	sink := &AzureMonitorSink{ResourceID: "/subscriptions/s/resourceGroups/g", TokenSource: StaticAzureToken("tok"), Endpoint: server.URL}
+	event := Event{Type: EventResult, Target: "http://api", Result: &Result{Success: true, Latency: 42 * time.Millisecond}}
+	if err := sink.Send(context.Background(), event); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	if metrics["Availability"].Sum != 1 || metrics["LatencyMs"].Sum != 42 {
+		t.Errorf("Unexpected metrics: %+v", metrics)
+	}
+	if metrics["LatencyMs"].DimValues[0] != "http://api" {
+		t.Errorf("Expected target dimension, got %v", metrics["LatencyMs"].DimValues)
+	}
+}
+
+func TestEventGridSink(t *testing.T) {
+	var events []eventGridEvent
+	var key string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("aeg-sas-key")
+		json.NewDecoder(r.Body).Decode(&events)
+	}))
+	defer server.Close()
+
+	sink := &EventGridSink{Endpoint: server.URL, Key: "secret"}
+	if err := sink.Send(context.Background(), Event{ID: "1", Type: EventStateChange, Target: "http://api", State: StateDown}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	if key != "secret" || len(events) != 1 {
+		t.Fatalf("Expected one event with key, got %d events and key %q", len(events), key)
+	}
+	if events[0].EventType != "Pingpong.state_change" || events[0].Subject != "http://api" || events[0].Data.State != StateDown {
+		t.Errorf("Unexpected event: %+v", events[0])
+	}
+}
+
+func TestAzureMonitorSink_DefaultTokenConcurrent(t *testing.T) {
+	sink := &AzureMonitorSink{}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if sink.token() == nil {
+				t.Error("Expected a default token source")
+			}
+		}()
+	}
+	wg.Wait()
+	if sink.TokenSource != nil {
+		t.Error("Expected the configured token source to be left alone")
+	}
+}
@@ -0,0 +1,131 @@
+package pingpong
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"sync"
+)
+
+// AzureDiscovery creates a target for every instance of Azure virtual
+// machine scale sets, selected by name or by tags
+type AzureDiscovery struct {
+	SubscriptionID string
+	ResourceGroup  string
+	ScaleSets      []string          // Names of scale sets; if empty, every scale set in the resource group matching Tags
+	Tags           map[string]string // Tag filters applied when ScaleSets is empty
+	Port           int               // Defaults to 80
+	Scheme         string            // Defaults to http
+	Path           string            // Defaults to /
+	TokenSource    AzureTokenSource  // Defaults to a managed identity token for Azure Resource Manager
+	Endpoint       string            // Overrides https://management.azure.com
+	Client         *http.Client
+
+	defaultToken AzureTokenSource // Created once, so concurrent discoveries share its cached token
+	tokenOnce    sync.Once
+}
+
+// token returns the configured token source, or the default one
+func (d *AzureDiscovery) token() AzureTokenSource {
+	if d.TokenSource != nil {
+		return d.TokenSource
+	}
+	d.tokenOnce.Do(func() {
+		d.defaultToken = AzureManagedIdentityToken("https://management.azure.com/")
+	})
+	return d.defaultToken
+}
+
+// Discover lists the private addresses of the scale set instances
+func (d *AzureDiscovery) Discover(ctx context.Context) ([]Target, error) {
+	endpoint := valueOr(d.Endpoint, "https://management.azure.com")
+	token, err := d.token()(ctx)
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{"Authorization": "Bearer " + token}
+	base := fmt.Sprintf("%s/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/virtualMachineScaleSets",
+		endpoint, url.PathEscape(d.SubscriptionID), url.PathEscape(d.ResourceGroup))
+
+	scaleSets := d.ScaleSets
+	if len(scaleSets) == 0 {
+		next := base + "?api-version=2023-09-01"
+		for next != "" {
+			var page struct {
+				Value []struct {
+					Name string            `json:"name"`
+					Tags map[string]string `json:"tags"`
+				} `json:"value"`
+				NextLink string `json:"nextLink"`
+			}
+			if err := fetchJSON(ctx, d.Client, next, headers, &page); err != nil {
+				return nil, err
+			}
+			for _, scaleSet := range page.Value {
+				if d.matches(scaleSet.Tags) {
+					scaleSets = append(scaleSets, scaleSet.Name)
+				}
+			}
+			next = page.NextLink
+		}
+	}
+
+	port := d.Port
+	if port == 0 {
+		port = 80
+	}
+	var targets []Target
+	for _, scaleSet := range scaleSets {
+		next := base + "/" + url.PathEscape(scaleSet) + "/networkInterfaces?api-version=2018-10-01"
+		for next != "" {
+			var page struct {
+				Value []struct {
+					Properties struct {
+						VirtualMachine struct {
+							ID string `json:"id"`
+						} `json:"virtualMachine"`
+						IPConfigurations []struct {
+							Properties struct {
+								Primary          bool   `json:"primary"`
+								PrivateIPAddress string `json:"privateIPAddress"`
+							} `json:"properties"`
+						} `json:"ipConfigurations"`
+					} `json:"properties"`
+				} `json:"value"`
+				NextLink string `json:"nextLink"`
+			}
+			if err := fetchJSON(ctx, d.Client, next, headers, &page); err != nil {
+				return nil, err
+			}
+			for _, nic := range page.Value {
+				for _, config := range nic.Properties.IPConfigurations {
+					if !config.Properties.Primary || config.Properties.PrivateIPAddress == "" {
+						continue
+					}
+					targets = append(targets, Target{
+						URL: backendURL(config.Properties.PrivateIPAddress+":"+strconv.Itoa(port), d.Scheme, d.Path),
+						Labels: map[string]string{
+							"scale_set": scaleSet,
+							"instance":  path.Base(nic.Properties.VirtualMachine.ID),
+						},
+					})
+				}
+			}
+			next = page.NextLink
+		}
+	}
+	return targets, nil
+}
+
+// matches reports whether tags contain every tag filter
+func (d *AzureDiscovery) matches(tags map[string]string) bool {
+	for key, value := range d.Tags {
+		if tags[key] != value {
+			return false
+		}
+	}
+	return true
+}
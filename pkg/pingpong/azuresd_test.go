@@ -0,0 +1,67 @@
+package pingpong
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestAzureDiscovery(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer az" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		const base = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachineScaleSets"
+		switch {
+		case r.URL.Path == base && r.URL.Query().Get("page") == "":
+			w.Write([]byte(`{"value":[{"name":"batch","tags":{}}],"nextLink":"` + server.URL + base + `?page=2"}`))
+		case r.URL.Path == base:
+			w.Write([]byte(`{"value":[{"name":"web","tags":{"monitor":"true"}}]}`))
+		case r.URL.Path == base+"/web/networkInterfaces" && r.URL.Query().Get("page") == "":
+			w.Write([]byte(`{"value":[{"properties":{"virtualMachine":{"id":"` + base + `/web/virtualMachines/0"},
+				"ipConfigurations":[{"properties":{"primary":true,"privateIPAddress":"10.1.0.4"}}]}}],
+				"nextLink":"` + server.URL + base + `/web/networkInterfaces?page=2"}`))
+		case r.URL.Path == base+"/web/networkInterfaces":
+			w.Write([]byte(`{"value":[{"properties":{"virtualMachine":{"id":"` + base + `/web/virtualMachines/1"},
+				"ipConfigurations":[{"properties":{"primary":true,"privateIPAddress":"10.1.0.5"}}]}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	discovery := &AzureDiscovery{
+		SubscriptionID: "sub",
+		ResourceGroup:  "rg",
+		Tags:           map[string]string{"monitor": "true"},
+		Path:           "/healthz",
+		TokenSource:    StaticAzureToken("az"),
+		Endpoint:       server.URL,
+	}
+	targets, err := discovery.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover returned error: %v", err)
+	}
+	if len(targets) != 2 || targets[0].URL != "http://10.1.0.4:80/healthz" || targets[1].Labels["instance"] != "1" || targets[1].Labels["scale_set"] != "web" {
+		t.Errorf("Unexpected targets: %+v", targets)
+	}
+}
+
+func TestAzureDiscovery_DefaultTokenConcurrent(t *testing.T) {
+	discovery := &AzureDiscovery{}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if discovery.token() == nil {
+				t.Error("Expected a default token source")
+			}
+		}()
+	}
+	wg.Wait()
+}
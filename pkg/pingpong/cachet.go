@@ -0,0 +1,187 @@
+package pingpong
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// Cachet component and incident status codes
+const (
+	cachetComponentOperational  = 1
+	cachetComponentPerformance  = 2
+	cachetComponentMajorOutage  = 4
+	cachetIncidentInvestigating = 1
+	cachetIncidentFixed         = 4
+)
+
+// CachetSink pushes component status, and optionally incidents, to a
+// self-hosted Cachet status page on state changes
+type CachetSink struct {
+	URL         string         // Base URL of the Cachet instance
+	Token       string         // API token sent as X-Cachet-Token
+	Components  map[string]int // Maps target URLs to component IDs
+	ComponentID int            // Component used for targets missing from Components
+	Incidents   bool           // Open an incident when a target goes down and resolve it on recovery
+	Client      *http.Client
+
+	mu        sync.Mutex
+	incidents map[string]int // Maps our incident IDs to Cachet incident IDs
+}
+
+// Send updates the component and incident for state change events
+func (c *CachetSink) Send(ctx context.Context, event Event) error {
+	if event.Type != EventStateChange {
+		return nil
+	}
+	componentID, ok := c.Components[event.Target]
+	if !ok {
+		componentID = c.ComponentID
+	}
+	if componentID == 0 {
+		return nil
+	}
+
+	componentStatus := cachetComponentOperational
+	switch event.State {
+	case StateDegraded:
+		componentStatus = cachetComponentPerformance
+	case StateDown:
+		componentStatus = cachetComponentMajorOutage
+	}
+	if err := c.call(ctx, http.MethodPut, fmt.Sprintf("/api/v1/components/%d", componentID),
+		map[string]any{"status": componentStatus}, nil); err != nil {
+		return err
+	}
+
+	if !c.Incidents || event.IncidentID == "" {
+		return nil
+	}
+	c.mu.Lock()
+	if c.incidents == nil {
+		c.incidents = make(map[string]int)
+	}
+	incidentID, open := c.incidents[event.IncidentID]
+	c.mu.Unlock()
+
+	switch {
+	case event.State == StateDown && !open:
+		var created struct {
+			Data struct {
+				ID int `json:"id"`
+			} `json:"data"`
+		}
+		err := c.call(ctx, http.MethodPost, "/api/v1/incidents", map[string]any{
+			"name":             fmt.Sprintf("%s is down", event.Target),
+			"message":          incidentMessage(event),
+			"status":           cachetIncidentInvestigating,
+			"visible":          1,
+			"component_id":     componentID,
+			"component_status": cachetComponentMajorOutage,
+		}, &created)
+		if err != nil {
+			return err
+		}
+		c.mu.Lock()
+		c.incidents[event.IncidentID] = created.Data.ID
+		c.mu.Unlock()
+	case event.State != StateDown && open:
+		err := c.call(ctx, http.MethodPut, fmt.Sprintf("/api/v1/incidents/%d", incidentID), map[string]any{
+			"status":           cachetIncidentFixed,
+			"message":          fmt.Sprintf("%s has recovered", event.Target),
+			"component_id":     componentID,
+			"component_status": cachetComponentOperational,
+		}, nil)
+		if err != nil {
+			return err
+		}
+		c.mu.Lock()
+		delete(c.incidents, event.IncidentID)
+		c.mu.Unlock()
+	}
+	return nil
+}
+
+// call sends a JSON request to the Cachet API and decodes the response into out
+func (c *CachetSink) call(ctx context.Context, method, path string, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode Cachet request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.URL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create Cachet request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Cachet-Token", c.Token)
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s returned status %d", method, path, resp.StatusCode)
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// incidentMessage describes why a target went down
+func incidentMessage(event Event) string {
+	if event.Result == nil || event.Result.Error == "" {
+		return fmt.Sprintf("%s stopped answering health checks", event.Target)
+	}
+	return fmt.Sprintf("%s stopped answering health checks: %s", event.Target, event.Result.Error)
+}
+
+// GatusSink reports every result to a Gatus instance as an external endpoint
+// (POST /api/v1/endpoints/{key}/external)
+type GatusSink struct {
+	URL    string            // Base URL of the Gatus instance
+	Token  string            // Bearer token configured for the external endpoint
+	Keys   map[string]string // Maps target URLs to external endpoint keys ("<group>_<name>")
+	Key    string            // Key used for targets missing from Keys
+	Client *http.Client
+}
+
+// Send pushes a result to the external endpoint mapped to its target
+func (g *GatusSink) Send(ctx context.Context, event Event) error {
+	if event.Type != EventResult || event.Result == nil {
+		return nil
+	}
+	key, ok := g.Keys[event.Target]
+	if !ok {
+		key = g.Key
+	}
+	if key == "" {
+		return nil
+	}
+
+	query := url.Values{
+		"success":  {strconv.FormatBool(event.Result.Success)},
+		"duration": {event.Result.Latency.String()},
+	}
+	if event.Result.Error != "" {
+		query.Set("error", event.Result.Error)
+	}
+	endpoint := fmt.Sprintf("%s/api/v1/endpoints/%s/external?%s", g.URL, url.PathEscape(key), query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Gatus request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+g.Token)
+
+	return doRequest(g.Client, req)
+}
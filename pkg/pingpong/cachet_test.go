@@ -0,0 +1,62 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCachetSink(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodPost {
+			w.Write([]byte(`{"data":{"id":12}}`))
+		}
+	}))
+	defer server.Close()
+
+	sink := &CachetSink{URL: server.URL, Token: "tok", ComponentID: 3, Incidents: true}
+	for _, state := range []State{StateDown, StateUp} {
+		event := Event{Type: EventStateChange, Target: "http://api", State: state, IncidentID: "inc"}
+		if err := sink.Send(context.Background(), event); err != nil {
+			t.Fatalf("Send returned error: %v", err)
+		}
+	}
+
+	expected := []string{
+		"PUT /api/v1/components/3",
+		"POST /api/v1/incidents",
+		"PUT /api/v1/components/3",
+		"PUT /api/v1/incidents/12",
+	}
+	if len(requests) != len(expected) {
+		t.Fatalf("Expected requests %v, got %v", expected, requests)
+	}
+	for i := range expected {
+		if requests[i] != expected[i] {
+			t.Errorf("Request %d: expected %s, got %s", i, expected[i], requests[i])
+		}
+	}
+}
+
+func TestGatusSink(t *testing.T) {
+	var path, success string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		success = r.URL.Query().Get("success")
+	}))
+	defer server.Close()
+
+	sink := &GatusSink{URL: server.URL, Token: "tok", Key: "core_api"}
+	if err := sink.Send(context.Background(), Event{Type: EventResult, Result: &Result{Success: false, Error: "boom"}}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if path != "/api/v1/endpoints/core_api/external" || success != "false" {
+		t.Errorf("Unexpected request to %s with success=%s", path, success)
+	}
+}
@@ -0,0 +1,226 @@
+package pingpong
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Step is a single HTTP request in a multi-step check. Steps run in order
+// and share a cookie jar and variables, so a check can follow a login or
+// checkout flow.
+type Step struct {
+	Name         string
+	Method       string // Defaults to GET
+	URL          string
+	Headers      map[string]string
+	Body         string
+	ExpectStatus int          // Expected status code (default 200)
+	ExpectBody   string       // If set, the response body must contain this string
+	Extract      []Extraction // Values captured from the response for later steps
+
+	hashBody bool // Record the hash of the response body in the result
+}
+
+// Check performs a single check of a target, running its steps and
+// assertions once without retries, so other programs can reuse the checker
+// for ad-hoc probes without starting a Service. The error is nil only if the
+// check succeeded; the Result describes the outcome either way.
+func Check(ctx context.Context, target Target) (Result, error) {
+	if target.URL == "" && len(target.Steps) == 0 && target.Health == nil {
+		return Result{}, errors.New("target has no URL, steps or health function")
+	}
+	service := NewService(Config{
+		Targets:    []Target{target},
+		MaxRetries: 1,
+		Logger:     quietLogger{},
+	})
+	result := service.pingServer(ctx, service.targets[0])
+	switch {
+	case result.Success:
+		return result, nil
+	case ctx.Err() != nil:
+		return result, ctx.Err()
+	default:
+		return result, fmt.Errorf("%s: %s", result.Reason, result.Error)
+	}
+}
+
+// name returns the step name, falling back to its method and URL
+func (st Step) name() string {
+	if st.Name != "" {
+		return st.Name
+	}
+	return valueOr(st.Method, http.MethodGet) + " " + st.URL
+}
+
+// steps returns the steps making up a check of a target: its Steps, or a
+// single GET to its URL
+func (t *target) steps() []Step {
+	if len(t.Steps) > 0 {
+		return t.Steps
+	}
+	return []Step{{URL: t.URL, ExpectBody: t.ExpectedBody}}
+}
+
+// pingOnce performs a single ping attempt of a target, running every step in
+// order, and records its outcome in result
+func (s *Service) pingOnce(ctx context.Context, t *target, result *Result) bool {
+	result.StatusCode = 0
+	result.Latency = 0
+	result.Reason = ""
+	result.Error = ""
+	result.BodyHash = ""
+	if t.Health != nil {
+		return s.runHealthFunc(ctx, t, result)
+	}
+
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Timeout: t.Timeout, Jar: jar}
+	if len(t.Steps) > 0 {
+		// Recorded flows list redirects as steps of their own
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	steps := t.steps()
+	vars := make(map[string]string)
+	result.Steps = nil
+	for i, step := range steps {
+		step = expandStep(step, vars)
+		step.hashBody = t.hashBody() && i == len(steps)-1
+		ok := s.runStep(ctx, client, step, t.Headers, vars, result)
+		if len(steps) > 1 {
+			result.Steps = append(result.Steps, StepResult{
+				Name:       step.name(),
+				Success:    ok,
+				StatusCode: result.StatusCode,
+				Latency:    result.Latency - stepsLatency(result.Steps),
+				Reason:     result.Reason,
+				Error:      result.Error,
+			})
+		}
+		if !ok {
+			if len(steps) > 1 {
+				result.Error = fmt.Sprintf("step %d (%s): %s", i+1, step.name(), result.Error)
+			}
+			return false
+		}
+	}
+
+	result.Success = true
+	return true
+}
+
+// stepsLatency returns the combined latency of steps
+func stepsLatency(steps []StepResult) time.Duration {
+	var total time.Duration
+	for _, step := range steps {
+		total += step.Latency
+	}
+	return total
+}
+
+// runStep performs a single step with the target's headers, stores extracted
+// values in vars and records its outcome in result
+func (s *Service) runStep(ctx context.Context, client *http.Client, step Step, headers, vars map[string]string, result *Result) bool {
+	var body io.Reader
+	if step.Body != "" {
+		body = strings.NewReader(step.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, valueOr(step.Method, http.MethodGet), step.URL, body)
+	if err != nil {
+		result.Reason = FailureUnknown
+		result.Error = err.Error()
+		return false
+	}
+
+	// Add custom headers
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	for key, value := range step.Headers {
+		req.Header.Set(key, value)
+	}
+
+	// Remember which address we connected to for failure correlation. Dials
+	// may finish on another goroutine after the request has timed out.
+	var remoteMu sync.Mutex
+	var remoteAddr string
+	setRemote := func(addr string) {
+		remoteMu.Lock()
+		defer remoteMu.Unlock()
+		remoteAddr = addr
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			setRemote(info.Conn.RemoteAddr().String())
+		},
+		ConnectDone: func(network, addr string, err error) {
+			setRemote(addr)
+		},
+	}))
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.Latency += time.Since(start)
+	remoteMu.Lock()
+	result.RemoteAddr = remoteAddr
+	remoteMu.Unlock()
+	if err != nil {
+		result.Reason = classifyError(err)
+		result.Error = err.Error()
+		return false
+	}
+	defer resp.Body.Close()
+	result.StatusCode = resp.StatusCode
+
+	expectStatus := step.ExpectStatus
+	if expectStatus == 0 {
+		expectStatus = http.StatusOK
+	}
+	if resp.StatusCode != expectStatus {
+		result.Reason = classifyStatus(resp.StatusCode)
+		result.Error = fmt.Sprintf("unexpected status code: %d", resp.StatusCode)
+		return false
+	}
+
+	if step.ExpectBody == "" && len(step.Extract) == 0 && !step.hashBody {
+		// Drain the body so the connection can be reused by the next step
+		io.Copy(io.Discard, resp.Body)
+		return true
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		result.Reason = classifyError(err)
+		result.Error = err.Error()
+		return false
+	}
+	if step.hashBody {
+		result.BodyHash = hashBody(respBody)
+	}
+	if step.ExpectBody != "" && !strings.Contains(string(respBody), step.ExpectBody) {
+		result.Reason = FailureBodyMismatch
+		result.Error = fmt.Sprintf("response body does not contain %q", step.ExpectBody)
+		return false
+	}
+	for _, extraction := range step.Extract {
+		value, err := extraction.extract(resp.Header, respBody)
+		if err != nil {
+			result.Reason = FailureBodyMismatch
+			result.Error = fmt.Sprintf("extracting %s: %v", extraction.Name, err)
+			return false
+		}
+		vars[extraction.Name] = value
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package pingpong
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte("pong"))
+		case "/slow":
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	result, err := Check(context.Background(), Target{Name: "ok", URL: server.URL + "/ok", ExpectedBody: "pong"})
+	if err != nil || !result.Success || result.Target != "ok" || result.StatusCode != http.StatusOK {
+		t.Errorf("Expected a successful check, got %+v, %v", result, err)
+	}
+
+	// Failures are not retried
+	result, err = Check(context.Background(), Target{URL: server.URL + "/fail"})
+	if err == nil || result.Success || result.Reason != FailureHTTP5xx || result.Attempts != 1 {
+		t.Errorf("Expected a single failed attempt, got %+v, %v", result, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := Check(ctx, Target{URL: server.URL + "/slow"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context deadline to be honoured, got %v", err)
+	}
+
+	if _, err := Check(context.Background(), Target{}); err == nil {
+		t.Error("Expected an error for a target without a URL")
+	}
+}
@@ -0,0 +1,100 @@
+// Package client is a typed Go client for the pingpong admin API. The
+// operations in operations.go are generated from the OpenAPI document served
+// at /api/openapi.json; run go generate after changing the admin API.
+package client
+
+//go:generate go run ../../../internal/clientgen/genclient operations.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Client calls the admin API of a running instance
+type Client struct {
+	URL        string // Base URL of the instance, e.g. http://localhost:8080
+	Token      string // Admin token, if the instance requires one
+	HTTPClient *http.Client
+}
+
+// New returns a client for the instance at baseURL
+func New(baseURL, token string) *Client {
+	return &Client{URL: strings.TrimSuffix(baseURL, "/"), Token: token}
+}
+
+// Error is returned when the admin API responds with a non-2xx status
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("admin API returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// do sends a request with an optional JSON body, decodes the JSON response
+// into out and returns the response headers
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) (http.Header, error) {
+	endpoint := c.URL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, err
+		}
+	}
+	return resp.Header, nil
+}
+
+// headerInt reads an integer response header, returning 0 when it is missing
+func headerInt(header http.Header, name string) (int, error) {
+	value := header.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s header %q", name, value)
+	}
+	return n, nil
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/SumonRayy/ping-pong-go/internal/clientgen"
+	"github.com/SumonRayy/ping-pong-go/pkg/pingpong"
+)
+
+func TestGeneratedOperationsUpToDate(t *testing.T) {
+	expected, err := clientgen.Generate(pingpong.OpenAPI())
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := os.ReadFile("operations.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Error("operations.go is out of date with the admin API; run go generate ./pkg/pingpong/client")
+	}
+}
+
+func TestClient(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := pingpong.NewService(pingpong.Config{
+		ServerURL:    "http://127.0.0.1:1",
+		PingInterval: time.Hour,
+		AdminToken:   "secret",
+		Listener:     listener,
+		Logger:       &pingpong.DefaultLogger{},
+	})
+	if err := service.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer service.Stop()
+
+	ctx := context.Background()
+	client := New("http://"+listener.Addr().String(), "secret")
+	added, err := client.AddTargets(ctx, []pingpong.TargetEntry{{Name: "api", URL: "http://127.0.0.1:2"}})
+	if err != nil || len(added.Added) != 1 || added.Added[0] != "api" {
+		t.Fatalf("Expected the target to be added, got %+v (%v)", added, err)
+	}
+	targets, total, err := client.ListTargets(ctx, ListTargetsParams{})
+	if err != nil || len(targets) != 2 || targets[1].Name != "api" || total != 2 {
+		t.Errorf("Expected both targets to be listed, got %+v of %d (%v)", targets, total, err)
+	}
+	targets, total, err = client.ListTargets(ctx, ListTargetsParams{Sort: "-name", Limit: 1})
+	if err != nil || len(targets) != 1 || targets[0].Name != "http://127.0.0.1:1" || total != 2 {
+		t.Errorf("Expected the first of two targets by descending name, got %+v of %d (%v)", targets, total, err)
+	}
+	silenced, err := client.Silence(ctx, SilenceParams{Duration: "30m"})
+	if err != nil || time.Until(silenced.SilencedUntil) < 29*time.Minute {
+		t.Errorf("Expected notifiers to be silenced for 30m, got %+v (%v)", silenced, err)
+	}
+	if err := client.Unsilence(ctx); err != nil {
+		t.Errorf("Unsilence returned error: %v", err)
+	}
+
+	var apiErr *Error
+	err = New(client.URL, "wrong").Acknowledge(ctx)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 401 {
+		t.Errorf("Expected a 401 error with the wrong token, got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+// Code generated by clientgen from the admin API's OpenAPI document. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/SumonRayy/ping-pong-go/pkg/pingpong"
+)
+
+// Acknowledge calls POST /api/ack: Acknowledge the open incident and notify everyone
+func (c *Client) Acknowledge(ctx context.Context) error {
+	_, err := c.do(ctx, "POST", "/api/ack", nil, nil, nil)
+	return err
+}
+
+// SilenceParams are the query parameters of Silence
+type SilenceParams struct {
+	Duration string // How long to silence notifiers, e.g. 1h (default 1h)
+}
+
+// Silence calls POST /api/silence: Pause notifiers; sinks keep receiving events
+func (c *Client) Silence(ctx context.Context, params SilenceParams) (*pingpong.SilenceResponse, error) {
+	query := url.Values{}
+	if params.Duration != "" {
+		query.Set("duration", params.Duration)
+	}
+	var out pingpong.SilenceResponse
+	if _, err := c.do(ctx, "POST", "/api/silence", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Unsilence calls DELETE /api/silence: Resume notifiers
+func (c *Client) Unsilence(ctx context.Context) error {
+	_, err := c.do(ctx, "DELETE", "/api/silence", nil, nil, nil)
+	return err
+}
+
+// ExportState calls GET /api/state: Snapshot the targets with their state, incidents and counters, and the silence
+func (c *Client) ExportState(ctx context.Context) (*pingpong.Snapshot, error) {
+	var out pingpong.Snapshot
+	if _, err := c.do(ctx, "GET", "/api/state", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ImportState calls POST /api/state: Apply a snapshot exported by another instance
+func (c *Client) ImportState(ctx context.Context, body pingpong.Snapshot) (*pingpong.ImportStateResponse, error) {
+	var out pingpong.ImportStateResponse
+	if _, err := c.do(ctx, "POST", "/api/state", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTargetsParams are the query parameters of ListTargets
+type ListTargetsParams struct {
+	State  string // Only targets in one of these comma-separated states: up, degraded, down or unknown
+	Search string // Only targets whose name or URL contains this, ignoring case
+	Sort   string // Order by name, state, latency, uptime or last_change; prefix with - for descending order
+	Offset int    // Targets skipped after filtering and sorting
+	Limit  int    // Maximum number of targets returned
+}
+
+// ListTargets calls GET /api/targets: List the targets with their effective settings, filtered, sorted and paged
+// It also returns the X-Total-Count header: Number of targets matching the filters before paging
+func (c *Client) ListTargets(ctx context.Context, params ListTargetsParams) ([]pingpong.EffectiveTarget, int, error) {
+	query := url.Values{}
+	if params.State != "" {
+		query.Set("state", params.State)
+	}
+	if params.Search != "" {
+		query.Set("search", params.Search)
+	}
+	if params.Sort != "" {
+		query.Set("sort", params.Sort)
+	}
+	if params.Offset != 0 {
+		query.Set("offset", strconv.Itoa(params.Offset))
+	}
+	if params.Limit != 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	var out []pingpong.EffectiveTarget
+	header, err := c.do(ctx, "GET", "/api/targets", query, nil, &out)
+	if err != nil {
+		return out, 0, err
+	}
+	totalCount, err := headerInt(header, "X-Total-Count")
+	if err != nil {
+		return out, 0, err
+	}
+	return out, totalCount, nil
+}
+
+// AddTargets calls POST /api/targets: Start checking targets; targets whose name is already in use are skipped
+func (c *Client) AddTargets(ctx context.Context, body []pingpong.TargetEntry) (*pingpong.AddTargetsResponse, error) {
+	var out pingpong.AddTargetsResponse
+	if _, err := c.do(ctx, "POST", "/api/targets", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RemoveTargetParams are the query parameters of RemoveTarget
+type RemoveTargetParams struct {
+	Name string // Name of the target to remove
+}
+
+// RemoveTarget calls DELETE /api/targets: Stop checking a target, closing its open incident
+func (c *Client) RemoveTarget(ctx context.Context, params RemoveTargetParams) error {
+	query := url.Values{}
+	if params.Name != "" {
+		query.Set("name", params.Name)
+	}
+	_, err := c.do(ctx, "DELETE", "/api/targets", query, nil, nil)
+	return err
+}
@@ -0,0 +1,36 @@
+package pingpong
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ContentChange describes a change of a target's response body detected by
+// Target.HashBody
+type ContentChange struct {
+	PreviousHash string `json:"previous_hash"` // SHA-256 of the previous body, hex encoded
+	Hash         string `json:"hash"`          // SHA-256 of the new body, hex encoded
+}
+
+// hashBody returns the hex encoded SHA-256 of a response body
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// checkContent remembers the body hash of a result and returns a change when
+// it differs from the previous one. The first hash seen is not a change.
+func (s *Service) checkContent(t *target, result Result) *ContentChange {
+	if result.BodyHash == "" {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	previous := t.bodyHash
+	t.bodyHash = result.BodyHash
+	if previous == "" || previous == result.BodyHash {
+		return nil
+	}
+	s.logger.Warn("Content of %s changed", t.Name)
+	return &ContentChange{PreviousHash: previous, Hash: result.BodyHash}
+}
@@ -0,0 +1,55 @@
+package pingpong
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestService_ContentChanged(t *testing.T) {
+	var body atomic.Value
+	body.Store("v1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body.Load().(string)))
+	}))
+	defer server.Close()
+
+	sink := &recordingSink{}
+	enabled := true
+	service := NewService(Config{
+		Targets:             []Target{{URL: server.URL, HashBody: &enabled}},
+		MaxConsecutiveFails: 10,
+		Sinks:               []Sink{sink},
+		Logger:              &TestLogger{},
+	})
+	target := service.targets[0]
+	check := func() Result {
+		result := service.pingServer(context.Background(), target)
+		service.recordResult(target, result)
+		return result
+	}
+
+	first := check()
+	if first.BodyHash != hashBody([]byte("v1")) {
+		t.Fatalf("Expected the hash of the body, got %q", first.BodyHash)
+	}
+	check()
+	body.Store("defaced")
+	changed := check()
+	check()
+
+	service.shutdown(ShutdownStopped)
+	service.dispatchEvents()
+	events := sink.ofType(EventContentChanged)
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 content_changed event, got %d", len(events))
+	}
+	if change := events[0].ContentChange; change.PreviousHash != first.BodyHash || change.Hash != changed.BodyHash {
+		t.Errorf("Unexpected change %+v", change)
+	}
+	if summary := events[0].Summary(); summary != server.URL+" content changed" {
+		t.Errorf("Unexpected summary %q", summary)
+	}
+}
@@ -0,0 +1,82 @@
+package pingpong
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// DesktopNotifier shows notifications on the local desktop with
+// notify-send on Linux, osascript on macOS and a PowerShell toast on
+// Windows, for developers running pingpong on their workstation against
+// staging environments
+type DesktopNotifier struct {
+	AppName  string // Shown as the notification source (default "pingpong")
+	Degraded bool   // Also show changes between up and degraded
+
+	// run executes the notification command; replaced in tests
+	run func(ctx context.Context, name string, args ...string) error
+}
+
+// Send shows state change and acknowledgement notifications
+func (d *DesktopNotifier) Send(ctx context.Context, event Event) error {
+	if !notifiable(event, d.Degraded) {
+		return nil
+	}
+	name, args, err := desktopCommand(runtime.GOOS, valueOr(d.AppName, "pingpong"), notificationTitle(event), event.Summary(), isOutage(event))
+	if err != nil {
+		return err
+	}
+	run := d.run
+	if run == nil {
+		run = runCommand
+	}
+	return run(ctx, name, args...)
+}
+
+// desktopCommand returns the command showing a notification on goos
+func desktopCommand(goos, app, title, message string, urgent bool) (string, []string, error) {
+	switch goos {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		urgency := "normal"
+		if urgent {
+			urgency = "critical"
+		}
+		return "notify-send", []string{"--app-name=" + app, "--urgency=" + urgency, title, message}, nil
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s subtitle %s", appleScriptString(message), appleScriptString(app), appleScriptString(title))
+		return "osascript", []string{"-e", script}, nil
+	case "windows":
+		script := fmt.Sprintf(`[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode(%s)) > $null
+$text.Item(1).AppendChild($template.CreateTextNode(%s)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier(%s).Show([Windows.UI.Notifications.ToastNotification]::new($template))`,
+			powerShellString(title), powerShellString(message), powerShellString(app))
+		return "powershell", []string{"-NoProfile", "-NonInteractive", "-Command", script}, nil
+	default:
+		return "", nil, fmt.Errorf("desktop notifications are not supported on %s", goos)
+	}
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// powerShellString quotes s as a single-quoted PowerShell string literal
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// runCommand runs a command, including its output in the error if it fails
+func runCommand(ctx context.Context, name string, args ...string) error {
+	output, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", name, err, truncate(strings.TrimSpace(string(output)), 512))
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package pingpong
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDesktopNotifier(t *testing.T) {
+	var calls [][]string
+	notifier := &DesktopNotifier{run: func(ctx context.Context, name string, args ...string) error {
+		calls = append(calls, append([]string{name}, args...))
+		return nil
+	}}
+
+	// Results are not notified
+	notifier.Send(context.Background(), Event{Type: EventResult, Target: "http://api"})
+	err := notifier.Send(context.Background(), Event{Type: EventStateChange, Target: "http://api", State: StateDown, PreviousState: StateUp})
+	if _, _, unsupported := desktopCommand(runtime.GOOS, "", "", "", false); unsupported != nil {
+		t.Skipf("Desktop notifications are not supported on %s", runtime.GOOS)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 || !strings.Contains(strings.Join(calls[0], " "), "http://api is DOWN") {
+		t.Errorf("Expected one notification about the outage, got %v", calls)
+	}
+}
+
+func TestDesktopCommand(t *testing.T) {
+	name, args, _ := desktopCommand("linux", "pingpong", "Target down", "api is DOWN", true)
+	if name != "notify-send" || strings.Join(args, "|") != "--app-name=pingpong|--urgency=critical|Target down|api is DOWN" {
+		t.Errorf("Unexpected linux command: %s %q", name, args)
+	}
+
+	name, args, _ = desktopCommand("darwin", "pingpong", "Target down", `say "hi"`, true)
+	if name != "osascript" || args[1] != `display notification "say \"hi\"" with title "pingpong" subtitle "Target down"` {
+		t.Errorf("Unexpected macOS command: %s %q", name, args)
+	}
+
+	name, args, _ = desktopCommand("windows", "pingpong", "Target down", "it's down", true)
+	if name != "powershell" || !strings.Contains(args[len(args)-1], "'it''s down'") {
+		t.Errorf("Unexpected Windows command: %s %q", name, args)
+	}
+
+	if _, _, err := desktopCommand("plan9", "pingpong", "", "", false); err == nil {
+		t.Error("Expected an error for an unsupported platform")
+	}
+}
@@ -0,0 +1,208 @@
+package pingpong
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DigestNotifier groups state changes that happen within a short window into
+// a single digest notification per group, e.g. "7 targets in group 'eu-west'
+// went down", to cut noise during shared-infrastructure outages. It is meant
+// to be shared by several services so their state changes can be grouped.
+//
+// Buffered state changes are delivered once the window has passed, even if no
+// further event arrives, and when the service shuts down. A group containing a
+// single state change is delivered as is. Errors of deliveries made when the
+// window passes are returned by the next call to Send or Flush.
+type DigestNotifier struct {
+	Notifier Sink
+	Window   time.Duration // How long to collect state changes (default 30s)
+	GroupBy  string        // Label grouping targets (default "group")
+	Degraded bool          // Also collect changes between up and degraded, for notifiers opting in too
+
+	mu      sync.Mutex
+	pending []Event
+	opened  time.Time
+	timer   *time.Timer // Delivers the pending state changes when the window passes
+	err     error       // Error of the last delivery by timer
+}
+
+// digestKey identifies the state changes merged into one digest
+type digestKey struct {
+	group string
+	state State
+}
+
+// Send buffers state changes and delivers digests once the window has passed
+func (d *DigestNotifier) Send(ctx context.Context, event Event) error {
+	now := event.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	d.mu.Lock()
+	var due []Event
+	if len(d.pending) > 0 && now.Sub(d.opened) >= d.window() {
+		due = d.takePending()
+	}
+	if event.Type == EventStateChange && notifiable(event, d.Degraded) {
+		if len(d.pending) == 0 {
+			d.opened = now
+			d.timer = time.AfterFunc(d.window(), func() { d.deliverOpened(now) })
+		}
+		d.pending = append(d.pending, event)
+	}
+	err := d.err
+	d.err = nil
+	d.mu.Unlock()
+
+	err = errors.Join(err, d.deliver(ctx, due))
+	if event.Type != EventStateChange {
+		err = errors.Join(err, d.Notifier.Send(ctx, event))
+	}
+	return err
+}
+
+// Flush delivers all buffered state changes immediately
+func (d *DigestNotifier) Flush(ctx context.Context) error {
+	d.mu.Lock()
+	due := d.takePending()
+	err := d.err
+	d.err = nil
+	d.mu.Unlock()
+	return errors.Join(err, d.deliver(ctx, due))
+}
+
+// deliverOpened delivers the state changes buffered since opened when their
+// window passes without another event delivering them
+func (d *DigestNotifier) deliverOpened(opened time.Time) {
+	d.mu.Lock()
+	if len(d.pending) == 0 || !d.opened.Equal(opened) {
+		d.mu.Unlock()
+		return
+	}
+	due := d.takePending()
+	d.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+	defer cancel()
+	if err := d.deliver(ctx, due); err != nil {
+		d.mu.Lock()
+		d.err = errors.Join(d.err, err)
+		d.mu.Unlock()
+	}
+}
+
+// takePending returns and clears the buffered state changes. The caller must
+// hold d.mu.
+func (d *DigestNotifier) takePending() []Event {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	pending := d.pending
+	d.pending = nil
+	return pending
+}
+
+// window returns the configured window or its default
+func (d *DigestNotifier) window() time.Duration {
+	if d.Window <= 0 {
+		return 30 * time.Second
+	}
+	return d.Window
+}
+
+// deliver groups events and sends one notification per group
+func (d *DigestNotifier) deliver(ctx context.Context, events []Event) error {
+	groupBy := valueOr(d.GroupBy, "group")
+	groups := make(map[digestKey][]Event)
+	var keys []digestKey
+	for _, event := range events {
+		key := digestKey{group: event.Labels[groupBy], state: event.State}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], event)
+	}
+
+	var errs []error
+	for _, key := range keys {
+		group := groups[key]
+		event := group[0]
+		if len(group) > 1 {
+			event = newDigest(group, groupBy, key.group)
+		}
+		if err := d.Notifier.Send(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// newDigest merges several state changes into a digest event
+func newDigest(events []Event, groupBy, group string) Event {
+	last := events[len(events)-1]
+	digest := Event{
+		ID:         newEventID(),
+		Type:       EventDigest,
+		Time:       last.Time,
+		InstanceID: last.InstanceID,
+		State:      last.State,
+		Severity:   SeverityInfo,
+		Related:    events,
+	}
+	if group != "" {
+		digest.Labels = map[string]string{groupBy: group}
+	}
+	for _, event := range events {
+		if severityRank(event.Severity) > severityRank(digest.Severity) {
+			digest.Severity = event.Severity
+		}
+	}
+	if digest.State == StateDown {
+		digest.Hint = commonCause(events, groupBy)
+	}
+	return digest
+}
+
+// severityRank orders severities from least to most urgent
+func severityRank(severity Severity) int {
+	switch severity {
+	case SeverityCritical:
+		return 2
+	case SeverityWarning, "":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// digestSummary describes a digest event
+func digestSummary(e Event) string {
+	verb := "recovered"
+	switch e.State {
+	case StateDown:
+		verb = "went down"
+	case StateDegraded:
+		verb = "became degraded"
+	}
+
+	var groups []string
+	for _, group := range e.Labels {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	summary := fmt.Sprintf("%d targets %s", len(e.Related), verb)
+	if len(groups) > 0 {
+		summary = fmt.Sprintf("%d targets in group '%s' %s", len(e.Related), groups[0], verb)
+	}
+	if e.Hint != "" {
+		summary += fmt.Sprintf(" (probable common cause: %s)", e.Hint)
+	}
+	return summary
+}
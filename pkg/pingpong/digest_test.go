@@ -0,0 +1,102 @@
+package pingpong
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDigestNotifier(t *testing.T) {
+	inner := &recordingSink{}
+	notifier := &DigestNotifier{Notifier: inner, Window: time.Minute}
+
+	start := time.Now()
+	down := func(target, group string, severity Severity, offset time.Duration) Event {
+		return Event{
+			Type:          EventStateChange,
+			Target:        target,
+			State:         StateDown,
+			PreviousState: StateUp,
+			Severity:      severity,
+			Labels:        map[string]string{"group": group},
+			Time:          start.Add(offset),
+		}
+	}
+	events := []Event{
+		down("http://a", "eu-west", SeverityWarning, 0),
+		down("http://b", "eu-west", SeverityCritical, time.Second),
+		down("http://c", "eu-west", SeverityWarning, 2*time.Second),
+		down("http://d", "us-east", SeverityWarning, 3*time.Second),
+		{Type: EventResult, Time: start.Add(30 * time.Second)},
+	}
+	for _, event := range events {
+		if err := notifier.Send(context.Background(), event); err != nil {
+			t.Fatalf("Send returned error: %v", err)
+		}
+	}
+	if len(inner.ofType(EventDigest)) != 0 || len(inner.ofType(EventStateChange)) != 0 {
+		t.Fatal("Expected state changes to be buffered within the window")
+	}
+
+	notifier.Send(context.Background(), Event{Type: EventResult, Time: start.Add(time.Minute)})
+
+	digests := inner.ofType(EventDigest)
+	if len(digests) != 1 {
+		t.Fatalf("Expected one digest, got %+v", digests)
+	}
+	if summary := digests[0].Summary(); summary != "3 targets in group 'eu-west' went down" {
+		t.Errorf("Unexpected digest summary %q", summary)
+	}
+	if digests[0].Severity != SeverityCritical {
+		t.Errorf("Expected digest to carry the highest severity, got %s", digests[0].Severity)
+	}
+	if single := inner.ofType(EventStateChange); len(single) != 1 || single[0].Target != "http://d" {
+		t.Errorf("Expected single us-east state change to be delivered as is, got %+v", single)
+	}
+
+	notifier.Send(context.Background(), down("http://e", "eu-west", SeverityWarning, 2*time.Minute))
+	if err := notifier.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+	if len(inner.ofType(EventStateChange)) != 2 {
+		t.Error("Expected Flush to deliver buffered state changes")
+	}
+
+	// Latency flaps only reach notifiers that opt in, with their own verb
+	inner = &recordingSink{}
+	notifier = &DigestNotifier{Notifier: inner, Window: time.Minute}
+	degraded := down("http://a", "eu-west", SeverityWarning, 0)
+	degraded.State = StateDegraded
+	notifier.Send(context.Background(), degraded)
+	notifier.Flush(context.Background())
+	if len(inner.events) != 0 {
+		t.Errorf("Expected degraded changes to be dropped without opting in, got %+v", inner.events)
+	}
+	notifier.Degraded = true
+	notifier.Send(context.Background(), degraded)
+	degraded.Target = "http://b"
+	notifier.Send(context.Background(), degraded)
+	notifier.Flush(context.Background())
+	if digests := inner.ofType(EventDigest); len(digests) != 1 || digests[0].Summary() != "2 targets in group 'eu-west' became degraded" {
+		t.Errorf("Expected a digest of degraded targets, got %+v", digests)
+	}
+}
+
+func TestDigestNotifier_DeliversWhenWindowPasses(t *testing.T) {
+	inner := &recordingSink{}
+	notifier := &DigestNotifier{Notifier: inner, Window: 50 * time.Millisecond}
+	for _, target := range []string{"http://a", "http://b"} {
+		notifier.Send(context.Background(), Event{Type: EventStateChange, Target: target, State: StateDown, PreviousState: StateUp, Time: time.Now()})
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(inner.ofType(EventDigest)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if digests := inner.ofType(EventDigest); len(digests) != 1 || len(digests[0].Related) != 2 {
+		t.Fatalf("Expected the digest to be delivered without another event, got %+v", digests)
+	}
+	if err := notifier.Flush(context.Background()); err != nil || len(inner.ofType(EventDigest)) != 1 {
+		t.Errorf("Expected nothing left to flush, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Discoverer finds targets to check, for example from a service discovery
+// system. Discover is called every DiscoveryInterval; targets it no longer
+// returns are removed.
+type Discoverer interface {
+	Discover(ctx context.Context) ([]Target, error)
+}
+
+// discover refreshes discovered targets every DiscoveryInterval until the
+// service shuts down
+func (s *Service) discover(ctx context.Context) {
+	ticker := time.NewTicker(s.config.DiscoveryInterval)
+	defer ticker.Stop()
+
+	for {
+		for i, discoverer := range s.config.Discovery {
+			source := fmt.Sprintf("discovery %d", i+1)
+			discoverCtx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+			specs, err := discoverer.Discover(discoverCtx)
+			cancel()
+			if err != nil {
+				s.logger.Error("Target %s failed, keeping previous targets: %v", source, err)
+				continue
+			}
+			s.syncTargets(ctx, source, specs)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncTargets starts targets newly reported by a discovery source and
+// decommissions the ones it no longer reports. A target reported with a new
+// URL, severity or labels is replaced in place once its ping routine has
+// stopped, keeping its state and open incident. Targets already known under
+// the same name, including configured ones, are left alone.
+func (s *Service) syncTargets(ctx context.Context, source string, specs []Target) {
+	wanted := make(map[string]*target, len(specs))
+	var order []*target
+	for _, spec := range specs {
+		t := newTarget(s.config, spec)
+		t.source = source
+		if _, ok := wanted[t.Name]; !ok {
+			order = append(order, t)
+		}
+		wanted[t.Name] = t
+	}
+
+	s.mu.Lock()
+	var added, removed, replaced []*target
+	known := make(map[string]bool, len(s.targets))
+	kept := make([]*target, 0, len(s.targets))
+	for _, t := range s.targets {
+		if t.source == source {
+			if w, ok := wanted[t.Name]; !ok {
+				removed = append(removed, t)
+				continue
+			} else if !sameTarget(t.Target, w.Target) {
+				replaced = append(replaced, t)
+				t = w
+			}
+		}
+		known[t.Name] = true
+		kept = append(kept, t)
+	}
+	s.targets = kept // Removed targets make room
+	room := s.roomForTargets()
+	var ignored int
+	for _, t := range order {
+		if !known[t.Name] {
+			if room == 0 {
+				ignored++
+				continue
+			}
+			room--
+			t = wanted[t.Name]
+			known[t.Name] = true
+			kept = append(kept, t)
+			added = append(added, t)
+		}
+	}
+	s.targets = kept
+	s.mu.Unlock()
+	s.checkLoops(added)
+
+	for _, t := range replaced {
+		s.mu.Lock()
+		cancel, stopped := t.cancel, t.stopped
+		s.mu.Unlock()
+		if cancel != nil {
+			cancel()
+			<-stopped
+		}
+		s.logger.Info("Updated target %s (%s)", t.Name, source)
+		w := wanted[t.Name]
+		w.applyState(t.persistedState())
+		s.checkLoops([]*target{w})
+		s.startTarget(ctx, w)
+	}
+	for _, t := range removed {
+		s.logger.Info("Removed target %s (%s)", t.Name, source)
+		s.decommission(t)
+	}
+	if ignored > 0 {
+		s.logger.Warn("Ignoring %d targets discovered by %s: MaxTargets of %d reached", ignored, source, s.config.MaxTargets)
+	}
+	for _, t := range added {
+		s.logger.Info("Discovered target %s (%s)", t.Name, source)
+		s.restoreTarget(t)
+		s.startTarget(ctx, t)
+	}
+}
+
+// sameTarget reports whether a rediscovered target is unchanged
+func sameTarget(a, b Target) bool {
+	return a.URL == b.URL && a.Severity == b.Severity && maps.Equal(a.Labels, b.Labels)
+}
+
+// fetchJSON decodes the JSON response of a GET request into v
+func fetchJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, v any) error {
+	return requestJSON(ctx, client, http.MethodGet, url, headers, v)
+}
+
+// requestJSON decodes the JSON response of a request without body into v
+func requestJSON(ctx context.Context, client *http.Client, method, url string, headers map[string]string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s returned status %d", method, req.URL.Redacted(), resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("parsing response of %s: %w", req.URL.Redacted(), err)
+	}
+	return nil
+}
+
+// backendURL builds the URL checked on a discovered backend from its
+// address, which may be host:port or a base URL
+func backendURL(address, scheme, path string) string {
+	if !strings.Contains(address, "://") {
+		address = valueOr(scheme, "http") + "://" + address
+	}
+	return strings.TrimSuffix(address, "/") + "/" + strings.TrimPrefix(path, "/")
+}
@@ -0,0 +1,73 @@
+package pingpong
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// staticDiscoverer returns a fixed list of targets
+type staticDiscoverer struct {
+	mu      sync.Mutex
+	targets []Target
+}
+
+func (d *staticDiscoverer) Discover(ctx context.Context) ([]Target, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.targets, nil
+}
+
+func TestService_SyncTargets(t *testing.T) {
+	service := NewService(Config{
+		Targets:      []Target{{URL: "http://static.example.com"}},
+		Discovery:    []Discoverer{&staticDiscoverer{}},
+		PingInterval: time.Hour,
+		Logger:       &TestLogger{},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	names := func() []string {
+		var names []string
+		for _, t := range service.targetList() {
+			names = append(names, t.Name)
+		}
+		return names
+	}
+
+	service.syncTargets(ctx, "discovery 1", []Target{{URL: "http://a"}, {URL: "http://b"}, {URL: "http://static.example.com"}})
+	if got := names(); len(got) != 3 || got[1] != "http://a" || got[2] != "http://b" {
+		t.Fatalf("Expected static and discovered targets, got %v", got)
+	}
+	first := service.targetList()[1]
+
+	service.syncTargets(ctx, "discovery 1", []Target{{URL: "http://a"}})
+	if got := names(); len(got) != 2 || got[1] != "http://a" {
+		t.Fatalf("Expected http://b to be removed, got %v", got)
+	}
+	if service.targetList()[1] != first {
+		t.Error("Expected unchanged target to keep its state")
+	}
+
+	first.mu.Lock()
+	first.state, first.incidentID = StateDown, "incident-1"
+	first.mu.Unlock()
+	service.syncTargets(ctx, "discovery 1", []Target{{URL: "http://a", Labels: map[string]string{"zone": "b"}}})
+	t2 := service.targetList()[1]
+	if t2 == first || t2.Labels["zone"] != "b" {
+		t.Error("Expected relabelled target to be replaced")
+	}
+	select {
+	case <-first.stopped:
+	default:
+		t.Error("Expected the replaced target to have stopped")
+	}
+	if state := t2.persistedState(); state.State != StateDown || state.IncidentID != "incident-1" {
+		t.Errorf("Expected the replacement to carry over the open incident, got %+v", state)
+	}
+	if healthy, _ := service.health(); healthy {
+		t.Error("Expected discovered targets without successful pings to be unhealthy")
+	}
+}
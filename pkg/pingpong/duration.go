@@ -0,0 +1,79 @@
+package pingpong
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParseDuration parses a Go duration such as "2s", "500ms" or "1m30s". A
+// plain integer is read as milliseconds, the unit intervals and timeouts were
+// configured in before duration strings were accepted.
+func ParseDuration(value string) (time.Duration, error) {
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: use a duration such as \"2s\" or \"500ms\", or milliseconds", value)
+	}
+	return d, nil
+}
+
+// Duration is a time.Duration read from configuration files with
+// ParseDuration
+type Duration time.Duration
+
+// UnmarshalYAML accepts duration strings and integer milliseconds
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: expected a duration", node.Line)
+	}
+	parsed, err := ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// String formats the duration like time.Duration
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalYAML writes the duration as a string such as "2s"
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+// MarshalJSON writes the duration as a string such as "2s"
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts duration strings and integer milliseconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	var parsed time.Duration
+	var err error
+	switch value := value.(type) {
+	case string:
+		parsed, err = ParseDuration(value)
+	case float64:
+		parsed, err = ParseDuration(strconv.FormatFloat(value, 'f', -1, 64))
+	default:
+		err = fmt.Errorf("invalid duration %s", data)
+	}
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
@@ -0,0 +1,26 @@
+package pingpong
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"2000":  2 * time.Second,
+		"0":     0,
+		"2s":    2 * time.Second,
+		"500ms": 500 * time.Millisecond,
+		"1m30s": 90 * time.Second,
+	}
+	for value, expected := range tests {
+		if d, err := ParseDuration(value); err != nil || d != expected {
+			t.Errorf("ParseDuration(%q) = %s, %v; expected %s", value, d, err, expected)
+		}
+	}
+	for _, value := range []string{"", "2 seconds", "1.5"} {
+		if _, err := ParseDuration(value); err == nil {
+			t.Errorf("Expected ParseDuration(%q) to fail", value)
+		}
+	}
+}
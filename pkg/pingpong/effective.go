@@ -0,0 +1,247 @@
+package pingpong
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// redacted replaces secrets in EffectiveConfig
+const redacted = "REDACTED"
+
+// EffectiveConfig is the configuration a service runs with once defaults
+// are applied and targets are resolved, with secrets redacted. It answers
+// questions like "why is it pinging the wrong URL" without reading code.
+type EffectiveConfig struct {
+	InstanceID          string             `json:"instance_id" yaml:"instance_id"`
+	Labels              map[string]string  `json:"labels,omitempty" yaml:"labels,omitempty"`
+	OwnURL              string             `json:"own_url,omitempty" yaml:"own_url,omitempty"`
+	OwnCheck            OwnCheckMode       `json:"own_check" yaml:"own_check"`
+	PingInterval        Duration           `json:"ping_interval" yaml:"ping_interval"`
+	MaxConsecutiveFails int                `json:"max_consecutive_fails" yaml:"max_consecutive_fails"`
+	ShutdownOnMaxFails  bool               `json:"shutdown_on_max_fails" yaml:"shutdown_on_max_fails"` // Whether reaching MaxConsecutiveFails shuts the service down rather than parking the target
+	MaxRetries          int                `json:"max_retries" yaml:"max_retries"`
+	RetryOn             map[string]bool    `json:"retry_on,omitempty" yaml:"retry_on,omitempty"`
+	FailureThreshold    int                `json:"failure_threshold" yaml:"failure_threshold"`
+	ResetSuccesses      int                `json:"reset_after_successes,omitempty" yaml:"reset_after_successes,omitempty"`
+	ResetQuietPeriod    Duration           `json:"reset_quiet_period,omitempty" yaml:"reset_quiet_period,omitempty"`
+	MaxConcurrentChecks int                `json:"max_concurrent_checks,omitempty" yaml:"max_concurrent_checks,omitempty"`
+	MaxTargets          int                `json:"max_targets,omitempty" yaml:"max_targets,omitempty"`
+	MaxHistory          int                `json:"max_history,omitempty" yaml:"max_history,omitempty"`
+	MaxQueuedEvents     int                `json:"max_queued_events" yaml:"max_queued_events"`
+	CheckTimeout        Duration           `json:"check_timeout,omitempty" yaml:"check_timeout,omitempty"`
+	ErrorRatio          *EffectiveRatio    `json:"error_ratio,omitempty" yaml:"error_ratio,omitempty"`
+	Discovery           []string           `json:"discovery,omitempty" yaml:"discovery,omitempty"`
+	DiscoveryInterval   Duration           `json:"discovery_interval,omitempty" yaml:"discovery_interval,omitempty"`
+	Peers               []string           `json:"peers,omitempty" yaml:"peers,omitempty"`
+	PeerInterval        Duration           `json:"peer_interval,omitempty" yaml:"peer_interval,omitempty"`
+	RegionLabel         string             `json:"region_label,omitempty" yaml:"region_label,omitempty"`
+	StateFile           string             `json:"state_file,omitempty" yaml:"state_file,omitempty"`
+	StartupHealthy      bool               `json:"startup_healthy" yaml:"startup_healthy"`
+	StartupGrace        Duration           `json:"startup_grace,omitempty" yaml:"startup_grace,omitempty"`
+	RemovalPolicy       RemovalPolicy      `json:"removal_policy" yaml:"removal_policy"`
+	Watchdog            *EffectiveWatchdog `json:"watchdog,omitempty" yaml:"watchdog,omitempty"`
+	Seed                int64              `json:"seed" yaml:"seed"`
+	Jitter              float64            `json:"jitter,omitempty" yaml:"jitter,omitempty"`
+	AdminToken          string             `json:"admin_token,omitempty" yaml:"admin_token,omitempty"`
+	Sinks               []string           `json:"sinks,omitempty" yaml:"sinks,omitempty"`
+	Notifiers           []string           `json:"notifiers,omitempty" yaml:"notifiers,omitempty"`
+	Reporters           []string           `json:"reporters,omitempty" yaml:"reporters,omitempty"`
+	Targets             []EffectiveTarget  `json:"targets" yaml:"targets"`
+}
+
+// EffectiveRatio is the resolved ErrorRatioAlert of an EffectiveConfig
+type EffectiveRatio struct {
+	Window     Duration `json:"window" yaml:"window"`
+	Baseline   Duration `json:"baseline" yaml:"baseline"`
+	Change     float64  `json:"change" yaml:"change"`
+	MinSamples int      `json:"min_samples" yaml:"min_samples"`
+}
+
+// EffectiveWatchdog is the resolved Watchdog of an EffectiveConfig
+type EffectiveWatchdog struct {
+	Multiple int            `json:"multiple" yaml:"multiple"`
+	Action   WatchdogAction `json:"action" yaml:"action"`
+}
+
+// EffectiveTarget is a target with the settings it inherits from Config
+// applied
+type EffectiveTarget struct {
+	Name         string            `json:"name" yaml:"name"`
+	URL          string            `json:"url" yaml:"url"`
+	Steps        []string          `json:"steps,omitempty" yaml:"steps,omitempty"` // "METHOD URL" of each step
+	Interval     Duration          `json:"interval" yaml:"interval"`               // Resolved, falling back to Config.PingInterval
+	Timeout      Duration          `json:"timeout" yaml:"timeout"`
+	SoftTimeout  Duration          `json:"soft_timeout,omitempty" yaml:"soft_timeout,omitempty"`
+	Severity     Severity          `json:"severity" yaml:"severity"`
+	ExpectedBody string            `json:"expected_body,omitempty" yaml:"expected_body,omitempty"`
+	HashBody     bool              `json:"hash_body,omitempty" yaml:"hash_body,omitempty"`
+	HealthFunc   bool              `json:"health_func,omitempty" yaml:"health_func,omitempty"` // Checked by a HealthFunc of the application instead of HTTP requests
+	Headers      map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Notifiers    []string          `json:"notifiers,omitempty" yaml:"notifiers,omitempty"` // Set when the target overrides Config.Notifiers
+	Remediations []string          `json:"remediations,omitempty" yaml:"remediations,omitempty"`
+}
+
+// EffectiveConfig returns the configuration the service runs with. Targets
+// found by discovery are only listed once the service has started.
+func (s *Service) EffectiveConfig() EffectiveConfig {
+	config := s.config
+	effective := EffectiveConfig{
+		InstanceID:          config.InstanceID,
+		Labels:              config.Labels,
+		OwnURL:              redactURL(config.OwnURL),
+		OwnCheck:            s.ownCheck(),
+		PingInterval:        Duration(config.PingInterval),
+		MaxConsecutiveFails: config.MaxConsecutiveFails,
+		ShutdownOnMaxFails:  s.shutdownOnMaxFailures(),
+		MaxRetries:          config.MaxRetries,
+		FailureThreshold:    config.FailureThreshold,
+		ResetSuccesses:      config.ResetPolicy.Successes,
+		ResetQuietPeriod:    Duration(config.ResetPolicy.QuietPeriod),
+		MaxConcurrentChecks: config.MaxConcurrentChecks,
+		MaxTargets:          config.MaxTargets,
+		MaxHistory:          config.MaxHistory,
+		MaxQueuedEvents:     config.MaxQueuedEvents,
+		CheckTimeout:        Duration(config.CheckTimeout),
+		Discovery:           typeNames(config.Discovery),
+		DiscoveryInterval:   Duration(config.DiscoveryInterval),
+		PeerInterval:        Duration(config.PeerInterval),
+		RegionLabel:         config.RegionLabel,
+		StateFile:           config.StateFile,
+		StartupHealthy:      config.StartupHealthy,
+		StartupGrace:        Duration(config.StartupGrace),
+		RemovalPolicy:       RemovalPolicy(valueOr(string(config.RemovalPolicy), string(RemovalPurge))),
+		Seed:                config.Seed,
+		Jitter:              config.Jitter,
+		Sinks:               typeNames(config.Sinks),
+		Notifiers:           typeNames(config.Notifiers),
+		Reporters:           typeNames(config.Reporters),
+		Targets:             []EffectiveTarget{},
+	}
+	if len(config.Discovery) == 0 {
+		effective.DiscoveryInterval = 0
+	}
+	if len(config.Peers) == 0 {
+		effective.PeerInterval = 0
+	}
+	for reason, retry := range config.RetryOn {
+		if effective.RetryOn == nil {
+			effective.RetryOn = make(map[string]bool)
+		}
+		effective.RetryOn[string(reason)] = retry
+	}
+	if config.Watchdog != nil {
+		watchdog := config.Watchdog.withDefaults()
+		effective.Watchdog = &EffectiveWatchdog{Multiple: watchdog.Multiple, Action: watchdog.Action}
+	}
+	if config.ErrorRatio != nil {
+		alert := config.ErrorRatio.withDefaults()
+		effective.ErrorRatio = &EffectiveRatio{
+			Window:     Duration(alert.Window),
+			Baseline:   Duration(alert.Baseline),
+			Change:     alert.Change,
+			MinSamples: alert.MinSamples,
+		}
+	}
+	for _, peer := range config.Peers {
+		effective.Peers = append(effective.Peers, redactURL(peer))
+	}
+	if config.AdminToken != "" {
+		effective.AdminToken = redacted
+	}
+
+	for _, t := range s.targetList() {
+		target := EffectiveTarget{
+			Name:         t.Name,
+			URL:          redactURL(t.URL),
+			Interval:     Duration(t.Interval),
+			Timeout:      Duration(t.Timeout),
+			SoftTimeout:  Duration(t.SoftTimeout),
+			Severity:     t.Severity,
+			ExpectedBody: t.ExpectedBody,
+			HashBody:     t.hashBody(),
+			HealthFunc:   t.Health != nil,
+			Headers:      redactHeaders(t.Headers),
+			Labels:       t.Labels,
+			Notifiers:    typeNames(t.Notifiers),
+			Remediations: typeNames(t.Remediations),
+		}
+		if t.Name == t.URL {
+			target.Name = target.URL
+		}
+		for _, step := range t.Steps {
+			target.Steps = append(target.Steps, valueOr(step.Method, "GET")+" "+redactURL(step.URL))
+		}
+		effective.Targets = append(effective.Targets, target)
+	}
+	return effective
+}
+
+// typeNames returns the type name of each value, which describes sinks and
+// other plugged-in implementations without exposing their credentials
+func typeNames[T any](values []T) []string {
+	var names []string
+	for _, value := range values {
+		t := reflect.TypeOf(value)
+		for t != nil && t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t == nil {
+			continue
+		}
+		names = append(names, t.Name())
+	}
+	return names
+}
+
+// isSecret reports whether a header or query parameter name suggests its
+// value is a credential
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	for _, hint := range []string{"auth", "token", "secret", "password", "passwd", "key", "cookie", "signature", "sig"} {
+		if strings.Contains(name, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactHeaders returns headers with credential values replaced
+func redactHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	safe := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if isSecret(name) {
+			value = redacted
+		}
+		safe[name] = value
+	}
+	return safe
+}
+
+// redactURL removes the password and credential query parameters from a
+// URL
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+	query := u.Query()
+	changed := false
+	for name := range query {
+		if isSecret(name) {
+			query[name] = []string{redacted}
+			changed = true
+		}
+	}
+	if changed {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
@@ -0,0 +1,135 @@
+package pingpong
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrorRatioAlert raises an alert when the share of 4xx and 5xx responses of
+// a target rises sharply, even while checks still pass. Every response of
+// every attempt counts, so it catches partial degradations behind load
+// balancers that retries would otherwise hide.
+type ErrorRatioAlert struct {
+	Window     time.Duration // Recent period whose error ratio is watched (default 5m)
+	Baseline   time.Duration // Period before Window the recent ratio is compared to (default 1h)
+	Change     float64       // Rise in error ratio, between 0 and 1, that raises an alert (default 0.1)
+	MinSamples int           // Responses required in both periods before comparing (default 10)
+}
+
+// ErrorRatioChange describes a change in the error ratio of a target
+type ErrorRatioChange struct {
+	Baseline float64 `json:"baseline"` // Error ratio before the window
+	Current  float64 `json:"current"`  // Error ratio within the window
+	Window   string  `json:"window"`
+	Alerting bool    `json:"alerting"` // False once the ratio is back to normal
+}
+
+// statusSample is a response status code seen while checking a target
+type statusSample struct {
+	time time.Time
+	code int
+}
+
+// withDefaults returns the alert settings with defaults applied
+func (a ErrorRatioAlert) withDefaults() ErrorRatioAlert {
+	if a.Window <= 0 {
+		a.Window = 5 * time.Minute
+	}
+	if a.Baseline <= 0 {
+		a.Baseline = time.Hour
+	}
+	if a.Change <= 0 {
+		a.Change = 0.1
+	}
+	if a.MinSamples <= 0 {
+		a.MinSamples = 10
+	}
+	return a
+}
+
+// recordStatusCode counts a response status code of a target
+func (s *Service) recordStatusCode(t *target, now time.Time, code int) {
+	if code == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.statusCounts[code]++
+	if s.config.ErrorRatio != nil {
+		t.statusSamples = append(t.statusSamples, statusSample{time: now, code: code})
+		s.trimHistory(t)
+	}
+}
+
+// errorRatio returns the share of 4xx and 5xx responses among samples and
+// how many there were
+func errorRatio(samples []statusSample) (float64, int) {
+	if len(samples) == 0 {
+		return 0, 0
+	}
+	errors := 0
+	for _, sample := range samples {
+		if sample.code >= 400 {
+			errors++
+		}
+	}
+	return float64(errors) / float64(len(samples)), len(samples)
+}
+
+// checkErrorRatio compares the recent error ratio of a target with its
+// baseline and returns a change when an alert is raised or cleared. While an
+// alert is open the baseline it was raised against is kept, so the outage
+// does not become the new normal.
+func (s *Service) checkErrorRatio(t *target, now time.Time) *ErrorRatioChange {
+	if s.config.ErrorRatio == nil {
+		return nil
+	}
+	alert := s.config.ErrorRatio.withDefaults()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	windowStart := now.Add(-alert.Window)
+	keep := 0
+	for keep < len(t.statusSamples) && t.statusSamples[keep].time.Before(windowStart.Add(-alert.Baseline)) {
+		keep++
+	}
+	t.statusSamples = t.statusSamples[keep:]
+
+	split := 0
+	for split < len(t.statusSamples) && t.statusSamples[split].time.Before(windowStart) {
+		split++
+	}
+	baseline, baselineSamples := errorRatio(t.statusSamples[:split])
+	current, currentSamples := errorRatio(t.statusSamples[split:])
+	t.errorRatio = current
+	if currentSamples < alert.MinSamples {
+		return nil
+	}
+
+	if t.errorRatioAlert != nil {
+		if current-t.errorRatioAlert.Baseline >= alert.Change {
+			return nil
+		}
+		change := &ErrorRatioChange{Baseline: t.errorRatioAlert.Baseline, Current: current, Window: alert.Window.String()}
+		t.errorRatioAlert = nil
+		return change
+	}
+	if baselineSamples < alert.MinSamples || current-baseline < alert.Change {
+		return nil
+	}
+	t.errorRatioAlert = &ErrorRatioChange{Baseline: baseline, Current: current, Window: alert.Window.String(), Alerting: true}
+	change := *t.errorRatioAlert
+	return &change
+}
+
+// errorRatioSummary describes an error ratio event
+func errorRatioSummary(e Event) string {
+	change := e.ErrorRatio
+	if change == nil {
+		return fmt.Sprintf("%s error ratio changed", e.Target)
+	}
+	if !change.Alerting {
+		return fmt.Sprintf("%s error ratio back to %.0f%% (baseline %.0f%%)", e.Target, change.Current*100, change.Baseline*100)
+	}
+	return fmt.Sprintf("%s error ratio rose from %.0f%% to %.0f%% over the last %s", e.Target, change.Baseline*100, change.Current*100, change.Window)
+}
@@ -0,0 +1,106 @@
+package pingpong
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorRatioAlert(t *testing.T) {
+	service := NewService(Config{
+		ServerURL:  "http://example.com",
+		ErrorRatio: &ErrorRatioAlert{Window: time.Minute, Baseline: 10 * time.Minute, Change: 0.2, MinSamples: 5},
+		Logger:     &TestLogger{},
+	})
+	target := service.targets[0]
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+
+	// respond records one response every 5 seconds, every nth being a 503,
+	// and returns the changes reported along the way
+	respond := func(d time.Duration, n int) []*ErrorRatioChange {
+		var changes []*ErrorRatioChange
+		for i := 0; i < int(d/(5*time.Second)); i++ {
+			now = now.Add(5 * time.Second)
+			code := 200
+			if n > 0 && i%n == 0 {
+				code = 503
+			}
+			service.recordStatusCode(target, now, code)
+			if change := service.checkErrorRatio(target, now); change != nil {
+				changes = append(changes, change)
+			}
+		}
+		return changes
+	}
+
+	if changes := respond(10*time.Minute, 10); len(changes) != 0 {
+		t.Fatalf("Expected no alert for a steady error ratio, got %+v", changes)
+	}
+
+	// Half the responses fail while checks may still pass on retry
+	changes := respond(time.Minute, 2)
+	if len(changes) != 1 || !changes[0].Alerting || changes[0].Baseline > 0.15 || changes[0].Current < 0.3 {
+		t.Fatalf("Expected one alert, got %+v", changes)
+	}
+
+	// The alert stays open while the ratio remains high
+	if changes := respond(5*time.Minute, 2); len(changes) != 0 {
+		t.Fatalf("Expected the alert to stay open, got %+v", changes)
+	}
+
+	changes = respond(2*time.Minute, 0)
+	if len(changes) != 1 || changes[0].Alerting {
+		t.Fatalf("Expected the alert to clear, got %+v", changes)
+	}
+
+	metrics := service.renderMetrics()
+	for _, want := range []string{
+		`pingpong_responses_total{instance_id="` + service.config.InstanceID + `",target="http://example.com",code="200"}`,
+		`pingpong_responses_total{instance_id="` + service.config.InstanceID + `",target="http://example.com",code="503"}`,
+		`pingpong_error_ratio{instance_id="` + service.config.InstanceID + `",target="http://example.com"} 0`,
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("Expected metrics to contain %s, got:\n%s", want, metrics)
+		}
+	}
+}
+
+func TestErrorRatioEvent(t *testing.T) {
+	sink := &recordingSink{}
+	service := NewService(Config{
+		ServerURL:           "http://example.com",
+		MaxConsecutiveFails: 10,
+		ErrorRatio:          &ErrorRatioAlert{Window: time.Minute, MinSamples: 1},
+		Sinks:               []Sink{sink},
+		Logger:              &TestLogger{},
+	})
+	target := service.targets[0]
+	start := time.Now()
+	service.recordStatusCode(target, start.Add(-2*time.Minute), 200)
+	service.recordStatusCode(target, start, 503)
+	service.recordStatusCode(target, start, 200)
+	service.recordResult(target, Result{Success: true, Time: start})
+	service.shutdown(ShutdownStopped)
+	service.dispatchEvents()
+
+	events := sink.ofType(EventErrorRatio)
+	if len(events) != 1 || events[0].ErrorRatio == nil || events[0].ErrorRatio.Current != 0.5 {
+		t.Fatalf("Expected one error ratio event, got %+v", events)
+	}
+	if !notifiable(events[0], false) || !strings.Contains(events[0].Summary(), "rose from 0% to 50%") {
+		t.Errorf("Unexpected summary %q", events[0].Summary())
+	}
+}
+
+func TestErrorRatioSummary_FailedResult(t *testing.T) {
+	event := Event{
+		Type:       EventErrorRatio,
+		Target:     "api",
+		ErrorRatio: &ErrorRatioChange{Baseline: 0, Current: 0.5, Window: "5m0s", Alerting: true},
+		Result:     &Result{Reason: FailureHTTP5xx, Error: "status 503"},
+	}
+	if summary := event.Summary(); summary != "api error ratio rose from 0% to 50% over the last 5m0s (http_5xx: status 503)" {
+		t.Errorf("Expected the failed result like other events, got %q", summary)
+	}
+}
@@ -0,0 +1,292 @@
+package pingpong
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// EventType identifies the kind of event emitted to sinks
+type EventType string
+
+const (
+	// EventResult is emitted after every ping cycle
+	EventResult EventType = "result"
+	// EventStateChange is emitted when the target goes up or down
+	EventStateChange EventType = "state_change"
+	// EventAcknowledged is emitted when someone acknowledges an open incident
+	EventAcknowledged EventType = "acknowledged"
+	// EventDigest groups several state changes into one notification (see DigestNotifier)
+	EventDigest EventType = "digest"
+	// EventRemediation is emitted when a remediation action has run (see Remediation)
+	EventRemediation EventType = "remediation"
+	// EventErrorRatio is emitted when the share of error responses rises sharply or recovers (see ErrorRatioAlert)
+	EventErrorRatio EventType = "error_ratio"
+	// EventContentChanged is emitted when the response body hash of a target differs from the previous check (see Target.HashBody)
+	EventContentChanged EventType = "content_changed"
+	// EventDecommissioned is emitted when a target is removed, carrying the incident it closes if one was open
+	EventDecommissioned EventType = "decommissioned"
+)
+
+// State is the health state of the pinged target
+type State string
+
+const (
+	// StateUnknown means no ping cycle has completed yet
+	StateUnknown State = "unknown"
+	// StateUp means the target is answering pings
+	StateUp State = "up"
+	// StateDegraded means the target answers but slower than its SoftTimeout
+	StateDegraded State = "degraded"
+	// StateDown means the target has failed FailureThreshold consecutive ping cycles
+	StateDown State = "down"
+)
+
+// Severity ranks how urgent problems with the target are
+type Severity string
+
+const (
+	// SeverityInfo is for targets whose outages need no immediate attention
+	SeverityInfo Severity = "info"
+	// SeverityWarning is the default severity
+	SeverityWarning Severity = "warning"
+	// SeverityCritical is for targets whose outages should page someone
+	SeverityCritical Severity = "critical"
+)
+
+// Event is delivered to every configured Sink
+type Event struct {
+	ID            string              `json:"id"`
+	Type          EventType           `json:"type"`
+	Time          time.Time           `json:"time"`
+	InstanceID    string              `json:"instance_id"`
+	Labels        map[string]string   `json:"labels,omitempty"`
+	Target        string              `json:"target"`
+	State         State               `json:"state,omitempty"`
+	PreviousState State               `json:"previous_state,omitempty"`
+	Result        *Result             `json:"result,omitempty"`
+	IncidentID    string              `json:"incident_id,omitempty"` // Shared by all events from going down until recovery
+	Severity      Severity            `json:"severity,omitempty"`
+	Related       []Event             `json:"related,omitempty"`     // State changes grouped into a digest
+	Hint          string              `json:"hint,omitempty"`        // Probable common cause of a digest of outages
+	Remediation   *RemediationOutcome `json:"remediation,omitempty"` // Outcome of a remediation run
+	ErrorRatio    *ErrorRatioChange   `json:"error_ratio,omitempty"` // Change that raised or cleared an error ratio alert
+	ContentChange *ContentChange      `json:"content_change,omitempty"`
+
+	notifiers []Sink // Notifiers of a removed target, which can no longer be looked up by name
+}
+
+// Sink receives events emitted by the service. Send is called from a single
+// dispatcher goroutine, so a slow sink delays other sinks but never pinging.
+type Sink interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// Flusher is implemented by sinks that buffer events. Flush is called after
+// the last event has been delivered when the service shuts down.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// sinkTimeout bounds the time a single Sink.Send call may take
+const sinkTimeout = 10 * time.Second
+
+// eventQueueSize is the default number of events buffered for delivery to
+// sinks
+const eventQueueSize = 100
+
+// newEvent creates an event of the given type about a target stamped with
+// the instance identity
+func (s *Service) newEvent(t *target, eventType EventType, result *Result) Event {
+	return Event{
+		ID:         newEventID(),
+		Type:       eventType,
+		Time:       timestamp(),
+		InstanceID: s.config.InstanceID,
+		Labels:     t.Labels,
+		Target:     t.Name,
+		Severity:   t.Severity,
+		Result:     result,
+	}
+}
+
+// Summary returns a one-line human readable description of the event for
+// notifications
+func (e Event) Summary() string {
+	var summary string
+	switch e.Type {
+	case EventStateChange:
+		summary = fmt.Sprintf("%s is %s", e.Target, strings.ToUpper(string(e.State)))
+	case EventAcknowledged:
+		summary = fmt.Sprintf("%s outage acknowledged", e.Target)
+	case EventContentChanged:
+		summary = fmt.Sprintf("%s content changed", e.Target)
+	case EventDecommissioned:
+		summary = fmt.Sprintf("%s removed", e.Target)
+		if e.IncidentID != "" {
+			summary += ", open incident closed"
+		}
+	case EventDigest:
+		summary = digestSummary(e)
+	case EventErrorRatio:
+		summary = errorRatioSummary(e)
+	case EventRemediation:
+		summary = fmt.Sprintf("%s remediation", e.Target)
+		if e.Remediation != nil {
+			outcome := "succeeded"
+			if !e.Remediation.Success {
+				outcome = "failed: " + e.Remediation.Error
+			}
+			summary += fmt.Sprintf(" %s %s", e.Remediation.Name, outcome)
+		}
+	default:
+		summary = fmt.Sprintf("%s %s", e.Target, e.Type)
+	}
+	if e.Result != nil && !e.Result.Success && e.Result.Error != "" {
+		summary += fmt.Sprintf(" (%s: %s)", e.Result.Reason, e.Result.Error)
+	}
+	return summary
+}
+
+// emit queues an event for delivery, dropping it if the queue is full
+func (s *Service) emit(event Event) {
+	if len(s.config.Sinks) == 0 && len(s.notifiers()) == 0 {
+		return
+	}
+	select {
+	case s.events <- event:
+	default:
+		s.droppedEvents.Add(1)
+		s.logger.Warn("Event queue full (MaxQueuedEvents %d), dropping %s event", cap(s.events), event.Type)
+	}
+}
+
+// dispatchEvents delivers queued events to all sinks until the service shuts
+// down, then flushes whatever is still queued
+func (s *Service) dispatchEvents() {
+	defer close(s.dispatcherDone)
+	for {
+		select {
+		case event := <-s.events:
+			s.deliver(event)
+		case <-s.done:
+			// Deliver the outcome of remediations cancelled by the shutdown
+			s.remediating.Wait()
+			for {
+				select {
+				case event := <-s.events:
+					s.deliver(event)
+				default:
+					s.flushSinks()
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver sends an event to every sink and, unless notifications are
+// silenced, to every notifier, logging failures
+func (s *Service) deliver(event Event) {
+	for _, sink := range s.config.Sinks {
+		s.send(sink, event)
+	}
+	if s.silenced(event.Time) {
+		return
+	}
+	notifiers := s.config.Notifiers
+	if event.notifiers != nil {
+		notifiers = event.notifiers
+	} else if t := s.targetNamed(event.Target); t != nil && t.Notifiers != nil {
+		notifiers = t.Notifiers
+	}
+	for _, notifier := range notifiers {
+		if event.Type == EventDecommissioned {
+			// Deliver what is buffered about the target before it is gone
+			s.flush(notifier)
+		}
+		s.send(notifier, event)
+	}
+}
+
+// notifiers returns every notifier in use, including those of targets,
+// without duplicates
+func (s *Service) notifiers() []Sink {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.allNotifiers
+}
+
+// trackNotifiers adds the notifiers of targets to the ones in use. The
+// caller must hold s.mu unless the service is not shared yet.
+func (s *Service) trackNotifiers(targets []*target) {
+	for _, t := range targets {
+		s.allNotifiers = appendSinks(s.allNotifiers, t.Notifiers...)
+	}
+}
+
+// appendSinks appends the sinks not in list yet
+func appendSinks(list []Sink, sinks ...Sink) []Sink {
+	for _, sink := range sinks {
+		if !slices.ContainsFunc(list, func(other Sink) bool { return sameSink(sink, other) }) {
+			list = append(list, sink)
+		}
+	}
+	return list
+}
+
+// sameSink reports whether a and b are the same sink: the same pointer, or
+// equal values of a comparable type. Sinks of other types, such as funcs or
+// structs holding a map, are never the same rather than panicking.
+func sameSink(a, b Sink) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !va.IsValid() || !vb.IsValid() || va.Type() != vb.Type() {
+		return !va.IsValid() && !vb.IsValid()
+	}
+	switch va.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Chan:
+		return va.Pointer() == vb.Pointer()
+	}
+	return va.Comparable() && vb.Comparable() && va.Equal(vb)
+}
+
+// flushSinks flushes every sink and notifier that buffers events
+func (s *Service) flushSinks() {
+	for _, sink := range append(slices.Clone(s.config.Sinks), s.notifiers()...) {
+		s.flush(sink)
+	}
+}
+
+// flush flushes a sink if it buffers events
+func (s *Service) flush(sink Sink) {
+	flusher, ok := sink.(Flusher)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+	defer cancel()
+	if err := flusher.Flush(ctx); err != nil {
+		s.logger.Error("Error flushing %T: %v", sink, err)
+	}
+}
+
+// send delivers an event to a single sink with a timeout
+func (s *Service) send(sink Sink, event Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+	defer cancel()
+	if err := sink.Send(ctx, event); err != nil {
+		s.logger.Error("Error sending %s event to %T: %v", event.Type, sink, err)
+	}
+}
+
+// newEventID returns a random identifier for an event
+func newEventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,99 @@
+package pingpong
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// recordingSink collects every event it receives
+type recordingSink struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recordingSink) Send(ctx context.Context, event Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+// ofType returns the received events of the given type
+func (r *recordingSink) ofType(eventType EventType) []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []Event
+	for _, event := range r.events {
+		if event.Type == eventType {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func TestService_StateChangeEvents(t *testing.T) {
+	sink := &recordingSink{}
+	service := NewService(Config{
+		ServerURL:           "http://example.com",
+		MaxConsecutiveFails: 10,
+		FailureThreshold:    2,
+		Sinks:               []Sink{sink},
+		Logger:              &TestLogger{},
+	})
+
+	for _, success := range []bool{true, false, false, false, true} {
+		service.recordResult(service.targets[0], Result{Success: success})
+	}
+	service.shutdown(ShutdownStopped)
+	service.dispatchEvents()
+
+	if results := sink.ofType(EventResult); len(results) != 5 {
+		t.Errorf("Expected 5 result events, got %d", len(results))
+	}
+	changes := sink.ofType(EventStateChange)
+	expected := []State{StateUp, StateDown, StateUp}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d state changes, got %+v", len(expected), changes)
+	}
+	for i, state := range expected {
+		if changes[i].State != state {
+			t.Errorf("State change %d: expected %s, got %s", i, state, changes[i].State)
+		}
+	}
+	if changes[1].PreviousState != StateUp || changes[1].Target != "http://example.com" {
+		t.Errorf("Unexpected down event: %+v", changes[1])
+	}
+	if changes[1].IncidentID == "" || changes[2].IncidentID != changes[1].IncidentID {
+		t.Errorf("Expected down and recovery events to share an incident ID, got %q and %q", changes[1].IncidentID, changes[2].IncidentID)
+	}
+}
+
+// mapSink is a sink whose type is not comparable
+type mapSink struct {
+	fields map[string]string
+}
+
+func (mapSink) Send(ctx context.Context, event Event) error { return nil }
+
+func TestService_NotifiersDeduplicated(t *testing.T) {
+	shared, other := &recordingSink{}, mapSink{fields: map[string]string{}}
+	service := NewService(Config{
+		Targets: []Target{
+			{Name: "a", URL: "http://a", Notifiers: []Sink{shared, other}},
+			{Name: "b", URL: "http://b", Notifiers: []Sink{shared, other}},
+		},
+		Notifiers: []Sink{shared},
+		Logger:    &TestLogger{},
+	})
+	// The value sink cannot be compared, so it is kept once per target
+	if n := len(service.notifiers()); n != 3 {
+		t.Errorf("Expected the shared notifier once and the value sink per target, got %d notifiers", n)
+	}
+	if _, err := service.AddTargets([]Target{{Name: "c", URL: "http://c", Notifiers: []Sink{shared, &recordingSink{}}}}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(service.notifiers()); n != 4 {
+		t.Errorf("Expected the new notifier of an added target to be tracked, got %d notifiers", n)
+	}
+}
@@ -0,0 +1,109 @@
+package pingpong
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// EventBridgeSink puts state change events onto an AWS EventBridge bus so
+// outages can trigger serverless remediation workflows
+type EventBridgeSink struct {
+	Region       string         // Required, also with an overridden endpoint since requests are signed for it
+	EventBusName string         // Defaults to "default"
+	Source       string         // Defaults to "pingpong"
+	DetailType   string         // Defaults to "Pingpong State Change"
+	Credentials  AWSCredentials // Defaults to the AWS_* environment variables
+	AllEvents    bool           // Also put result events, not just state changes
+	Endpoint     string         // Overrides https://events.<region>.amazonaws.com
+	Client       *http.Client
+}
+
+// eventBridgeEntry is a single PutEvents request entry
+type eventBridgeEntry struct {
+	Source       string  `json:"Source"`
+	DetailType   string  `json:"DetailType"`
+	Detail       string  `json:"Detail"`
+	EventBusName string  `json:"EventBusName"`
+	Time         float64 `json:"Time"`
+}
+
+// eventBridgeResponse is the PutEvents response
+type eventBridgeResponse struct {
+	FailedEntryCount int `json:"FailedEntryCount"`
+	Entries          []struct {
+		ErrorCode    string `json:"ErrorCode"`
+		ErrorMessage string `json:"ErrorMessage"`
+	} `json:"Entries"`
+}
+
+// Send puts the event onto the configured bus
+func (e *EventBridgeSink) Send(ctx context.Context, event Event) error {
+	if event.Type != EventStateChange && !e.AllEvents {
+		return nil
+	}
+	if e.Region == "" {
+		return errors.New("no AWS region configured")
+	}
+
+	detail, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	entry := eventBridgeEntry{
+		Source:       valueOr(e.Source, "pingpong"),
+		DetailType:   valueOr(e.DetailType, "Pingpong State Change"),
+		Detail:       string(detail),
+		EventBusName: valueOr(e.EventBusName, "default"),
+		Time:         float64(event.Time.UnixMilli()) / 1000,
+	}
+	body, err := json.Marshal(map[string][]eventBridgeEntry{"Entries": {entry}})
+	if err != nil {
+		return fmt.Errorf("failed to encode PutEvents request: %w", err)
+	}
+
+	endpoint := valueOr(e.Endpoint, "https://events."+e.Region+".amazonaws.com/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create PutEvents request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AWSEvents.PutEvents")
+	signAWSRequest(req, body, e.Credentials.orEnv(), e.Region, "events", time.Now())
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("PutEvents returned status %d: %s", resp.StatusCode, data)
+	}
+	var result eventBridgeResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("failed to decode PutEvents response: %w", err)
+	}
+	if result.FailedEntryCount > 0 && len(result.Entries) > 0 {
+		return fmt.Errorf("PutEvents rejected event: %s: %s", result.Entries[0].ErrorCode, result.Entries[0].ErrorMessage)
+	}
+	return nil
+}
+
+// valueOr returns value, or fallback if value is empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,65 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAWSRequest(t *testing.T) {
+	// get-vanilla from the AWS Signature Version 4 test suite
+	req, _ := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	creds := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signAWSRequest(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if auth := req.Header.Get("Authorization"); auth != expected {
+		t.Errorf("Unexpected Authorization header:\n got: %s\nwant: %s", auth, expected)
+	}
+}
+
+func TestEventBridgeSink(t *testing.T) {
+	var calls int
+	var entries map[string][]eventBridgeEntry
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("X-Amz-Target") != "AWSEvents.PutEvents" || !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+			t.Errorf("Unexpected headers: %v", r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &entries)
+		w.Write([]byte(`{"FailedEntryCount":0,"Entries":[{"EventId":"1"}]}`))
+	}))
+	defer server.Close()
+
+	sink := &EventBridgeSink{
+		Region:      "eu-west-1",
+		DetailType:  "Target Health",
+		Credentials: AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+		Endpoint:    server.URL,
+	}
+
+	if err := sink.Send(context.Background(), Event{Type: EventResult}); err != nil || calls != 0 {
+		t.Errorf("Expected result events to be skipped, got %d calls (%v)", calls, err)
+	}
+	if err := sink.Send(context.Background(), Event{Type: EventStateChange, State: StateDown}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	entry := entries["Entries"][0]
+	if entry.DetailType != "Target Health" || entry.Source != "pingpong" || entry.EventBusName != "default" ||
+		!strings.Contains(entry.Detail, `"state":"down"`) {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+
+	sink.Region = ""
+	if err := sink.Send(context.Background(), Event{Type: EventStateChange, State: StateDown}); err == nil || calls != 1 {
+		t.Errorf("Expected an error without sending when no region is configured, got %d calls (%v)", calls, err)
+	}
+}
@@ -0,0 +1,159 @@
+package pingpong
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ExtractSource selects where an Extraction reads its value from
+type ExtractSource string
+
+const (
+	// ExtractJSON evaluates a JSONPath expression such as $.data.items[0].id against the response body
+	ExtractJSON ExtractSource = "json"
+	// ExtractRegex uses the first capture group of a regular expression matched against the response body
+	ExtractRegex ExtractSource = "regex"
+	// ExtractHeader reads a response header
+	ExtractHeader ExtractSource = "header"
+)
+
+// Extraction captures a value from a step's response into a variable that
+// later steps reference as {{name}} in their URL, headers, body and expected body
+type Extraction struct {
+	Name string
+	From ExtractSource
+	Expr string
+}
+
+// variablePattern matches {{name}} references
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// expand replaces {{name}} references with variable values, leaving unknown
+// references untouched
+func expand(s string, vars map[string]string) string {
+	if len(vars) == 0 || !strings.Contains(s, "{{") {
+		return s
+	}
+	return variablePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if value, ok := vars[variablePattern.FindStringSubmatch(ref)[1]]; ok {
+			return value
+		}
+		return ref
+	})
+}
+
+// expandStep returns a copy of step with variable references expanded
+func expandStep(step Step, vars map[string]string) Step {
+	step.URL = expand(step.URL, vars)
+	step.Body = expand(step.Body, vars)
+	step.ExpectBody = expand(step.ExpectBody, vars)
+	if len(step.Headers) > 0 {
+		headers := make(map[string]string, len(step.Headers))
+		for key, value := range step.Headers {
+			headers[key] = expand(value, vars)
+		}
+		step.Headers = headers
+	}
+	return step
+}
+
+// extract evaluates an extraction against a response
+func (e Extraction) extract(header http.Header, body []byte) (string, error) {
+	switch e.From {
+	case ExtractHeader:
+		if value := header.Get(e.Expr); value != "" {
+			return value, nil
+		}
+		return "", fmt.Errorf("response has no %s header", e.Expr)
+	case ExtractRegex:
+		re, err := regexp.Compile(e.Expr)
+		if err != nil {
+			return "", fmt.Errorf("invalid regex %q: %w", e.Expr, err)
+		}
+		match := re.FindSubmatch(body)
+		if match == nil {
+			return "", fmt.Errorf("response body does not match %q", e.Expr)
+		}
+		if len(match) > 1 {
+			return string(match[1]), nil
+		}
+		return string(match[0]), nil
+	case ExtractJSON, "":
+		return jsonPath(body, e.Expr)
+	default:
+		return "", fmt.Errorf("unknown extraction source %q", e.From)
+	}
+}
+
+// jsonPath evaluates a simple JSONPath expression made of .field, ['field']
+// and [index] selectors against a JSON document
+func jsonPath(body []byte, expr string) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("response body is not JSON: %w", err)
+	}
+
+	path := strings.TrimPrefix(strings.TrimSpace(expr), "$")
+	for path != "" {
+		var key string
+		index := -1
+		switch {
+		case strings.HasPrefix(path, "."):
+			path = path[1:]
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			key, path = path[:end], path[end:]
+		case strings.HasPrefix(path, "["):
+			end := strings.Index(path, "]")
+			if end < 0 {
+				return "", fmt.Errorf("invalid JSONPath %q", expr)
+			}
+			selector := strings.Trim(path[1:end], `'"`)
+			path = path[end+1:]
+			if i, err := strconv.Atoi(selector); err == nil {
+				index = i
+			} else {
+				key = selector
+			}
+		default:
+			return "", fmt.Errorf("invalid JSONPath %q", expr)
+		}
+
+		if index >= 0 {
+			items, ok := value.([]any)
+			if !ok || index >= len(items) {
+				return "", fmt.Errorf("JSONPath %q: index %d not found", expr, index)
+			}
+			value = items[index]
+			continue
+		}
+		fields, ok := value.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("JSONPath %q: field %q not found", expr, key)
+		}
+		if value, ok = fields[key]; !ok {
+			return "", fmt.Errorf("JSONPath %q: field %q not found", expr, key)
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case nil:
+		return "", fmt.Errorf("JSONPath %q is null", expr)
+	default:
+		encoded, err := json.Marshal(v)
+		return string(encoded), err
+	}
+}
@@ -0,0 +1,84 @@
+package pingpong
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	body := []byte(`{"data":{"token":"abc","items":[{"id":7},{"id":8}],"ok":true}}`)
+	tests := map[string]string{
+		"$.data.token":       "abc",
+		"$.data.items[1].id": "8",
+		"$['data'].ok":       "true",
+		"$.data.items[0]":    `{"id":7}`,
+	}
+	for expr, want := range tests {
+		if got, err := jsonPath(body, expr); err != nil || got != want {
+			t.Errorf("jsonPath(%q) = %q, %v; want %q", expr, got, err, want)
+		}
+	}
+	if _, err := jsonPath(body, "$.data.missing"); err == nil {
+		t.Error("Expected an error for a missing field")
+	}
+}
+
+func TestSteps_ExtractAndChain(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			w.Header().Set("X-Request-Id", "req-1")
+			w.Write([]byte(`{"data":{"token":"t0k3n","user":{"id":42}}}`))
+		case "/users/42":
+			if r.Header.Get("Authorization") != "Bearer t0k3n" || r.Header.Get("X-Trace") != "req-1" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`<span class="csrf">c5rf</span>`))
+		case "/confirm":
+			if body := r.FormValue("csrf"); body != "c5rf" {
+				http.Error(w, "bad csrf", http.StatusForbidden)
+				return
+			}
+			w.Write([]byte("confirmed"))
+		}
+	}))
+	defer server.Close()
+
+	steps := []Step{
+		{Name: "login", Method: http.MethodPost, URL: server.URL + "/login", Extract: []Extraction{
+			{Name: "token", From: ExtractJSON, Expr: "$.data.token"},
+			{Name: "user", From: ExtractJSON, Expr: "$.data.user.id"},
+			{Name: "request", From: ExtractHeader, Expr: "X-Request-Id"},
+		}},
+		{Name: "profile", URL: server.URL + "/users/{{user}}",
+			Headers: map[string]string{"Authorization": "Bearer {{token}}", "X-Trace": "{{request}}"},
+			Extract: []Extraction{{Name: "csrf", From: ExtractRegex, Expr: `class="csrf">([^<]+)<`}}},
+		{Name: "confirm", Method: http.MethodPost, URL: server.URL + "/confirm", Body: "csrf={{csrf}}",
+			Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, ExpectBody: "confirmed"},
+	}
+
+	result := pingConfig(Config{Steps: steps, MaxRetries: 1, Logger: &TestLogger{}})
+	if !result.Success {
+		t.Fatalf("Expected chained flow to succeed, got %s: %s", result.Reason, result.Error)
+	}
+	if len(result.Steps) != 3 {
+		t.Fatalf("Expected 3 step results, got %+v", result.Steps)
+	}
+	for _, step := range result.Steps {
+		if !step.Success || step.StatusCode != http.StatusOK || step.Latency <= 0 {
+			t.Errorf("Unexpected step result: %+v", step)
+		}
+	}
+
+	steps[0].Extract[0].Expr = "$.data.missing"
+	result = pingConfig(Config{Steps: steps, MaxRetries: 1, Logger: &TestLogger{}})
+	if result.Success || result.Reason != FailureBodyMismatch || !strings.HasPrefix(result.Error, "step 1 (login): extracting token") {
+		t.Errorf("Expected extraction failure in step 1, got %s: %s", result.Reason, result.Error)
+	}
+	if len(result.Steps) != 1 || result.Steps[0].Success {
+		t.Errorf("Expected a single failed step result, got %+v", result.Steps)
+	}
+}
@@ -0,0 +1,96 @@
+package pingpong
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+)
+
+// FailureReason classifies why a ping failed so that network problems can be
+// told apart from application problems
+type FailureReason string
+
+const (
+	// FailureDNS means the target host name could not be resolved
+	FailureDNS FailureReason = "dns_error"
+	// FailureConnectTimeout means the TCP connection could not be established in time
+	FailureConnectTimeout FailureReason = "connect_timeout"
+	// FailureConnect means the TCP connection was refused or reset
+	FailureConnect FailureReason = "connect_error"
+	// FailureTLS means the TLS handshake or certificate verification failed
+	FailureTLS FailureReason = "tls_error"
+	// FailureHTTP4xx means the target answered with a 4xx status code
+	FailureHTTP4xx FailureReason = "http_4xx"
+	// FailureHTTP5xx means the target answered with a 5xx status code
+	FailureHTTP5xx FailureReason = "http_5xx"
+	// FailureHTTPStatus means the target answered with another unexpected status code
+	FailureHTTPStatus FailureReason = "http_status"
+	// FailureBodyMismatch means the response body did not contain the expected content
+	FailureBodyMismatch FailureReason = "body_mismatch"
+	// FailureDeadline means the request did not complete within the timeout
+	FailureDeadline FailureReason = "deadline"
+	// FailureHealthFunc means a HealthFunc of the embedding application
+	// reported a problem
+	FailureHealthFunc FailureReason = "health_func"
+	// FailureUnknown is used for errors that fit no other class
+	FailureUnknown FailureReason = "unknown"
+)
+
+// Retryable reports whether failures of this class are retried by default.
+// Timeouts, connection problems and server errors are usually transient;
+// client errors, TLS problems and body mismatches are not.
+func (r FailureReason) Retryable() bool {
+	switch r {
+	case FailureHTTP4xx, FailureHTTPStatus, FailureBodyMismatch, FailureTLS:
+		return false
+	default:
+		return true
+	}
+}
+
+// classifyError maps a transport error to a FailureReason
+func classifyError(err error) FailureReason {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return FailureDNS
+	}
+
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &certErr) || errors.As(err, &recordErr) || errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return FailureTLS
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		if opErr.Timeout() {
+			return FailureConnectTimeout
+		}
+		return FailureConnect
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return FailureDeadline
+	}
+
+	return FailureUnknown
+}
+
+// classifyStatus maps an unexpected HTTP status code to a FailureReason
+func classifyStatus(code int) FailureReason {
+	switch {
+	case code >= 400 && code < 500:
+		return FailureHTTP4xx
+	case code >= 500 && code < 600:
+		return FailureHTTP5xx
+	default:
+		return FailureHTTPStatus
+	}
+}
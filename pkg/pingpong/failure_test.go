@@ -0,0 +1,106 @@
+package pingpong
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClassifyStatus(t *testing.T) {
+	tests := map[int]FailureReason{
+		http.StatusNotFound:           FailureHTTP4xx,
+		http.StatusUnauthorized:       FailureHTTP4xx,
+		http.StatusBadGateway:         FailureHTTP5xx,
+		http.StatusNoContent:          FailureHTTPStatus,
+		http.StatusMovedPermanently:   FailureHTTPStatus,
+		http.StatusServiceUnavailable: FailureHTTP5xx,
+	}
+	for code, expected := range tests {
+		if reason := classifyStatus(code); reason != expected {
+			t.Errorf("classifyStatus(%d) = %q, expected %q", code, reason, expected)
+		}
+	}
+}
+
+func TestPingServer_FailureReasons(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	body := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("degraded"))
+	}))
+	defer body.Close()
+
+	tls := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tls.Close()
+
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closedURL := closed.URL
+	closed.Close()
+
+	tests := []struct {
+		name     string
+		config   Config
+		expected FailureReason
+	}{
+		{"deadline", Config{ServerURL: slow.URL, Timeout: 50 * time.Millisecond}, FailureDeadline},
+		{"body", Config{ServerURL: body.URL, ExpectedBody: "ok"}, FailureBodyMismatch},
+		{"tls", Config{ServerURL: tls.URL}, FailureTLS},
+		{"connect", Config{ServerURL: closedURL}, FailureConnect},
+		{"dns", Config{ServerURL: "http://pingpong.invalid/health"}, FailureDNS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.MaxRetries = 1
+			tt.config.Logger = &TestLogger{}
+			result := pingConfig(tt.config)
+			if result.Success || result.Reason != tt.expected {
+				t.Errorf("Expected failure reason %q, got success=%v reason=%q (%s)", tt.expected, result.Success, result.Reason, result.Error)
+			}
+		})
+	}
+}
+
+func TestService_MetricsReasonLabels(t *testing.T) {
+	service := NewService(Config{InstanceID: "probe-1", Labels: map[string]string{"region": "eu-west"}, Logger: &TestLogger{}})
+	service.recordResult(service.targets[0], Result{Reason: FailureHTTP5xx})
+	service.recordResult(service.targets[0], Result{Reason: FailureHTTP5xx})
+	service.recordResult(service.targets[0], Result{Success: true})
+
+	metrics := service.renderMetrics()
+	for _, expected := range []string{
+		`pingpong_checks_total{instance_id="probe-1",region="eu-west",result="failure",reason="http_5xx"} 2`,
+		`pingpong_checks_total{instance_id="probe-1",region="eu-west",result="success"} 1`,
+	} {
+		if !strings.Contains(metrics, expected) {
+			t.Errorf("Expected metrics to contain %s, got:\n%s", expected, metrics)
+		}
+	}
+}
+
+func TestPingServer_RetryOnlyRetryable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	result := pingConfig(Config{ServerURL: server.URL, MaxRetries: 3, Logger: &TestLogger{}})
+	if result.Attempts != 1 {
+		t.Errorf("Expected 404 not to be retried, got %d attempts", result.Attempts)
+	}
+
+	config := Config{
+		ServerURL:  server.URL,
+		MaxRetries: 2,
+		RetryOn:    map[FailureReason]bool{FailureHTTP4xx: true},
+		Logger:     &TestLogger{},
+	}
+	result = pingConfig(config)
+	if result.Attempts != 2 {
+		t.Errorf("Expected RetryOn override to retry 404, got %d attempts", result.Attempts)
+	}
+}
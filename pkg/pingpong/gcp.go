@@ -0,0 +1,65 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// gcpMetadataTokenURL is the metadata server endpoint issuing access tokens
+// for the default service account on GCE, GKE and Cloud Run
+const gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// GCPTokenSource returns an OAuth2 access token for Google Cloud APIs
+type GCPTokenSource func(ctx context.Context) (string, error)
+
+// StaticGCPToken returns a token source that always returns token
+func StaticGCPToken(token string) GCPTokenSource {
+	return func(ctx context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+// GCPMetadataToken returns a token source that fetches and caches access
+// tokens from the GCP metadata server
+func GCPMetadataToken() GCPTokenSource {
+	var mu sync.Mutex
+	var token string
+	var expiry time.Time
+
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if token != "" && time.Until(expiry) > time.Minute {
+			return token, nil
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpMetadataTokenURL, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch token from metadata server: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("metadata server returned status %d", resp.StatusCode)
+		}
+
+		var body struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int    `json:"expires_in"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return "", fmt.Errorf("failed to decode metadata token: %w", err)
+		}
+		token = body.AccessToken
+		expiry = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+		return token, nil
+	}
+}
@@ -0,0 +1,115 @@
+package pingpong
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"sync"
+)
+
+// GCPDiscovery creates a target for every running instance of Compute
+// Engine instance groups
+type GCPDiscovery struct {
+	Project        string
+	Zone           string
+	InstanceGroups []string          // Names of zonal instance groups
+	Labels         map[string]string // Only instances with all of these labels are checked
+	Port           int               // Defaults to 80
+	Scheme         string            // Defaults to http
+	Path           string            // Defaults to /
+	TokenSource    GCPTokenSource    // Defaults to GCPMetadataToken
+	Endpoint       string            // Overrides https://compute.googleapis.com
+	Client         *http.Client
+
+	defaultToken GCPTokenSource // Created once, so concurrent discoveries share its cached token
+	tokenOnce    sync.Once
+}
+
+// gcpInstance is a Compute Engine instance
+type gcpInstance struct {
+	Name              string            `json:"name"`
+	Status            string            `json:"status"`
+	Labels            map[string]string `json:"labels"`
+	NetworkInterfaces []struct {
+		NetworkIP string `json:"networkIP"`
+	} `json:"networkInterfaces"`
+}
+
+// token returns the configured token source, or the default one
+func (d *GCPDiscovery) token() GCPTokenSource {
+	if d.TokenSource != nil {
+		return d.TokenSource
+	}
+	d.tokenOnce.Do(func() {
+		d.defaultToken = GCPMetadataToken()
+	})
+	return d.defaultToken
+}
+
+// Discover lists the running instances of the configured instance groups
+func (d *GCPDiscovery) Discover(ctx context.Context) ([]Target, error) {
+	token, err := d.token()(ctx)
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{"Authorization": "Bearer " + token}
+	base := fmt.Sprintf("%s/compute/v1/projects/%s/zones/%s", valueOr(d.Endpoint, "https://compute.googleapis.com"), d.Project, d.Zone)
+	port := d.Port
+	if port == 0 {
+		port = 80
+	}
+
+	var targets []Target
+	for _, group := range d.InstanceGroups {
+		pageToken := ""
+		for {
+			listURL := fmt.Sprintf("%s/instanceGroups/%s/listInstances?filter=%s", base, url.PathEscape(group), url.QueryEscape(`status = "RUNNING"`))
+			if pageToken != "" {
+				listURL += "&pageToken=" + url.QueryEscape(pageToken)
+			}
+			var page struct {
+				Items []struct {
+					Instance string `json:"instance"`
+				} `json:"items"`
+				NextPageToken string `json:"nextPageToken"`
+			}
+			if err := requestJSON(ctx, d.Client, http.MethodPost, listURL, headers, &page); err != nil {
+				return nil, err
+			}
+
+			for _, item := range page.Items {
+				var instance gcpInstance
+				if err := fetchJSON(ctx, d.Client, base+"/instances/"+url.PathEscape(path.Base(item.Instance)), headers, &instance); err != nil {
+					return nil, err
+				}
+				if !d.matches(instance) || len(instance.NetworkInterfaces) == 0 {
+					continue
+				}
+				targets = append(targets, Target{
+					URL:    backendURL(instance.NetworkInterfaces[0].NetworkIP+":"+strconv.Itoa(port), d.Scheme, d.Path),
+					Labels: map[string]string{"instance": instance.Name, "instance_group": group, "zone": d.Zone},
+				})
+			}
+			if pageToken = page.NextPageToken; pageToken == "" {
+				break
+			}
+		}
+	}
+	return targets, nil
+}
+
+// matches reports whether an instance is running and has every label filter
+func (d *GCPDiscovery) matches(instance gcpInstance) bool {
+	if instance.Status != "RUNNING" {
+		return false
+	}
+	for key, value := range d.Labels {
+		if instance.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
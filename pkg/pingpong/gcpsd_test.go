@@ -0,0 +1,66 @@
+package pingpong
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestGCPDiscovery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gcp" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/compute/v1/projects/shop/zones/europe-west1-b/instanceGroups/web/listInstances":
+			if r.Method != http.MethodPost {
+				http.Error(w, "method", http.StatusMethodNotAllowed)
+				return
+			}
+			w.Write([]byte(`{"items":[{"instance":"https://compute.googleapis.com/compute/v1/projects/shop/zones/europe-west1-b/instances/web-1"},
+				{"instance":"https://compute.googleapis.com/compute/v1/projects/shop/zones/europe-west1-b/instances/web-2"}]}`))
+		case "/compute/v1/projects/shop/zones/europe-west1-b/instances/web-1":
+			w.Write([]byte(`{"name":"web-1","status":"RUNNING","labels":{"env":"prod"},"networkInterfaces":[{"networkIP":"10.132.0.5"}]}`))
+		case "/compute/v1/projects/shop/zones/europe-west1-b/instances/web-2":
+			w.Write([]byte(`{"name":"web-2","status":"RUNNING","labels":{"env":"staging"},"networkInterfaces":[{"networkIP":"10.132.0.6"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	discovery := &GCPDiscovery{
+		Project:        "shop",
+		Zone:           "europe-west1-b",
+		InstanceGroups: []string{"web"},
+		Labels:         map[string]string{"env": "prod"},
+		Port:           8080,
+		TokenSource:    StaticGCPToken("gcp"),
+		Endpoint:       server.URL,
+	}
+	targets, err := discovery.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover returned error: %v", err)
+	}
+	if len(targets) != 1 || targets[0].URL != "http://10.132.0.5:8080/" || targets[0].Labels["instance"] != "web-1" {
+		t.Errorf("Unexpected targets: %+v", targets)
+	}
+}
+
+func TestGCPDiscovery_DefaultTokenConcurrent(t *testing.T) {
+	discovery := &GCPDiscovery{}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if discovery.token() == nil {
+				t.Error("Expected a default token source")
+			}
+		}()
+	}
+	wg.Wait()
+}
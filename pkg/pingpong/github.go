@@ -0,0 +1,129 @@
+package pingpong
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// GitHubStatusReporter watches the first Window of checks after startup and
+// reports the outcome as a GitHub commit status or deployment status, so CD
+// pipelines can gate promotion on real probe results. It reports pending on
+// the first result, failure as soon as the target goes down, and success once
+// Window has passed without the target going down. A report GitHub refuses
+// is retried on the next event, so the status does not stay stale.
+type GitHubStatusReporter struct {
+	Owner        string
+	Repo         string
+	Token        string
+	SHA          string        // Report a commit status on this commit
+	DeploymentID int64         // Report a deployment status instead of a commit status
+	Window       time.Duration // How long to watch after the first result (default 5m)
+	Context      string        // Commit status context (default "pingpong")
+	TargetURL    string        // Link shown next to the status, e.g. the /status endpoint
+	Targets      []string      // Only watch these targets (default all)
+	APIURL       string        // Defaults to https://api.github.com
+	Client       *http.Client
+
+	mu       sync.Mutex
+	start    time.Time
+	finished bool
+	unsent   *githubStatus // The last report, if it failed
+	reports  int           // Number of reports started, to tell whether a failed one is still the latest
+}
+
+// githubStatus is a status reported to GitHub
+type githubStatus struct {
+	state       string
+	description string
+}
+
+// Send updates the GitHub status based on the event
+func (g *GitHubStatusReporter) Send(ctx context.Context, event Event) error {
+	if len(g.Targets) > 0 && !slices.Contains(g.Targets, event.Target) {
+		return nil
+	}
+	window := g.Window
+	if window <= 0 {
+		window = 5 * time.Minute
+	}
+
+	g.mu.Lock()
+	var status *githubStatus
+	switch {
+	case g.finished:
+	case g.start.IsZero():
+		g.start = event.Time
+		status = &githubStatus{"pending", fmt.Sprintf("Checking %s for %s", event.Target, window)}
+	case event.Type == EventStateChange && event.State == StateDown:
+		g.finished = true
+		description := fmt.Sprintf("%s went down after %s", event.Target, event.Time.Sub(g.start).Round(time.Second))
+		if event.Result != nil && event.Result.Reason != "" {
+			description += fmt.Sprintf(" (%s)", event.Result.Reason)
+		}
+		status = &githubStatus{"failure", description}
+	case event.Time.Sub(g.start) >= window:
+		g.finished = true
+		status = &githubStatus{"success", fmt.Sprintf("%s healthy for %s", event.Target, window)}
+	}
+	// A new status replaces a failed report, otherwise retry that
+	if status == nil {
+		status = g.unsent
+	}
+	g.unsent = nil
+	if status == nil {
+		g.mu.Unlock()
+		return nil
+	}
+	g.reports++
+	report := g.reports
+	g.mu.Unlock()
+
+	err := g.report(ctx, status.state, status.description)
+	if err != nil {
+		g.mu.Lock()
+		if g.reports == report {
+			g.unsent = status
+		}
+		g.mu.Unlock()
+	}
+	return err
+}
+
+// report posts a commit status or deployment status
+func (g *GitHubStatusReporter) report(ctx context.Context, state, description string) error {
+	api := valueOr(g.APIURL, "https://api.github.com")
+	var url string
+	body := map[string]string{"description": description}
+	if g.DeploymentID != 0 {
+		if state == "pending" {
+			state = "in_progress"
+		}
+		url = fmt.Sprintf("%s/repos/%s/%s/deployments/%d/statuses", api, g.Owner, g.Repo, g.DeploymentID)
+		body["log_url"] = g.TargetURL
+	} else {
+		url = fmt.Sprintf("%s/repos/%s/%s/statuses/%s", api, g.Owner, g.Repo, g.SHA)
+		body["context"] = valueOr(g.Context, "pingpong")
+		body["target_url"] = g.TargetURL
+	}
+	body["state"] = state
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode GitHub status: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create GitHub status request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+g.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	return doRequest(g.Client, req)
+}
@@ -0,0 +1,85 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGitHubStatusReporter(t *testing.T) {
+	var paths, states []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		paths = append(paths, r.URL.Path)
+		states = append(states, body["state"])
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	events := []Event{
+		{Type: EventResult, Time: start},
+		{Type: EventResult, Time: start.Add(time.Minute)},
+		{Type: EventResult, Time: start.Add(2 * time.Minute)},
+		{Type: EventResult, Time: start.Add(3 * time.Minute)},
+	}
+
+	commit := &GitHubStatusReporter{Owner: "o", Repo: "r", SHA: "abc", Window: 2 * time.Minute, APIURL: server.URL}
+	for _, event := range events {
+		if err := commit.Send(context.Background(), event); err != nil {
+			t.Fatalf("Send returned error: %v", err)
+		}
+	}
+	if len(states) != 2 || states[0] != "pending" || states[1] != "success" || paths[0] != "/repos/o/r/statuses/abc" {
+		t.Errorf("Unexpected commit statuses %v at %v", states, paths)
+	}
+
+	paths, states = nil, nil
+	deployment := &GitHubStatusReporter{Owner: "o", Repo: "r", DeploymentID: 7, Window: 2 * time.Minute, APIURL: server.URL}
+	events[1] = Event{Type: EventStateChange, State: StateDown, Time: start.Add(time.Minute)}
+	for _, event := range events {
+		if err := deployment.Send(context.Background(), event); err != nil {
+			t.Fatalf("Send returned error: %v", err)
+		}
+	}
+	if len(states) != 2 || states[0] != "in_progress" || states[1] != "failure" || paths[0] != "/repos/o/r/deployments/7/statuses" {
+		t.Errorf("Unexpected deployment statuses %v at %v", states, paths)
+	}
+}
+
+func TestGitHubStatusReporter_TargetsAndRetry(t *testing.T) {
+	var states []string
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			fail = false
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		states = append(states, body["state"])
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	reporter := &GitHubStatusReporter{Owner: "o", Repo: "r", SHA: "abc", Window: 2 * time.Minute, Targets: []string{"api"}, APIURL: server.URL}
+	if err := reporter.Send(context.Background(), Event{Type: EventResult, Target: "other", Time: start}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if err := reporter.Send(context.Background(), Event{Type: EventResult, Target: "api", Time: start}); err == nil {
+		t.Fatal("Expected the refused report to return an error")
+	}
+	if err := reporter.Send(context.Background(), Event{Type: EventStateChange, State: StateDown, Target: "other", Time: start.Add(time.Minute)}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if err := reporter.Send(context.Background(), Event{Type: EventResult, Target: "api", Time: start.Add(time.Minute)}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if len(states) != 1 || states[0] != "pending" {
+		t.Errorf("Expected only the retried pending status of api, got %v", states)
+	}
+}
@@ -0,0 +1,112 @@
+package pingpong
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// harFile is the subset of the HAR 1.2 format needed to replay requests
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			ResourceType string `json:"_resourceType"` // Chrome extension
+			Request      struct {
+				Method  string `json:"method"`
+				URL     string `json:"url"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+				PostData *struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+			Response struct {
+				Status int `json:"status"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// harSkippedResources lists browser resource types that are not part of a
+// user flow and are left out when importing a HAR file
+var harSkippedResources = map[string]bool{
+	"image":      true,
+	"stylesheet": true,
+	"script":     true,
+	"font":       true,
+	"media":      true,
+	"manifest":   true,
+	"ping":       true,
+	"websocket":  true,
+}
+
+// harSkippedHeaders lists request headers that are managed by the HTTP
+// client and must not be replayed verbatim
+var harSkippedHeaders = map[string]bool{
+	"host":              true,
+	"content-length":    true,
+	"connection":        true,
+	"accept-encoding":   true,
+	"cookie":            true,
+	"transfer-encoding": true,
+}
+
+// LoadHAR reads a HAR file exported from browser devtools and converts its
+// requests into check steps. See ParseHAR.
+func LoadHAR(path string) ([]Step, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseHAR(f)
+}
+
+// ParseHAR converts the requests in a HAR document into check steps, each
+// expecting the status code that was recorded. Images, stylesheets, scripts
+// and other static resources are skipped, as are cookies, which are replayed
+// from the responses of earlier steps instead.
+func ParseHAR(r io.Reader) ([]Step, error) {
+	var har harFile
+	if err := json.NewDecoder(r).Decode(&har); err != nil {
+		return nil, fmt.Errorf("failed to parse HAR: %w", err)
+	}
+
+	var steps []Step
+	for _, entry := range har.Log.Entries {
+		if harSkippedResources[entry.ResourceType] {
+			continue
+		}
+
+		step := Step{
+			Method:       entry.Request.Method,
+			URL:          entry.Request.URL,
+			Headers:      make(map[string]string),
+			ExpectStatus: entry.Response.Status,
+		}
+		for _, header := range entry.Request.Headers {
+			name := strings.ToLower(header.Name)
+			if strings.HasPrefix(name, ":") || harSkippedHeaders[name] {
+				continue
+			}
+			step.Headers[header.Name] = header.Value
+		}
+		if entry.Request.PostData != nil {
+			step.Body = entry.Request.PostData.Text
+			if _, ok := step.Headers["Content-Type"]; !ok && entry.Request.PostData.MimeType != "" {
+				step.Headers["Content-Type"] = entry.Request.PostData.MimeType
+			}
+		}
+		steps = append(steps, step)
+	}
+
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("HAR contains no replayable requests")
+	}
+	return steps, nil
+}
@@ -0,0 +1,60 @@
+package pingpong
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseHAR_Replay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+				http.Error(w, "bad login", http.StatusBadRequest)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1"})
+			http.Redirect(w, r, "/account", http.StatusFound)
+		case "/account":
+			if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "s1" {
+				http.Error(w, "no session", http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte("welcome"))
+		}
+	}))
+	defer server.Close()
+
+	har := fmt.Sprintf(`{"log":{"entries":[
+		{"request":{"method":"POST","url":"%[1]s/login","headers":[{"name":":authority","value":"x"},{"name":"Cookie","value":"old=1"}],
+			"postData":{"mimeType":"application/json","text":"{\"user\":\"u\"}"}},"response":{"status":302}},
+		{"_resourceType":"image","request":{"method":"GET","url":"%[1]s/logo.png","headers":[]},"response":{"status":200}},
+		{"request":{"method":"GET","url":"%[1]s/account","headers":[{"name":"Accept","value":"text/html"}]},"response":{"status":200}}
+	]}}`, server.URL)
+
+	steps, err := ParseHAR(strings.NewReader(har))
+	if err != nil {
+		t.Fatalf("ParseHAR returned error: %v", err)
+	}
+	if len(steps) != 2 || steps[0].ExpectStatus != http.StatusFound || steps[0].Headers["Cookie"] != "" {
+		t.Fatalf("Unexpected steps: %+v", steps)
+	}
+
+	service := NewService(Config{Steps: steps, MaxRetries: 1, Logger: &TestLogger{}})
+	if service.targets[0].Name != server.URL+"/login" {
+		t.Errorf("Expected first step URL as target, got %q", service.targets[0].Name)
+	}
+	if result := service.pingServer(context.Background(), service.targets[0]); !result.Success {
+		t.Errorf("Expected recorded flow to succeed, got %s: %s", result.Reason, result.Error)
+	}
+
+	steps[1].ExpectBody = "goodbye"
+	result := pingConfig(Config{Steps: steps, MaxRetries: 1, Logger: &TestLogger{}})
+	if result.Success || result.Reason != FailureBodyMismatch || !strings.HasPrefix(result.Error, "step 2 (GET ") {
+		t.Errorf("Expected step 2 body mismatch, got %s: %s", result.Reason, result.Error)
+	}
+}
@@ -0,0 +1,69 @@
+package pingpong
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// HealthFunc is a health check provided by the embedding application, such
+// as a database ping or a queue depth check. It returns nil when healthy.
+// Checks run under Target.Timeout and should return once ctx is done; one
+// that does not is reported as timed out and left running in the background,
+// and no new call starts until it returns.
+type HealthFunc func(ctx context.Context) error
+
+// healthFuncTargets returns a target per Config.HealthFuncs, ordered by name
+func healthFuncTargets(funcs map[string]HealthFunc) []Target {
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	targets := make([]Target, 0, len(names))
+	for _, name := range names {
+		targets = append(targets, Target{Name: name, Health: funcs[name]})
+	}
+	return targets
+}
+
+// runHealthFunc performs a single attempt of a target checked by a
+// HealthFunc and records its outcome in result
+func (s *Service) runHealthFunc(ctx context.Context, t *target, result *Result) bool {
+	if !t.healthBusy.CompareAndSwap(false, true) {
+		result.Reason = FailureDeadline
+		result.Error = "previous check has not returned yet"
+		return false
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer t.healthBusy.Store(false)
+		done <- t.Health(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err() // Ignored ctx, so stop waiting for it
+	}
+	result.Latency = time.Since(start)
+	if err == nil {
+		if ctx.Err() != nil {
+			err = ctx.Err() // Reported success only after giving up
+		} else {
+			result.Success = true
+			return true
+		}
+	}
+	result.Reason = FailureHealthFunc
+	if errors.Is(err, context.DeadlineExceeded) {
+		result.Reason = FailureDeadline
+	}
+	result.Error = err.Error()
+	return false
+}
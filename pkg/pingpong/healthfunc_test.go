@@ -0,0 +1,128 @@
+package pingpong
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestService_HealthFuncs(t *testing.T) {
+	var depth atomic.Int64
+	service := NewService(Config{
+		HealthFuncs: map[string]HealthFunc{
+			"queue": func(ctx context.Context) error {
+				if d := depth.Load(); d > 100 {
+					return fmt.Errorf("queue depth %d", d)
+				}
+				return nil
+			},
+			"db": func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		},
+		Timeout:             10 * time.Millisecond,
+		MaxRetries:          1,
+		MaxConsecutiveFails: 10,
+		Logger:              &TestLogger{},
+	})
+	if len(service.targets) != 2 || service.targets[0].Name != "db" || service.targets[1].Name != "queue" {
+		t.Fatalf("Expected only the db and queue targets, got %d targets", len(service.targets))
+	}
+	db, queue := service.targets[0], service.targets[1]
+
+	result := service.pingServer(context.Background(), db)
+	if result.Success || result.Reason != FailureDeadline {
+		t.Errorf("Expected a hanging health function to time out, got %+v", result)
+	}
+	service.recordResult(db, result)
+
+	result = service.pingServer(context.Background(), queue)
+	if !result.Success {
+		t.Fatalf("Expected the queue to be healthy, got %+v", result)
+	}
+	service.recordResult(queue, result)
+	depth.Store(500)
+	result = service.pingServer(context.Background(), queue)
+	if result.Success || result.Reason != FailureHealthFunc {
+		t.Errorf("Expected a health_func failure, got %+v", result)
+	}
+	service.recordResult(queue, result)
+	if healthy, _ := service.targetHealth(queue); healthy {
+		t.Error("Expected the queue to be unhealthy as soon as it is down, despite its recent success")
+	}
+
+	// Health functions feed the state machine and /health like any target
+	status := service.Status()
+	if status.Targets[0].State != StateDown || status.Targets[1].State != StateDown {
+		t.Errorf("Expected both health functions to be down, got %+v", status.Targets)
+	}
+	if healthy, reason := service.health(); healthy || !strings.Contains(reason, "db") {
+		t.Errorf("Expected /health to report db, got %v %q", healthy, reason)
+	}
+	if effective := service.EffectiveConfig().Targets; !effective[0].HealthFunc {
+		t.Error("Expected the effective config to flag health functions")
+	}
+
+	if _, err := Check(context.Background(), Target{Name: "ok", Health: func(context.Context) error { return nil }}); err != nil {
+		t.Errorf("Expected a one-shot check of a health function to succeed, got %v", err)
+	}
+
+	// A health function ignoring its context cannot block the ping routine
+	release := make(chan struct{})
+	defer close(release)
+	stuck := Target{Name: "stuck", Timeout: 10 * time.Millisecond, Health: func(context.Context) error {
+		<-release
+		return nil
+	}}
+	start := time.Now()
+	if result, err := Check(context.Background(), stuck); err == nil || result.Reason != FailureDeadline || time.Since(start) > time.Second {
+		t.Errorf("Expected a stuck health function to time out, got %+v after %s", result, time.Since(start))
+	}
+}
+
+func TestService_HealthFuncs_StuckAndNeverParked(t *testing.T) {
+	var calls atomic.Int64
+	release := make(chan struct{})
+	service := NewService(Config{
+		HealthFuncs: map[string]HealthFunc{
+			"stuck": func(context.Context) error {
+				calls.Add(1)
+				<-release
+				return errors.New("down")
+			},
+		},
+		Timeout:    10 * time.Millisecond,
+		MaxRetries: 1,
+		Logger:     &TestLogger{},
+	})
+	stuck := service.targets[0]
+
+	// No new call starts while the previous one ignores its context
+	for i := 0; i < 3; i++ {
+		if result := service.pingServer(context.Background(), stuck); result.Success || result.Reason != FailureDeadline {
+			t.Errorf("Expected check %d to time out, got %+v", i, result)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected a single call while the health function is stuck, got %d", n)
+	}
+	close(release)
+	for start := time.Now(); stuck.healthBusy.Load() && time.Since(start) < time.Second; {
+		time.Sleep(time.Millisecond)
+	}
+	service.pingServer(context.Background(), stuck)
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Expected a new call once the stuck one returned, got %d calls", n)
+	}
+
+	// With the default MaxConsecutiveFails of 0, an HTTP target would be
+	// parked on its first failure; a health function keeps being checked
+	if !service.recordResult(stuck, Result{Reason: FailureHealthFunc, Time: time.Now()}) {
+		t.Error("Expected a failing health function to keep being checked")
+	}
+}
@@ -0,0 +1,65 @@
+package pingpong
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// HistorySink appends the result of every check to a file, one JSON object
+// per line, so it can later be replayed with Simulate
+type HistorySink struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// Send appends result events to the history file
+func (h *HistorySink) Send(ctx context.Context, event Event) error {
+	if event.Type != EventResult || event.Result == nil {
+		return nil
+	}
+	line, err := json.Marshal(event.Result)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f, err := os.OpenFile(h.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadHistory reads the results written by a HistorySink
+func LoadHistory(path string) ([]Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var history []Result
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var result Result
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		history = append(history, result)
+	}
+	return history, scanner.Err()
+}
@@ -0,0 +1,246 @@
+package pingpong
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// KubernetesOperation selects what a KubernetesAction does
+type KubernetesOperation string
+
+const (
+	// KubernetesRestartDeployment triggers a rolling restart of a Deployment like `kubectl rollout restart`
+	KubernetesRestartDeployment KubernetesOperation = "restart_deployment"
+	// KubernetesDeletePod deletes a Pod, or one Pod matching a label selector, so its controller recreates it
+	KubernetesDeletePod KubernetesOperation = "delete_pod"
+	// KubernetesCordonNode marks a Node unschedulable like `kubectl cordon`
+	KubernetesCordonNode KubernetesOperation = "cordon_node"
+)
+
+// serviceAccountDir holds the credentials Kubernetes mounts into pods
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// KubernetesAction heals a target through the Kubernetes API, turning
+// pingpong into a lightweight self-healing operator. Inside a cluster it
+// authenticates with the pod's service account, so its permissions are
+// whatever RBAC grants that account.
+type KubernetesAction struct {
+	Operation     KubernetesOperation // Defaults to restart_deployment
+	APIServer     string              // Kubernetes API server URL (defaults to the in-cluster API server)
+	Token         string              // Bearer token (defaults to the service account token)
+	Namespace     string              // Defaults to the service account namespace, then "default"
+	Deployment    string              // Deployment to restart
+	Pod           string              // Pod to delete
+	LabelSelector string              // Deletes one pod matching the selector instead of Pod, preferring one that is not ready, then the oldest
+	Node          string              // Node to cordon
+	Client        *http.Client        // Defaults to a client trusting the service account CA
+}
+
+// Run performs the operation
+func (a *KubernetesAction) Run(ctx context.Context, event Event) error {
+	namespace := url.PathEscape(a.namespace())
+	switch valueOr(string(a.Operation), string(KubernetesRestartDeployment)) {
+	case string(KubernetesRestartDeployment):
+		if a.Deployment == "" {
+			return fmt.Errorf("no deployment configured")
+		}
+		patch := map[string]any{
+			"spec": map[string]any{
+				"template": map[string]any{
+					"metadata": map[string]any{
+						"annotations": map[string]string{
+							"kubectl.kubernetes.io/restartedAt": time.Now().UTC().Format(time.RFC3339),
+						},
+					},
+				},
+			},
+		}
+		path := fmt.Sprintf("/apis/apps/v1/namespaces/%s/deployments/%s", namespace, url.PathEscape(a.Deployment))
+		return a.call(ctx, http.MethodPatch, path, patch)
+	case string(KubernetesDeletePod):
+		pod := a.Pod
+		switch {
+		case a.LabelSelector != "":
+			var err error
+			if pod, err = a.selectPod(ctx, namespace); err != nil {
+				return err
+			}
+		case pod == "":
+			return fmt.Errorf("no pod or label selector configured")
+		}
+		return a.call(ctx, http.MethodDelete, fmt.Sprintf("/api/v1/namespaces/%s/pods/%s", namespace, url.PathEscape(pod)), nil)
+	case string(KubernetesCordonNode):
+		if a.Node == "" {
+			return fmt.Errorf("no node configured")
+		}
+		patch := map[string]any{"spec": map[string]any{"unschedulable": true}}
+		return a.call(ctx, http.MethodPatch, "/api/v1/nodes/"+url.PathEscape(a.Node), patch)
+	default:
+		return fmt.Errorf("unknown Kubernetes operation %q", a.Operation)
+	}
+}
+
+// kubernetesPod is the part of a Pod that selectPod looks at
+type kubernetesPod struct {
+	Metadata struct {
+		Name              string     `json:"name"`
+		CreationTimestamp time.Time  `json:"creationTimestamp"`
+		DeletionTimestamp *time.Time `json:"deletionTimestamp"`
+	} `json:"metadata"`
+	Status struct {
+		Conditions []struct {
+			Type   string `json:"type"`
+			Status string `json:"status"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+// ready reports whether the pod has the Ready condition
+func (p kubernetesPod) ready() bool {
+	for _, condition := range p.Status.Conditions {
+		if condition.Type == "Ready" {
+			return condition.Status == "True"
+		}
+	}
+	return false
+}
+
+// selectPod returns the name of the pod matching LabelSelector to delete: one
+// that is not ready if any, otherwise the oldest. Deleting a single pod per
+// run keeps the remaining replicas serving; pods already being deleted are
+// skipped.
+func (a *KubernetesAction) selectPod(ctx context.Context, namespace string) (string, error) {
+	server, err := a.apiServer()
+	if err != nil {
+		return "", err
+	}
+	client, err := a.client()
+	if err != nil {
+		return "", err
+	}
+	var headers map[string]string
+	if token := a.token(); token != "" {
+		headers = map[string]string{"Authorization": "Bearer " + token}
+	}
+	var list struct {
+		Items []kubernetesPod `json:"items"`
+	}
+	path := fmt.Sprintf("/api/v1/namespaces/%s/pods?labelSelector=%s", namespace, url.QueryEscape(a.LabelSelector))
+	if err := fetchJSON(ctx, client, server+path, headers, &list); err != nil {
+		return "", err
+	}
+
+	var selected *kubernetesPod
+	for i := range list.Items {
+		pod := &list.Items[i]
+		if pod.Metadata.DeletionTimestamp != nil {
+			continue
+		}
+		if selected == nil ||
+			(!pod.ready() && selected.ready()) ||
+			(pod.ready() == selected.ready() && pod.Metadata.CreationTimestamp.Before(selected.Metadata.CreationTimestamp)) {
+			selected = pod
+		}
+	}
+	if selected == nil {
+		return "", fmt.Errorf("no pod matches %s", a.LabelSelector)
+	}
+	return selected.Metadata.Name, nil
+}
+
+// namespace returns the configured namespace, falling back to the service
+// account namespace
+func (a *KubernetesAction) namespace() string {
+	if a.Namespace != "" {
+		return a.Namespace
+	}
+	if data, err := os.ReadFile(filepath.Join(serviceAccountDir, "namespace")); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	return "default"
+}
+
+// apiServer returns the configured API server, falling back to the
+// in-cluster address
+func (a *KubernetesAction) apiServer() (string, error) {
+	if a.APIServer != "" {
+		return strings.TrimSuffix(a.APIServer, "/"), nil
+	}
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" {
+		return "", fmt.Errorf("no API server configured and not running in a cluster")
+	}
+	return "https://" + net.JoinHostPort(host, valueOr(port, "443")), nil
+}
+
+// token returns the configured token, falling back to the service account
+// token. The token file is read on every call because bound tokens rotate.
+func (a *KubernetesAction) token() string {
+	if a.Token != "" {
+		return a.Token
+	}
+	data, _ := os.ReadFile(filepath.Join(serviceAccountDir, "token"))
+	return strings.TrimSpace(string(data))
+}
+
+// client returns the configured client, falling back to one trusting the
+// service account CA
+func (a *KubernetesAction) client() (*http.Client, error) {
+	if a.Client != nil {
+		return a.Client, nil
+	}
+	ca, err := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return http.DefaultClient, nil
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("invalid service account CA certificate")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	return &http.Client{Transport: transport}, nil
+}
+
+// call sends a request to the Kubernetes API, sending body as a strategic
+// merge patch
+func (a *KubernetesAction) call(ctx context.Context, method, path string, body any) error {
+	server, err := a.apiServer()
+	if err != nil {
+		return err
+	}
+	client, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	var encoded []byte
+	if body != nil {
+		if encoded, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, server+path, bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/strategic-merge-patch+json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if token := a.token(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return doRequest(client, req)
+}
@@ -0,0 +1,136 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// kubernetesRequest is a request received by the fake API server
+type kubernetesRequest struct {
+	method, uri, contentType, auth string
+	body                           map[string]any
+}
+
+func TestKubernetesAction_Operations(t *testing.T) {
+	var got kubernetesRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = kubernetesRequest{method: r.Method, uri: r.URL.RequestURI(), contentType: r.Header.Get("Content-Type"), auth: r.Header.Get("Authorization")}
+		json.NewDecoder(r.Body).Decode(&got.body)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		action KubernetesAction
+		method string
+		uri    string
+	}{
+		{"restart deployment", KubernetesAction{Deployment: "api", Namespace: "shop"}, http.MethodPatch, "/apis/apps/v1/namespaces/shop/deployments/api"},
+		{"delete pod", KubernetesAction{Operation: KubernetesDeletePod, Pod: "api-7d9f", Namespace: "shop"}, http.MethodDelete, "/api/v1/namespaces/shop/pods/api-7d9f"},
+		{"cordon node", KubernetesAction{Operation: KubernetesCordonNode, Node: "node-1"}, http.MethodPatch, "/api/v1/nodes/node-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.action.APIServer = server.URL
+			tt.action.Token = "t"
+			if err := tt.action.Run(context.Background(), Event{}); err != nil {
+				t.Fatalf("Run returned error: %v", err)
+			}
+			if got.method != tt.method || got.uri != tt.uri || got.auth != "Bearer t" {
+				t.Errorf("Unexpected request %s %s (%s)", got.method, got.uri, got.auth)
+			}
+			if tt.method == http.MethodPatch && got.contentType != "application/strategic-merge-patch+json" {
+				t.Errorf("Expected strategic merge patch, got %q", got.contentType)
+			}
+		})
+	}
+
+	if spec := got.body["spec"].(map[string]any); spec["unschedulable"] != true {
+		t.Errorf("Expected cordon patch, got %+v", got.body)
+	}
+	if err := (&KubernetesAction{Operation: KubernetesDeletePod, APIServer: server.URL}).Run(context.Background(), Event{}); err == nil {
+		t.Error("Expected an error without a pod or selector")
+	}
+}
+
+func TestKubernetesAction_DeletePodBySelector(t *testing.T) {
+	pods := `{"items": [
+		{"metadata": {"name": "api-old", "creationTimestamp": "2024-01-01T00:00:00Z"}, "status": {"conditions": [{"type": "Ready", "status": "True"}]}},
+		{"metadata": {"name": "api-stuck", "creationTimestamp": "2024-03-01T00:00:00Z"}, "status": {"conditions": [{"type": "Ready", "status": "False"}]}},
+		{"metadata": {"name": "api-new", "creationTimestamp": "2024-02-01T00:00:00Z"}, "status": {"conditions": [{"type": "Ready", "status": "True"}]}},
+		{"metadata": {"name": "api-gone", "creationTimestamp": "2023-01-01T00:00:00Z", "deletionTimestamp": "2024-04-01T00:00:00Z"}}
+	]}`
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.RequestURI() == "/api/v1/namespaces/shop/pods?labelSelector=app%3Dapi":
+			w.Write([]byte(pods))
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	action := KubernetesAction{Operation: KubernetesDeletePod, LabelSelector: "app=api", Namespace: "shop", APIServer: server.URL}
+	if err := action.Run(context.Background(), Event{}); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != "/api/v1/namespaces/shop/pods/api-stuck" {
+		t.Errorf("Expected only the pod that is not ready to be deleted, got %v", deleted)
+	}
+
+	// Without a pod that is not ready, the oldest goes
+	pods = strings.Replace(pods, `"status": "False"`, `"status": "True"`, 1)
+	deleted = nil
+	if err := action.Run(context.Background(), Event{}); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != "/api/v1/namespaces/shop/pods/api-old" {
+		t.Errorf("Expected only the oldest pod to be deleted, got %v", deleted)
+	}
+
+	pods = `{"items": []}`
+	if err := action.Run(context.Background(), Event{}); err == nil {
+		t.Error("Expected an error when no pod matches")
+	}
+}
+
+func TestKubernetesAction_InCluster(t *testing.T) {
+	var auth string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if r.URL.Path != "/apis/apps/v1/namespaces/payments/deployments/api" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	os.WriteFile(filepath.Join(dir, "ca.crt"), ca, 0o600)
+	os.WriteFile(filepath.Join(dir, "token"), []byte("sa-token\n"), 0o600)
+	os.WriteFile(filepath.Join(dir, "namespace"), []byte("payments"), 0o600)
+	defer func(previous string) { serviceAccountDir = previous }(serviceAccountDir)
+	serviceAccountDir = dir
+
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	t.Setenv("KUBERNETES_SERVICE_HOST", host)
+	t.Setenv("KUBERNETES_SERVICE_PORT", port)
+
+	if err := (&KubernetesAction{Deployment: "api"}).Run(context.Background(), Event{}); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if auth != "Bearer sa-token" {
+		t.Errorf("Expected service account token, got %q", auth)
+	}
+}
@@ -0,0 +1,117 @@
+package pingpong
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Suggestion holds thresholds derived from observing a target in learning
+// mode, together with the measurements they are based on
+type Suggestion struct {
+	Target           string        `json:"target"`
+	Samples          int           `json:"samples"`
+	Failures         int           `json:"failures"`
+	LongestOutage    int           `json:"longest_outage"` // Longest run of consecutive failed checks
+	P50              time.Duration `json:"p50_ns"`
+	P95              time.Duration `json:"p95_ns"`
+	P99              time.Duration `json:"p99_ns"`
+	Max              time.Duration `json:"max_ns"`
+	PingInterval     time.Duration `json:"ping_interval_ns"`
+	Timeout          time.Duration `json:"timeout_ns"`
+	LatencySLA       time.Duration `json:"latency_sla_ns"`
+	FailureThreshold int           `json:"failure_threshold"`
+}
+
+// Learn checks a target every interval until ctx is done and suggests
+// thresholds based on the measured behavior: a timeout well above the
+// slowest responses, a latency SLA above the 95th percentile and a failure
+// threshold that rides out the blips seen while learning
+func Learn(ctx context.Context, target Target, interval time.Duration) (Suggestion, error) {
+	service := NewService(Config{
+		Targets:    []Target{target},
+		MaxRetries: 1,
+		Timeout:    30 * time.Second,
+		Logger:     quietLogger{},
+	})
+	t := service.targets[0]
+	suggestion := Suggestion{Target: t.Name}
+
+	var latencies []time.Duration
+	streak := 0
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for ctx.Err() == nil {
+		result := service.pingServer(ctx, t)
+		if ctx.Err() != nil {
+			// The check was cut short by the end of the learning period
+			break
+		}
+		suggestion.Samples++
+		if result.Success {
+			latencies = append(latencies, result.Latency)
+			streak = 0
+		} else {
+			suggestion.Failures++
+			streak++
+			suggestion.LongestOutage = max(suggestion.LongestOutage, streak)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		}
+	}
+
+	if len(latencies) == 0 {
+		return suggestion, errors.New("no successful checks to learn from")
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	suggestion.P50 = percentile(latencies, 50)
+	suggestion.P95 = percentile(latencies, 95)
+	suggestion.P99 = percentile(latencies, 99)
+	suggestion.Max = latencies[len(latencies)-1]
+
+	suggestion.Timeout = roundUp(max(3*suggestion.P99, suggestion.Max+suggestion.Max/2, time.Second))
+	suggestion.LatencySLA = roundUp(suggestion.P95 + suggestion.P95/2)
+	suggestion.PingInterval = min(roundUp(max(10*suggestion.P99, 5*time.Second)), time.Minute)
+	suggestion.FailureThreshold = suggestion.LongestOutage + 1
+	return suggestion, nil
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+// roundUp rounds d up to two significant digits of milliseconds or seconds
+func roundUp(d time.Duration) time.Duration {
+	unit := time.Millisecond
+	switch {
+	case d >= 10*time.Second:
+		unit = time.Second
+	case d >= time.Second:
+		unit = 100 * time.Millisecond
+	case d >= 100*time.Millisecond:
+		unit = 10 * time.Millisecond
+	}
+	return (d + unit - 1) / unit * unit
+}
+
+// Env returns the suggestion as environment variables ready to paste into
+// a .env file
+func (s Suggestion) Env() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Learned from %d checks of %s (%d failed, longest outage %d checks)\n", s.Samples, s.Target, s.Failures, s.LongestOutage)
+	fmt.Fprintf(&b, "# Latency p50 %s, p95 %s, p99 %s, max %s; suggested latency SLA %s\n", s.P50, s.P95, s.P99, s.Max, s.LatencySLA)
+	fmt.Fprintf(&b, "SERVER_URL=%s\n", s.Target)
+	fmt.Fprintf(&b, "PING_INTERVAL=%s\n", s.PingInterval)
+	fmt.Fprintf(&b, "TIMEOUT=%s\n", s.Timeout)
+	fmt.Fprintf(&b, "FAILURE_THRESHOLD=%d\n", s.FailureThreshold)
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package pingpong
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLearn(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail two checks in a row once to simulate a blip
+		if n := atomic.AddInt32(&requests, 1); n == 3 || n == 4 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	suggestion, err := Learn(ctx, Target{URL: server.URL}, 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Learn returned error: %v", err)
+	}
+	if suggestion.Samples < 8 || suggestion.Failures != 2 || suggestion.LongestOutage != 2 {
+		t.Errorf("Unexpected observations: %+v", suggestion)
+	}
+	if suggestion.FailureThreshold != 3 || suggestion.Timeout != time.Second || suggestion.PingInterval != 5*time.Second {
+		t.Errorf("Unexpected thresholds: %+v", suggestion)
+	}
+	if env := suggestion.Env(); !strings.Contains(env, "FAILURE_THRESHOLD=3\n") || !strings.Contains(env, "TIMEOUT=1s\n") {
+		t.Errorf("Unexpected snippet:\n%s", env)
+	}
+}
+
+func TestRoundUp(t *testing.T) {
+	tests := map[time.Duration]time.Duration{
+		1234 * time.Microsecond:  2 * time.Millisecond,
+		123 * time.Millisecond:   130 * time.Millisecond,
+		1234 * time.Millisecond:  1300 * time.Millisecond,
+		12345 * time.Millisecond: 13 * time.Second,
+	}
+	for in, want := range tests {
+		if got := roundUp(in); got != want {
+			t.Errorf("roundUp(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package pingpong
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrLimitExceeded is wrapped by errors returned when a request would exceed
+// a cap such as Config.MaxTargets
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// roomForTargets returns how many more targets fit under MaxTargets, or -1
+// when there is no cap. The caller must hold s.mu.
+func (s *Service) roomForTargets() int {
+	if s.config.MaxTargets <= 0 {
+		return -1
+	}
+	return max(s.config.MaxTargets-len(s.targets), 0)
+}
+
+// trimHistory drops the oldest response samples of a target beyond
+// MaxHistory. The caller must hold t.mu.
+func (s *Service) trimHistory(t *target) {
+	excess := len(t.statusSamples) - s.config.MaxHistory
+	if s.config.MaxHistory <= 0 || excess <= 0 {
+		return
+	}
+	t.statusSamples = slices.Delete(t.statusSamples, 0, excess)
+	if !t.historyCapped {
+		t.historyCapped = true
+		s.logger.Warn("Response history of %s reached MaxHistory of %d, dropping the oldest samples", t.Name, s.config.MaxHistory)
+	}
+}
+
+// renderUsageMetrics renders the usage of capped resources and their caps
+func (s *Service) renderUsageMetrics(b *strings.Builder, targets []*target) {
+	history := 0
+	for _, t := range targets {
+		t.mu.Lock()
+		history += len(t.statusSamples)
+		t.mu.Unlock()
+	}
+	usage := []struct {
+		resource string
+		used     int
+		limit    int
+	}{
+		{"targets", len(targets), s.config.MaxTargets},
+		{"history_samples", history, s.config.MaxHistory * len(targets)},
+		{"queued_events", len(s.events), cap(s.events)},
+	}
+
+	b.WriteString("# HELP pingpong_resource_usage Current usage of resources bounded by the Max* settings.\n")
+	b.WriteString("# TYPE pingpong_resource_usage gauge\n")
+	for _, u := range usage {
+		fmt.Fprintf(b, "pingpong_resource_usage%s %d\n",
+			formatLabels([]string{"instance_id", s.config.InstanceID, "resource", u.resource}), u.used)
+	}
+	b.WriteString("# HELP pingpong_resource_limit Cap on resources set through the Max* settings.\n")
+	b.WriteString("# TYPE pingpong_resource_limit gauge\n")
+	for _, u := range usage {
+		if u.limit > 0 {
+			fmt.Fprintf(b, "pingpong_resource_limit%s %d\n",
+				formatLabels([]string{"instance_id", s.config.InstanceID, "resource", u.resource}), u.limit)
+		}
+	}
+	b.WriteString("# HELP pingpong_events_dropped_total Events dropped because the event queue was full.\n")
+	b.WriteString("# TYPE pingpong_events_dropped_total counter\n")
+	fmt.Fprintf(b, "pingpong_events_dropped_total%s %d\n",
+		formatLabels([]string{"instance_id", s.config.InstanceID}), s.droppedEvents.Load())
+}
@@ -0,0 +1,69 @@
+package pingpong
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestService_MaxTargets(t *testing.T) {
+	service := NewService(Config{ServerURL: "http://example.com", MaxTargets: 3, PingInterval: time.Hour, Logger: &TestLogger{}})
+
+	_, err := service.AddTargets([]Target{{URL: "http://a"}, {URL: "http://b"}, {URL: "http://c"}})
+	if !errors.Is(err, ErrLimitExceeded) || !strings.HasSuffix(err.Error(), "exceed MaxTargets of 3 by 1") {
+		t.Fatalf("Expected ErrLimitExceeded by one target, got %v", err)
+	}
+	if n := len(service.targetList()); n != 1 {
+		t.Fatalf("Expected a rejected batch to add nothing, got %d targets", n)
+	}
+	if added, err := service.AddTargets([]Target{{URL: "http://a"}, {URL: "http://example.com"}}); err != nil || len(added) != 1 {
+		t.Fatalf("Expected one target to be added, got %v (%v)", added, err)
+	}
+
+	// Discovery fills the remaining room and ignores the rest
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	service.syncTargets(ctx, "discovery", []Target{{URL: "http://d"}, {URL: "http://e"}})
+	targets := service.targetList()
+	if len(targets) != 3 || targets[2].Name != "http://d" {
+		t.Errorf("Expected only the first discovered target, got %d targets", len(targets))
+	}
+}
+
+func TestService_MaxHistory(t *testing.T) {
+	service := NewService(Config{ServerURL: "http://example.com", ErrorRatio: &ErrorRatioAlert{}, MaxHistory: 5, Logger: &TestLogger{}})
+	target := service.targets[0]
+
+	start := time.Now()
+	for i := 0; i < 8; i++ {
+		service.recordStatusCode(target, start.Add(time.Duration(i)*time.Second), 200+i)
+	}
+	if n := len(target.statusSamples); n != 5 {
+		t.Fatalf("Expected 5 samples, got %d", n)
+	}
+	if code := target.statusSamples[0].code; code != 203 {
+		t.Errorf("Expected the oldest samples to be dropped, first is %d", code)
+	}
+
+	metrics := service.renderMetrics()
+	for _, want := range []string{
+		`pingpong_resource_usage{instance_id="` + service.config.InstanceID + `",resource="history_samples"} 5`,
+		`pingpong_resource_limit{instance_id="` + service.config.InstanceID + `",resource="queued_events"} 100`,
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("Expected %q in metrics:\n%s", want, metrics)
+		}
+	}
+}
+
+func TestService_MaxQueuedEvents(t *testing.T) {
+	service := NewService(Config{ServerURL: "http://example.com", MaxQueuedEvents: 2, Sinks: []Sink{&recordingSink{}}, Logger: &TestLogger{}})
+	for i := 0; i < 3; i++ {
+		service.emit(Event{Type: EventResult})
+	}
+	if dropped := service.droppedEvents.Load(); dropped != 1 {
+		t.Errorf("Expected 1 dropped event, got %d", dropped)
+	}
+}
@@ -0,0 +1,117 @@
+package pingpong
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ErrInstanceLocked is returned by AcquireLock when the lock file is held by
+// another running process
+var ErrInstanceLocked = errors.New("another pingpong instance is already running")
+
+// InstanceLock is a PID lock file that prevents two instances with the same
+// identity from running on one host
+type InstanceLock struct {
+	path string
+}
+
+// LockPath returns the default lock file path for an instance identity, such
+// as the URL being pinged. Instances with the same identity share a lock.
+func LockPath(identity string) string {
+	sum := sha256.Sum256([]byte(identity))
+	return filepath.Join(os.TempDir(), "pingpong-"+hex.EncodeToString(sum[:6])+".lock")
+}
+
+// AcquireLock creates the lock file at path and writes the current PID to it.
+// If the file already belongs to a live process, ErrInstanceLocked is returned
+// unless force is set. Lock files left behind by dead processes are reclaimed;
+// a lock file without a PID, such as one another process has just created
+// and not written yet, is only taken over with force.
+func AcquireLock(path string, force bool) (*InstanceLock, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_, err = fmt.Fprintf(f, "%d\n", os.Getpid())
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("failed to write lock file: %w", err)
+			}
+			return &InstanceLock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		if !force {
+			pid, ok := readLockPID(path)
+			if !ok {
+				return nil, fmt.Errorf("%w (lock file %s without a PID)", ErrInstanceLocked, path)
+			}
+			if processAlive(pid) {
+				return nil, fmt.Errorf("%w (pid %d, lock file %s)", ErrInstanceLocked, pid, path)
+			}
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove stale lock file: %w", err)
+		}
+	}
+	return nil, fmt.Errorf("%w (lock file %s)", ErrInstanceLocked, path)
+}
+
+// Path returns the location of the lock file
+func (l *InstanceLock) Path() string {
+	return l.path
+}
+
+// Release removes the lock file if it still belongs to this process
+func (l *InstanceLock) Release() error {
+	if pid, ok := readLockPID(l.path); ok && pid != os.Getpid() {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// readLockPID reads the PID stored in a lock file
+func readLockPID(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	return pid, true
+}
+
+// processAlive reports whether a process with the given PID exists. Errors
+// other than "no such process" are treated as alive so that a lock is never
+// stolen from a process we merely lack permission to signal.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// FindProcess opens the process there, which fails once it is gone,
+		// while signal 0 is not supported
+		p.Release()
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || !(errors.Is(err, os.ErrProcessDone) || errors.Is(err, syscall.ESRCH))
+}
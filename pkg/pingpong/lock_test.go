@@ -0,0 +1,72 @@
+package pingpong
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestAcquireLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pingpong.lock")
+
+	lock, err := AcquireLock(path, false)
+	if err != nil {
+		t.Fatalf("AcquireLock returned error: %v", err)
+	}
+
+	if _, err := AcquireLock(path, false); !errors.Is(err, ErrInstanceLocked) {
+		t.Errorf("Expected ErrInstanceLocked for second lock, got %v", err)
+	}
+
+	forced, err := AcquireLock(path, true)
+	if err != nil {
+		t.Fatalf("AcquireLock with force returned error: %v", err)
+	}
+
+	if err := forced.Release(); err != nil {
+		t.Errorf("Release returned error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected lock file to be removed, stat returned %v", err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Errorf("Release of already removed lock returned error: %v", err)
+	}
+}
+
+func TestAcquireLock_Stale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pingpong.lock")
+	exited := exec.Command(os.Args[0], "-test.run=^$")
+	if err := exited.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(exited.Process.Pid)+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := AcquireLock(path, false)
+	if err != nil {
+		t.Fatalf("Expected stale lock to be reclaimed, got %v", err)
+	}
+	defer lock.Release()
+}
+
+func TestAcquireLock_WithoutPID(t *testing.T) {
+	// Another process may have created the lock file without writing its PID yet
+	path := filepath.Join(t.TempDir(), "pingpong.lock")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AcquireLock(path, false); !errors.Is(err, ErrInstanceLocked) {
+		t.Errorf("Expected a lock file without a PID to be left alone, got %v", err)
+	}
+
+	lock, err := AcquireLock(path, true)
+	if err != nil {
+		t.Fatalf("Expected force to take over the lock file, got %v", err)
+	}
+	defer lock.Release()
+}
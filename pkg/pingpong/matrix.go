@@ -0,0 +1,55 @@
+package pingpong
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MatrixNotifier posts formatted messages to a Matrix room
+type MatrixNotifier struct {
+	HomeserverURL string // e.g. https://matrix.org
+	AccessToken   string
+	RoomID        string // e.g. !abc123:matrix.org
+	Degraded      bool   // Also post changes between up and degraded
+	Client        *http.Client
+}
+
+// Send posts state change and acknowledgement notifications to the room
+func (m *MatrixNotifier) Send(ctx context.Context, event Event) error {
+	if !notifiable(event, m.Degraded) {
+		return nil
+	}
+
+	title := notificationTitle(event)
+	formatted := fmt.Sprintf("<strong>%s</strong>: %s", html.EscapeString(title), html.EscapeString(event.Summary()))
+	if event.Severity != "" && isOutage(event) {
+		formatted += fmt.Sprintf(" <em>[%s]</em>", html.EscapeString(string(event.Severity)))
+	}
+	body, err := json.Marshal(map[string]string{
+		"msgtype":        "m.text",
+		"body":           title + ": " + event.Summary(),
+		"format":         "org.matrix.custom.html",
+		"formatted_body": formatted,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode Matrix message: %w", err)
+	}
+
+	// The event ID doubles as transaction ID so retried sends are not duplicated
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimSuffix(m.HomeserverURL, "/"), url.PathEscape(m.RoomID), url.PathEscape(event.ID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Matrix request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.AccessToken)
+
+	return doRequest(m.Client, req)
+}
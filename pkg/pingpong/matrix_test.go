@@ -0,0 +1,38 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMatrixNotifier(t *testing.T) {
+	var path string
+	var message map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		if r.Method != http.MethodPut || r.Header.Get("Authorization") != "Bearer tok" {
+			t.Errorf("Unexpected %s request with auth %q", r.Method, r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&message)
+		w.Write([]byte(`{"event_id":"$1"}`))
+	}))
+	defer server.Close()
+
+	notifier := &MatrixNotifier{HomeserverURL: server.URL, AccessToken: "tok", RoomID: "!room:example.org"}
+	event := Event{ID: "evt1", Type: EventStateChange, Target: "http://api?a=<b>", State: StateDown, PreviousState: StateUp, Severity: SeverityCritical}
+	if err := notifier.Send(context.Background(), event); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	if path != "/_matrix/client/v3/rooms/%21room:example.org/send/m.room.message/evt1" {
+		t.Errorf("Unexpected path %s", path)
+	}
+	if message["msgtype"] != "m.text" || !strings.Contains(message["formatted_body"], "&lt;b&gt;") ||
+		!strings.Contains(message["formatted_body"], "[critical]") {
+		t.Errorf("Unexpected message: %v", message)
+	}
+}
@@ -0,0 +1,333 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mdnsAddress is the IPv4 mDNS multicast group
+const mdnsAddress = "224.0.0.251:5353"
+
+// DNS record types used by mDNS service discovery
+const (
+	dnsTypeA   = 1
+	dnsTypePTR = 12
+	dnsTypeTXT = 16
+	dnsTypeSRV = 33
+)
+
+// MDNSDiscovery finds services advertised over multicast DNS (Bonjour,
+// Avahi) on the local network, such as printers, NAS boxes and IoT bridges
+type MDNSDiscovery struct {
+	Services []string      // Service types, e.g. "_http._tcp" or "_ipp._tcp"
+	Domain   string        // Defaults to "local"
+	Wait     time.Duration // How long to collect answers (default 2s)
+	Scheme   string        // Defaults to https for _https._tcp services and http otherwise
+	Path     string        // Path checked on each service (defaults to its path TXT record, then /)
+	Address  string        // Overrides the mDNS multicast address
+	Misses   int           // Consecutive queries a service may miss before its target is dropped (default 3)
+
+	mu    sync.Mutex
+	known map[string]mdnsService // Services found by earlier queries, by service type and instance name
+}
+
+// mdnsService is a service found by an earlier query and the number of
+// queries it has missed since
+type mdnsService struct {
+	target Target
+	misses int
+}
+
+// dnsRecord is a resource record from a DNS message
+type dnsRecord struct {
+	name   string
+	rrType uint16
+	target string   // PTR and SRV
+	port   uint16   // SRV
+	ip     net.IP   // A
+	txt    []string // TXT
+}
+
+// Discover queries the configured service types and returns a target for
+// every service instance that answered. Multicast answers get lost, so a
+// service that answered before is kept until it misses Misses queries in a
+// row.
+func (d *MDNSDiscovery) Discover(ctx context.Context) ([]Target, error) {
+	found, err := d.query(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return d.remember(found), nil
+}
+
+// remember adds the services that answered earlier queries but not this
+// one to found, until they have missed too many queries
+func (d *MDNSDiscovery) remember(found []Target) []Target {
+	misses := d.Misses
+	if misses <= 0 {
+		misses = 3
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	services := make(map[string]mdnsService, len(found))
+	for _, target := range found {
+		services[target.Labels["service"]+"/"+target.Labels["instance"]] = mdnsService{target: target}
+	}
+	for key, service := range d.known {
+		if _, ok := services[key]; ok {
+			continue
+		}
+		if service.misses++; service.misses < misses {
+			services[key] = service
+			found = append(found, service.target)
+		}
+	}
+	d.known = services
+	return found
+}
+
+// query sends one query for the configured service types and returns a
+// target for every service instance that answered
+func (d *MDNSDiscovery) query(ctx context.Context) ([]Target, error) {
+	domain := strings.Trim(valueOr(d.Domain, "local"), ".")
+	wait := d.Wait
+	if wait == 0 {
+		wait = 2 * time.Second
+	}
+	var services []string
+	for _, service := range d.Services {
+		services = append(services, strings.Trim(service, ".")+"."+domain+".")
+	}
+
+	// Querying from a port other than 5353 makes responders answer by unicast
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	addr, err := net.ResolveUDPAddr("udp4", valueOr(d.Address, mdnsAddress))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.WriteToUDP(buildDNSQuery(services, dnsTypePTR), addr); err != nil {
+		return nil, fmt.Errorf("sending mDNS query: %w", err)
+	}
+
+	deadline := time.Now().Add(wait)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetReadDeadline(deadline)
+
+	var records []dnsRecord
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+			return nil, err
+		}
+		answers, err := parseDNSMessage(buf[:n])
+		if err != nil {
+			continue
+		}
+		records = append(records, answers...)
+	}
+	return d.targets(domain, services, records), nil
+}
+
+// targets resolves PTR, SRV, TXT and A records into targets
+func (d *MDNSDiscovery) targets(domain string, services []string, records []dnsRecord) []Target {
+	srv := make(map[string]dnsRecord)
+	txt := make(map[string][]string)
+	ips := make(map[string]net.IP)
+	for _, record := range records {
+		switch record.rrType {
+		case dnsTypeSRV:
+			srv[strings.ToLower(record.name)] = record
+		case dnsTypeTXT:
+			txt[strings.ToLower(record.name)] = record.txt
+		case dnsTypeA:
+			ips[strings.ToLower(record.name)] = record.ip
+		}
+	}
+
+	var targets []Target
+	seen := make(map[string]bool)
+	for _, record := range records {
+		if record.rrType != dnsTypePTR || seen[strings.ToLower(record.target)] {
+			continue
+		}
+		service := ""
+		for _, s := range services {
+			if strings.EqualFold(record.name, s) {
+				service = s
+			}
+		}
+		instance, ok := srv[strings.ToLower(record.target)]
+		if service == "" || !ok {
+			continue
+		}
+		seen[strings.ToLower(record.target)] = true
+
+		host := strings.TrimSuffix(instance.target, ".")
+		if ip := ips[strings.ToLower(instance.target)]; ip != nil {
+			host = ip.String()
+		}
+		scheme := d.Scheme
+		if scheme == "" && strings.HasPrefix(service, "_https.") {
+			scheme = "https"
+		}
+		path := d.Path
+		for _, entry := range txt[strings.ToLower(record.target)] {
+			if value, ok := strings.CutPrefix(entry, "path="); ok && path == "" {
+				path = value
+			}
+		}
+		name := strings.TrimSuffix(strings.TrimSuffix(record.target, "."+service), ".")
+		targets = append(targets, Target{
+			URL: backendURL(net.JoinHostPort(host, strconv.Itoa(int(instance.port))), scheme, path),
+			Labels: map[string]string{
+				"service":  strings.TrimSuffix(service, "."+domain+"."),
+				"instance": strings.ReplaceAll(name, `\.`, "."),
+			},
+		})
+	}
+	return targets
+}
+
+// buildDNSQuery encodes a DNS query for names of the given type
+func buildDNSQuery(names []string, qtype uint16) []byte {
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[4:], uint16(len(names)))
+	for _, name := range names {
+		msg = appendDNSName(msg, name)
+		msg = binary.BigEndian.AppendUint16(msg, qtype)
+		msg = binary.BigEndian.AppendUint16(msg, 1) // IN
+	}
+	return msg
+}
+
+// appendDNSName appends name in DNS wire format
+func appendDNSName(msg []byte, name string) []byte {
+	for _, label := range strings.Split(strings.Trim(name, "."), ".") {
+		if label == "" {
+			continue
+		}
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	return append(msg, 0)
+}
+
+// parseDNSMessage returns the answer, authority and additional records of
+// a DNS message
+func parseDNSMessage(msg []byte) ([]dnsRecord, error) {
+	if len(msg) < 12 {
+		return nil, errors.New("short DNS message")
+	}
+	questions := int(binary.BigEndian.Uint16(msg[4:]))
+	count := int(binary.BigEndian.Uint16(msg[6:])) + int(binary.BigEndian.Uint16(msg[8:])) + int(binary.BigEndian.Uint16(msg[10:]))
+
+	offset := 12
+	for i := 0; i < questions; i++ {
+		_, next, err := readDNSName(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+		offset = next + 4
+	}
+
+	var records []dnsRecord
+	for i := 0; i < count; i++ {
+		name, next, err := readDNSName(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+		if next+10 > len(msg) {
+			return nil, errors.New("truncated DNS record")
+		}
+		record := dnsRecord{name: name, rrType: binary.BigEndian.Uint16(msg[next:])}
+		length := int(binary.BigEndian.Uint16(msg[next+8:]))
+		start := next + 10
+		end := start + length
+		if end > len(msg) {
+			return nil, errors.New("truncated DNS record data")
+		}
+
+		switch record.rrType {
+		case dnsTypePTR:
+			record.target, _, err = readDNSName(msg, start)
+		case dnsTypeSRV:
+			if length < 7 {
+				return nil, errors.New("short SRV record")
+			}
+			record.port = binary.BigEndian.Uint16(msg[start+4:])
+			record.target, _, err = readDNSName(msg, start+6)
+		case dnsTypeA:
+			if length == 4 {
+				record.ip = net.IP(append([]byte{}, msg[start:end]...))
+			}
+		case dnsTypeTXT:
+			for i := start; i < end; {
+				n := int(msg[i])
+				if i+1+n > end {
+					break
+				}
+				record.txt = append(record.txt, string(msg[i+1:i+1+n]))
+				i += 1 + n
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+		offset = end
+	}
+	return records, nil
+}
+
+// readDNSName reads a possibly compressed name at offset and returns it
+// with the offset following it
+func readDNSName(msg []byte, offset int) (string, int, error) {
+	var labels []string
+	next := -1
+	for jumps := 0; ; {
+		if offset >= len(msg) {
+			return "", 0, errors.New("truncated DNS name")
+		}
+		length := int(msg[offset])
+		switch {
+		case length == 0:
+			if next < 0 {
+				next = offset + 1
+			}
+			return strings.Join(labels, ".") + ".", next, nil
+		case length&0xC0 == 0xC0:
+			if offset+1 >= len(msg) || jumps > 10 {
+				return "", 0, errors.New("invalid DNS name pointer")
+			}
+			if next < 0 {
+				next = offset + 2
+			}
+			offset = int(binary.BigEndian.Uint16(msg[offset:]) & 0x3FFF)
+			jumps++
+		default:
+			if offset+1+length > len(msg) {
+				return "", 0, errors.New("truncated DNS label")
+			}
+			labels = append(labels, strings.ReplaceAll(string(msg[offset+1:offset+1+length]), ".", `\.`))
+			offset += 1 + length
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+// appendDNSRecord appends a resource record with the given data
+func appendDNSRecord(msg []byte, name string, rrType uint16, data []byte) []byte {
+	msg = appendDNSName(msg, name)
+	msg = binary.BigEndian.AppendUint16(msg, rrType)
+	msg = binary.BigEndian.AppendUint16(msg, 0x8001) // IN, cache flush
+	msg = binary.BigEndian.AppendUint32(msg, 120)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(data)))
+	return append(msg, data...)
+}
+
+func TestMDNSDiscovery(t *testing.T) {
+	responder, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer responder.Close()
+
+	go func() {
+		buf := make([]byte, 1500)
+		n, from, err := responder.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		if n < 12 || binary.BigEndian.Uint16(buf[4:]) != 1 {
+			return
+		}
+
+		instance := appendDNSName(nil, "Living Room NAS._http._tcp.local.")
+		srv := append([]byte{0, 0, 0, 0, 0x13, 0x88}, appendDNSName(nil, "nas.local.")...)
+		txt := append([]byte{14}, "path=/api/ping"...)
+
+		msg := make([]byte, 12)
+		binary.BigEndian.PutUint16(msg[2:], 0x8400)
+		binary.BigEndian.PutUint16(msg[6:], 1)
+		binary.BigEndian.PutUint16(msg[10:], 3)
+		msg = appendDNSRecord(msg, "_http._tcp.local.", dnsTypePTR, instance)
+		msg = appendDNSRecord(msg, "Living Room NAS._http._tcp.local.", dnsTypeSRV, srv)
+		msg = appendDNSRecord(msg, "Living Room NAS._http._tcp.local.", dnsTypeTXT, txt)
+		msg = appendDNSRecord(msg, "nas.local.", dnsTypeA, []byte{192, 168, 1, 20})
+		responder.WriteToUDP(msg, from)
+	}()
+
+	discovery := &MDNSDiscovery{
+		Services: []string{"_http._tcp"},
+		Wait:     200 * time.Millisecond,
+		Address:  responder.LocalAddr().String(),
+	}
+	targets, err := discovery.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover returned error: %v", err)
+	}
+	if len(targets) != 1 {
+		t.Fatalf("Expected one target, got %+v", targets)
+	}
+	if targets[0].URL != "http://192.168.1.20:5000/api/ping" {
+		t.Errorf("Unexpected URL %s", targets[0].URL)
+	}
+	if targets[0].Labels["instance"] != "Living Room NAS" || targets[0].Labels["service"] != "_http._tcp" {
+		t.Errorf("Unexpected labels %+v", targets[0].Labels)
+	}
+}
+
+func TestReadDNSName_Compression(t *testing.T) {
+	msg := appendDNSName(make([]byte, 12), "_ipp._tcp.local.")
+	msg = append(msg, 7)
+	msg = append(msg, "printer"...)
+	msg = append(msg, 0xC0, 12)
+	name, next, err := readDNSName(msg, 29)
+	if err != nil || name != "printer._ipp._tcp.local." || next != len(msg) {
+		t.Errorf("Unexpected name %q (next %d, err %v)", name, next, err)
+	}
+}
+
+func TestMDNSDiscovery_Misses(t *testing.T) {
+	nas := Target{URL: "http://192.168.1.20:5000/", Labels: map[string]string{"service": "_http._tcp", "instance": "NAS"}}
+	printer := Target{URL: "http://192.168.1.30/", Labels: map[string]string{"service": "_http._tcp", "instance": "Printer"}}
+	discovery := &MDNSDiscovery{Misses: 2}
+
+	if found := discovery.remember([]Target{nas, printer}); len(found) != 2 {
+		t.Fatalf("Expected both services, got %+v", found)
+	}
+	// A lost answer does not drop the printer right away
+	if found := discovery.remember([]Target{nas}); len(found) != 2 || found[1].URL != printer.URL {
+		t.Fatalf("Expected the printer to be kept after one miss, got %+v", found)
+	}
+	if found := discovery.remember([]Target{nas}); len(found) != 1 || found[0].URL != nas.URL {
+		t.Fatalf("Expected the printer to be dropped after two misses, got %+v", found)
+	}
+
+	// Answering again resets the count
+	discovery.remember([]Target{nas, printer})
+	discovery.remember([]Target{nas})
+	if found := discovery.remember([]Target{nas, printer}); len(found) != 2 {
+		t.Fatalf("Expected the printer to be back, got %+v", found)
+	}
+	if found := discovery.remember([]Target{nas}); len(found) != 2 {
+		t.Errorf("Expected the miss count to restart, got %+v", found)
+	}
+}
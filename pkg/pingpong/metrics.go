@@ -0,0 +1,219 @@
+package pingpong
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// checkKey identifies a pingpong_checks_total series
+type checkKey struct {
+	success bool
+	reason  FailureReason
+}
+
+// invalidLabelChars matches characters not allowed in Prometheus label names
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// metricsHandler serves service metrics in the Prometheus text format
+func (s *Service) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprint(w, s.renderMetrics())
+}
+
+// targetMetrics is a snapshot of the metrics of one target
+type targetMetrics struct {
+	labels              []string
+	keys                []checkKey
+	counts              map[checkKey]uint64
+	consecutiveFailures int
+	lastLatency         float64
+	lastSuccess         int64
+	checkDuration       float64
+	checkTime           float64
+	codes               []int
+	statusCounts        map[int]uint64
+	errorRatio          float64
+}
+
+// targetMetrics returns a snapshot of a target's metrics
+func (s *Service) targetMetrics(t *target) targetMetrics {
+	m := targetMetrics{
+		labels:      s.metricLabels(t),
+		lastSuccess: atomic.LoadInt64(&t.lastPingSuccess),
+	}
+
+	t.mu.Lock()
+	m.keys = make([]checkKey, 0, len(t.checkCounts))
+	m.counts = make(map[checkKey]uint64, len(t.checkCounts))
+	for key, count := range t.checkCounts {
+		m.keys = append(m.keys, key)
+		m.counts[key] = count
+	}
+	m.consecutiveFailures = t.consecutiveFailures
+	if t.lastResult != nil {
+		m.lastLatency = t.lastResult.Latency.Seconds()
+	}
+	m.checkDuration = t.checkDuration.Seconds()
+	m.checkTime = t.checkTime.Seconds()
+	m.statusCounts = make(map[int]uint64, len(t.statusCounts))
+	for code, count := range t.statusCounts {
+		m.codes = append(m.codes, code)
+		m.statusCounts[code] = count
+	}
+	m.errorRatio = t.errorRatio
+	t.mu.Unlock()
+
+	sort.Ints(m.codes)
+
+	sort.Slice(m.keys, func(i, j int) bool {
+		if m.keys[i].success != m.keys[j].success {
+			return m.keys[i].success
+		}
+		return m.keys[i].reason < m.keys[j].reason
+	})
+	return m
+}
+
+// renderMetrics renders all metrics in the Prometheus text format
+func (s *Service) renderMetrics() string {
+	list := s.targetList()
+	targets := make([]targetMetrics, 0, len(list))
+	for _, t := range list {
+		targets = append(targets, s.targetMetrics(t))
+	}
+
+	var b strings.Builder
+	b.WriteString("# HELP pingpong_checks_total Ping cycles by result and failure reason.\n")
+	b.WriteString("# TYPE pingpong_checks_total counter\n")
+	for _, m := range targets {
+		for _, key := range m.keys {
+			result := "failure"
+			if key.success {
+				result = "success"
+			}
+			fmt.Fprintf(&b, "pingpong_checks_total%s %d\n",
+				formatLabels(m.labels, "result", result, "reason", string(key.reason)), m.counts[key])
+		}
+	}
+
+	b.WriteString("# HELP pingpong_consecutive_failures Current number of consecutive failed ping cycles.\n")
+	b.WriteString("# TYPE pingpong_consecutive_failures gauge\n")
+	for _, m := range targets {
+		fmt.Fprintf(&b, "pingpong_consecutive_failures%s %d\n", formatLabels(m.labels), m.consecutiveFailures)
+	}
+
+	b.WriteString("# HELP pingpong_last_latency_seconds Round-trip time of the last ping attempt.\n")
+	b.WriteString("# TYPE pingpong_last_latency_seconds gauge\n")
+	for _, m := range targets {
+		fmt.Fprintf(&b, "pingpong_last_latency_seconds%s %g\n", formatLabels(m.labels), m.lastLatency)
+	}
+
+	b.WriteString("# HELP pingpong_last_success_timestamp_seconds Unix time of the last successful ping.\n")
+	b.WriteString("# TYPE pingpong_last_success_timestamp_seconds gauge\n")
+	for _, m := range targets {
+		fmt.Fprintf(&b, "pingpong_last_success_timestamp_seconds%s %d\n", formatLabels(m.labels), m.lastSuccess)
+	}
+
+	b.WriteString("# HELP pingpong_last_check_duration_seconds Wall time of the last check of a target, including retries.\n")
+	b.WriteString("# TYPE pingpong_last_check_duration_seconds gauge\n")
+	for _, m := range targets {
+		fmt.Fprintf(&b, "pingpong_last_check_duration_seconds%s %g\n", formatLabels(m.labels), m.checkDuration)
+	}
+
+	b.WriteString("# HELP pingpong_check_duration_seconds_total Total wall time spent checking a target.\n")
+	b.WriteString("# TYPE pingpong_check_duration_seconds_total counter\n")
+	for _, m := range targets {
+		fmt.Fprintf(&b, "pingpong_check_duration_seconds_total%s %g\n", formatLabels(m.labels), m.checkTime)
+	}
+
+	b.WriteString("# HELP pingpong_responses_total Responses received from a target by status code, across all attempts.\n")
+	b.WriteString("# TYPE pingpong_responses_total counter\n")
+	for _, m := range targets {
+		for _, code := range m.codes {
+			fmt.Fprintf(&b, "pingpong_responses_total%s %d\n",
+				formatLabels(m.labels, "code", strconv.Itoa(code)), m.statusCounts[code])
+		}
+	}
+
+	if len(s.config.Peers) > 0 {
+		s.renderRegionMetrics(&b)
+	}
+
+	if s.slots != nil {
+		delayed, skipped := s.slots.counts()
+		b.WriteString("# HELP pingpong_checks_delayed_total Checks that waited for a slot under MaxConcurrentChecks, by target severity.\n")
+		b.WriteString("# TYPE pingpong_checks_delayed_total counter\n")
+		for _, severity := range priorities {
+			fmt.Fprintf(&b, "pingpong_checks_delayed_total%s %d\n",
+				formatLabels([]string{"instance_id", s.config.InstanceID, "priority", string(severity)}), delayed[severity])
+		}
+		b.WriteString("# HELP pingpong_checks_skipped_total Checks skipped because no slot freed up within the target interval, by target severity.\n")
+		b.WriteString("# TYPE pingpong_checks_skipped_total counter\n")
+		for _, severity := range priorities {
+			fmt.Fprintf(&b, "pingpong_checks_skipped_total%s %d\n",
+				formatLabels([]string{"instance_id", s.config.InstanceID, "priority", string(severity)}), skipped[severity])
+		}
+	}
+
+	s.renderUsageMetrics(&b, list)
+
+	if s.config.Watchdog != nil {
+		b.WriteString("# HELP pingpong_watchdog_stalls_total Ping routines found stalled by the watchdog.\n")
+		b.WriteString("# TYPE pingpong_watchdog_stalls_total counter\n")
+		fmt.Fprintf(&b, "pingpong_watchdog_stalls_total%s %d\n",
+			formatLabels([]string{"instance_id", s.config.InstanceID}), s.watchdogStalls.Load())
+	}
+
+	if s.config.ErrorRatio != nil {
+		b.WriteString("# HELP pingpong_error_ratio Share of 4xx and 5xx responses within the error ratio window.\n")
+		b.WriteString("# TYPE pingpong_error_ratio gauge\n")
+		for _, m := range targets {
+			fmt.Fprintf(&b, "pingpong_error_ratio%s %g\n", formatLabels(m.labels), m.errorRatio)
+		}
+	}
+
+	return b.String()
+}
+
+// metricLabels returns the instance identity and target as label name/value
+// pairs
+func (s *Service) metricLabels(t *target) []string {
+	pairs := []string{"instance_id", s.config.InstanceID, "target", t.Name}
+	names := make([]string, 0, len(t.Labels))
+	for name := range t.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		label := invalidLabelChars.ReplaceAllString(name, "_")
+		switch label {
+		case "instance_id", "target", "result", "reason", "code":
+			continue
+		}
+		pairs = append(pairs, label, t.Labels[name])
+	}
+	return pairs
+}
+
+// formatLabels renders label name/value pairs as a Prometheus label set,
+// skipping labels with empty values
+func formatLabels(base []string, extra ...string) string {
+	pairs := append(append([]string{}, base...), extra...)
+	var parts []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			continue
+		}
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(pairs[i+1])
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, pairs[i], value))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
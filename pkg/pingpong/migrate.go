@@ -0,0 +1,136 @@
+package pingpong
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TargetsFileVersion is the schema version of targets files written by this
+// release. Files without a version are version 0.
+const TargetsFileVersion = 1
+
+// migrations upgrade the YAML of a targets file from the version of their
+// index to the next one, reporting whether they changed anything. Add one
+// whenever the schema changes incompatibly.
+var migrations = []func(root *yaml.Node) (bool, error){
+	migrateDurations, // 0 to 1
+}
+
+// MigrateTargetsFile upgrades the YAML of a targets file to
+// TargetsFileVersion, keeping comments, and returns it with the version the
+// file had. Data already at the current version is returned unchanged, as
+// are older files the migrations have nothing to rewrite in, which are
+// reported at TargetsFileVersion. Files written by a newer release are
+// rejected.
+func MigrateTargetsFile(data []byte) ([]byte, int, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return data, TargetsFileVersion, nil // Empty, or invalid in a way decoding reports
+	}
+	root := doc.Content[0]
+
+	version := 0
+	versionNode := mappingValue(root, "version")
+	if versionNode != nil {
+		v, err := strconv.Atoi(versionNode.Value)
+		if err != nil || v < 0 {
+			return nil, 0, fmt.Errorf("line %d: invalid version %q", versionNode.Line, versionNode.Value)
+		}
+		version = v
+	}
+	switch {
+	case version == TargetsFileVersion:
+		return data, version, nil
+	case version > TargetsFileVersion:
+		return nil, version, fmt.Errorf("version %d is newer than version %d supported by this release", version, TargetsFileVersion)
+	}
+
+	changed := false
+	for _, migrate := range migrations[version:] {
+		rewrote, err := migrate(root)
+		if err != nil {
+			return nil, version, err
+		}
+		changed = changed || rewrote
+	}
+	if !changed {
+		return data, TargetsFileVersion, nil
+	}
+	current := strconv.Itoa(TargetsFileVersion)
+	if versionNode != nil {
+		versionNode.Value, versionNode.Tag = current, "!!int"
+	} else {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+		if len(root.Content) > 0 {
+			// Keep a comment heading the file above the new first key
+			key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+		}
+		root.Content = append([]*yaml.Node{key, {Kind: yaml.ScalarNode, Tag: "!!int", Value: current}}, root.Content...)
+	}
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, version, err
+	}
+	return b.Bytes(), version, nil
+}
+
+// migrateDurations rewrites intervals and timeouts given as plain
+// milliseconds as duration strings such as "30s"
+func migrateDurations(root *yaml.Node) (bool, error) {
+	settings := []*yaml.Node{mappingValue(root, "defaults")}
+	settings = append(settings, sequenceItems(mappingValue(root, "targets"))...)
+	for _, group := range sequenceItems(mappingValue(root, "groups")) {
+		settings = append(settings, group)
+		settings = append(settings, sequenceItems(mappingValue(group, "targets"))...)
+	}
+
+	changed := false
+	for _, node := range settings {
+		for _, key := range []string{"interval", "timeout", "soft_timeout"} {
+			value := mappingValue(node, key)
+			if value == nil || value.Kind != yaml.ScalarNode || value.Tag != "!!int" {
+				continue
+			}
+			ms, err := strconv.ParseInt(value.Value, 10, 64)
+			if err != nil {
+				return false, fmt.Errorf("line %d: invalid %s %q", value.Line, key, value.Value)
+			}
+			value.Value, value.Tag, value.Style = (time.Duration(ms) * time.Millisecond).String(), "!!str", 0
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// mappingValue returns the value of key in a mapping node, nil if node is
+// not a mapping or has no such key
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// sequenceItems returns the items of a sequence node, nil if node is not a
+// sequence
+func sequenceItems(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return node.Content
+}
@@ -0,0 +1,75 @@
+package pingpong
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrateTargetsFile(t *testing.T) {
+	legacy := `# Production targets
+defaults:
+  interval: 30000 # every 30s
+  timeout: 5s
+groups:
+  - name: api
+    targets:
+      - url: https://a.example.com/health
+        soft_timeout: 500
+targets:
+  - url: https://docs.example.com
+    labels: {timeout: 5}
+`
+	migrated, version, err := MigrateTargetsFile([]byte(legacy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 0 {
+		t.Errorf("Expected an unversioned file to be version 0, got %d", version)
+	}
+	for _, want := range []string{"# Production targets\nversion: 1\n", "interval: 30s # every 30s", "soft_timeout: 500ms", "labels: {timeout: 5}"} {
+		if !strings.Contains(string(migrated), want) {
+			t.Errorf("Expected the migrated file to contain %q, got:\n%s", want, migrated)
+		}
+	}
+
+	again, version, err := MigrateTargetsFile(migrated)
+	if err != nil || version != TargetsFileVersion || string(again) != string(migrated) {
+		t.Errorf("Expected a current file to be left alone, got version %d, %v", version, err)
+	}
+
+	// An unversioned file with nothing to rewrite needs no migration
+	plain := "targets:\n  - url: https://a.example.com\n    interval: 30s\n"
+	if data, version, err := MigrateTargetsFile([]byte(plain)); err != nil || version != TargetsFileVersion || string(data) != plain {
+		t.Errorf("Expected an unversioned file without plain numbers to be current, got version %d, %v", version, err)
+	}
+
+	if _, _, err := MigrateTargetsFile([]byte("version: 99\n")); err == nil {
+		t.Error("Expected an error for a file from a newer release")
+	}
+	if _, _, err := MigrateTargetsFile([]byte("version: latest\n")); err == nil {
+		t.Error("Expected an error for an invalid version")
+	}
+}
+
+func TestReadTargetsFile_Migrates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.yaml")
+	os.WriteFile(path, []byte("defaults:\n  interval: 5000\ntargets:\n  - url: https://a.example.com\n"), 0o644)
+
+	file, err := ReadTargetsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Version != 0 || time.Duration(file.Defaults.Interval) != 5*time.Second {
+		t.Errorf("Expected a version 0 file with a 5s interval, got version %d and %s", file.Version, file.Defaults.Interval)
+	}
+
+	if err := file.Write(path); err != nil {
+		t.Fatal(err)
+	}
+	if file, _ = ReadTargetsFile(path); file.Version != TargetsFileVersion {
+		t.Errorf("Expected a written file to be at version %d, got %d", TargetsFileVersion, file.Version)
+	}
+}
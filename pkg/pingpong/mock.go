@@ -0,0 +1,206 @@
+package pingpong
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MockStep is one entry of a MockTarget script
+type MockStep struct {
+	Status  int           // Status code to answer with; 0 never answers, so the check times out
+	Count   int           // How many requests in a row get this answer (default 1)
+	Latency time.Duration // Added to the MockTarget latency before answering
+}
+
+// String formats the step in the syntax read by ParseMockScript
+func (st MockStep) String() string {
+	s := "timeout"
+	switch {
+	case st.Status == http.StatusOK:
+		s = "ok"
+	case st.Status != 0:
+		s = strconv.Itoa(st.Status)
+	}
+	if st.Latency > 0 {
+		s += "@" + st.Latency.String()
+	}
+	if st.Count > 1 {
+		s += "x" + strconv.Itoa(st.Count)
+	}
+	return s
+}
+
+// ParseMockScript parses a comma-separated script such as
+// "ok×5, 500×3, timeout×2". Each entry is ok, a status code or timeout,
+// optionally followed by @latency and by xN, ×N or *N repeating it N times.
+func ParseMockScript(script string) ([]MockStep, error) {
+	var steps []MockStep
+	for _, entry := range strings.Split(script, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		step := MockStep{Count: 1}
+		if i := strings.IndexAny(entry, "x×*"); i >= 0 {
+			_, size := utf8.DecodeRuneInString(entry[i:])
+			count, err := strconv.Atoi(strings.TrimSpace(entry[i+size:]))
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("invalid repeat count in %q", entry)
+			}
+			step.Count = count
+			entry = strings.TrimSpace(entry[:i])
+		}
+		if answer, latency, ok := strings.Cut(entry, "@"); ok {
+			d, err := ParseDuration(latency)
+			if err != nil {
+				return nil, fmt.Errorf("invalid latency in %q: %w", entry, err)
+			}
+			step.Latency = d
+			entry = answer
+		}
+		switch entry {
+		case "ok":
+			step.Status = http.StatusOK
+		case "timeout":
+			step.Status = 0
+		default:
+			status, err := strconv.Atoi(entry)
+			if err != nil || status < 100 || status > 599 {
+				return nil, fmt.Errorf("invalid answer %q: expected ok, timeout or a status code", entry)
+			}
+			step.Status = status
+		}
+		steps = append(steps, step)
+	}
+	if len(steps) == 0 {
+		return nil, errors.New("empty script")
+	}
+	return steps, nil
+}
+
+// MockTarget is a target whose answers follow a script, for demoing and
+// testing alerting end to end without breaking a real service. The script
+// loops, so "ok×5,500×5" flaps forever. Requests to /mock/control read the
+// current behaviour (GET) or change it (POST with script and latency form
+// values); every other path is answered according to the script.
+type MockTarget struct {
+	mu      sync.Mutex
+	script  []MockStep
+	latency time.Duration
+	step    int // Index into script of the next answer
+	served  int // Answers already given for script[step]
+}
+
+// NewMockTarget creates a mock target following script, answering every
+// request after latency. A nil script always answers ok.
+func NewMockTarget(script []MockStep, latency time.Duration) *MockTarget {
+	m := &MockTarget{}
+	m.Set(script, latency)
+	return m
+}
+
+// Set replaces the script and latency and restarts the script
+func (m *MockTarget) Set(script []MockStep, latency time.Duration) {
+	if len(script) == 0 {
+		script = []MockStep{{Status: http.StatusOK, Count: 1}}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.script, m.latency = script, latency
+	m.step, m.served = 0, 0
+}
+
+// next returns the answer to the next request and advances the script
+func (m *MockTarget) next() (MockStep, time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	step := m.script[m.step]
+	m.served++
+	if m.served >= max(step.Count, 1) {
+		m.step = (m.step + 1) % len(m.script)
+		m.served = 0
+	}
+	return step, m.latency + step.Latency
+}
+
+// MockStatus is the response of the /mock/control endpoint of a MockTarget
+type MockStatus struct {
+	Script  string   `json:"script"`
+	Latency Duration `json:"latency"`
+	Next    string   `json:"next"` // Answer to the next request
+}
+
+// status returns the current behaviour of the mock target
+func (m *MockTarget) status() MockStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entries := make([]string, len(m.script))
+	for i, step := range m.script {
+		entries[i] = step.String()
+	}
+	return MockStatus{Script: strings.Join(entries, ","), Latency: Duration(m.latency), Next: m.script[m.step].String()}
+}
+
+// ServeHTTP answers requests according to the script
+func (m *MockTarget) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/mock/control" {
+		m.control(w, r)
+		return
+	}
+
+	step, latency := m.next()
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if step.Status == 0 {
+		<-r.Context().Done()
+		return
+	}
+	w.WriteHeader(step.Status)
+	if step.Status < 300 {
+		fmt.Fprintln(w, "ok")
+	} else {
+		fmt.Fprintln(w, http.StatusText(step.Status))
+	}
+}
+
+// control serves /mock/control
+func (m *MockTarget) control(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		m.mu.Lock()
+		script, latency := m.script, m.latency
+		m.mu.Unlock()
+		if value := r.FormValue("script"); value != "" {
+			var err error
+			if script, err = ParseMockScript(value); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if value := r.FormValue("latency"); value != "" {
+			var err error
+			if latency, err = ParseDuration(value); err != nil {
+				http.Error(w, "Invalid latency: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		m.Set(script, latency)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, m.status())
+}
@@ -0,0 +1,73 @@
+package pingpong
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMockScript(t *testing.T) {
+	steps, err := ParseMockScript("ok×2, 500*3, timeout, 503@20ms x2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []MockStep{
+		{Status: 200, Count: 2},
+		{Status: 500, Count: 3},
+		{Status: 0, Count: 1},
+		{Status: 503, Count: 2, Latency: 20 * time.Millisecond},
+	}
+	if len(steps) != len(want) {
+		t.Fatalf("Expected %d steps, got %+v", len(want), steps)
+	}
+	for i := range want {
+		if steps[i] != want[i] {
+			t.Errorf("Step %d: expected %+v, got %+v", i, want[i], steps[i])
+		}
+	}
+
+	for _, script := range []string{"", "ok×0", "200x", "maybe", "700", "ok@soon"} {
+		if _, err := ParseMockScript(script); err == nil {
+			t.Errorf("Expected an error for %q", script)
+		}
+	}
+}
+
+func TestMockTarget(t *testing.T) {
+	script, _ := ParseMockScript("ok×2,500,timeout")
+	server := httptest.NewServer(NewMockTarget(script, 0))
+	defer server.Close()
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+
+	var got []string
+	for i := 0; i < 5; i++ {
+		resp, err := client.Get(server.URL + "/health")
+		if err != nil {
+			got = append(got, "timeout")
+			continue
+		}
+		resp.Body.Close()
+		got = append(got, resp.Status[:3])
+	}
+	if strings.Join(got, ",") != "200,200,500,timeout,200" {
+		t.Errorf("Unexpected answers %v", got)
+	}
+
+	// The control endpoint replaces the script and latency
+	resp, err := http.PostForm(server.URL+"/mock/control", url.Values{"script": {"503"}, "latency": {"10ms"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 from the control endpoint, got %d", resp.StatusCode)
+	}
+	result, err := Check(context.Background(), Target{URL: server.URL})
+	if err == nil || result.StatusCode != http.StatusServiceUnavailable || result.Latency < 10*time.Millisecond {
+		t.Errorf("Expected a slow 503, got %+v (%v)", result, err)
+	}
+}
@@ -0,0 +1,50 @@
+package pingpong
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// NewMTLSClient returns an HTTP client that presents the client certificate
+// in certFile and keyFile, so receivers can require mutual TLS. The files are
+// read again for every new connection, so a rotated certificate is used
+// without a restart. If caFile is set, servers must present a certificate
+// signed by one of its CAs instead of the system roots.
+func NewMTLSClient(certFile, keyFile, caFile string) (*http.Client, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("client certificate and key are both required")
+	}
+	// Fail early on unreadable files rather than on the first delivery
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load client certificate: %w", err)
+			}
+			return &cert, nil
+		},
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,73 @@
+package pingpong
+
+// notifiable reports whether a notifier should alert people about an event:
+// state changes after startup and targets found down at startup, digests, acknowledgements, error ratio alerts,
+// content changes and removed targets. Changes between up and degraded,
+// which flap with latency around the soft timeout, only count for notifiers
+// opting in with degraded.
+func notifiable(event Event, degraded bool) bool {
+	switch event.Type {
+	case EventStateChange:
+		if !degraded && degradedChange(event) {
+			return false
+		}
+		return event.PreviousState != StateUnknown || event.State == StateDown
+	case EventAcknowledged, EventDigest, EventErrorRatio, EventContentChanged, EventDecommissioned:
+		return true
+	default:
+		return false
+	}
+}
+
+// degradedChange reports whether a state change is between up and degraded
+func degradedChange(event Event) bool {
+	upOrDegraded := func(state State) bool { return state == StateUp || state == StateDegraded }
+	return event.Type == EventStateChange && upOrDegraded(event.State) && upOrDegraded(event.PreviousState)
+}
+
+// notificationTitle returns a short title for a notification
+func notificationTitle(event Event) string {
+	switch {
+	case event.Type == EventAcknowledged:
+		return "Outage acknowledged"
+	case event.Type == EventContentChanged:
+		return "Content changed"
+	case event.Type == EventDecommissioned:
+		return "Target removed"
+	case event.Type == EventErrorRatio && event.ErrorRatio != nil && event.ErrorRatio.Alerting:
+		return "Error ratio rising"
+	case event.Type == EventErrorRatio:
+		return "Error ratio recovered"
+	case event.State == StateDown:
+		return "Target down"
+	case event.State == StateDegraded:
+		return "Target degraded"
+	default:
+		return "Target recovered"
+	}
+}
+
+// notificationTag returns an emoji shortcode tag for a notification
+func notificationTag(event Event) string {
+	switch {
+	case event.Type == EventAcknowledged:
+		return "eyes"
+	case event.Type == EventContentChanged:
+		return "pencil2"
+	case event.Type == EventDecommissioned:
+		return "wastebasket"
+	case event.Type == EventErrorRatio && event.ErrorRatio != nil && event.ErrorRatio.Alerting:
+		return "warning"
+	case event.State == StateDown:
+		return "rotating_light"
+	case event.State == StateDegraded:
+		return "snail"
+	default:
+		return "white_check_mark"
+	}
+}
+
+// isOutage reports whether an event announces that targets went down
+func isOutage(event Event) bool {
+	return (event.Type == EventStateChange || event.Type == EventDigest) && event.State == StateDown
+}
@@ -0,0 +1,149 @@
+package pingpong
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ntfyPriority maps an event to an ntfy priority (1 min to 5 urgent)
+func ntfyPriority(event Event) int {
+	if event.State != StateDown || event.Type == EventAcknowledged {
+		return 3
+	}
+	switch event.Severity {
+	case SeverityCritical:
+		return 5
+	case SeverityInfo:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// NtfyNotifier publishes push notifications to an ntfy topic. Down
+// notifications carry "Ack" and "Silence 1h" action buttons that call back
+// into the admin API at AdminURL.
+type NtfyNotifier struct {
+	ServerURL  string // Defaults to https://ntfy.sh
+	Topic      string
+	Token      string // Optional ntfy access token
+	AdminURL   string // Base URL of this instance used for action buttons, e.g. https://pinger.example.com
+	AdminToken string // Sent with action button requests when the admin API requires a token
+	Degraded   bool   // Also publish changes between up and degraded
+	Client     *http.Client
+}
+
+// ntfyAction is an ntfy action button
+type ntfyAction struct {
+	Action  string            `json:"action"`
+	Label   string            `json:"label"`
+	URL     string            `json:"url"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Clear   bool              `json:"clear,omitempty"`
+}
+
+// Send publishes state change and acknowledgement notifications
+func (n *NtfyNotifier) Send(ctx context.Context, event Event) error {
+	if !notifiable(event, n.Degraded) {
+		return nil
+	}
+
+	message := map[string]any{
+		"topic":    n.Topic,
+		"title":    notificationTitle(event),
+		"message":  event.Summary(),
+		"priority": ntfyPriority(event),
+		"tags":     []string{notificationTag(event)},
+	}
+	if n.AdminURL != "" && isOutage(event) {
+		var headers map[string]string
+		if n.AdminToken != "" {
+			headers = map[string]string{"Authorization": "Bearer " + n.AdminToken}
+		}
+		base := strings.TrimSuffix(n.AdminURL, "/")
+		message["actions"] = []ntfyAction{
+			{Action: "http", Label: "Ack", URL: base + "/api/ack", Method: http.MethodPost, Headers: headers, Clear: true},
+			{Action: "http", Label: "Silence 1h", URL: base + "/api/silence?duration=1h", Method: http.MethodPost, Headers: headers, Clear: true},
+		}
+		message["click"] = base + "/status"
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode ntfy message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, valueOr(n.ServerURL, "https://ntfy.sh"), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create ntfy request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.Token)
+	}
+
+	return doRequest(n.Client, req)
+}
+
+// gotifyPriority maps an event to a Gotify priority (0 to 10)
+func gotifyPriority(event Event) int {
+	if event.State != StateDown || event.Type == EventAcknowledged {
+		return 2
+	}
+	switch event.Severity {
+	case SeverityCritical:
+		return 10
+	case SeverityInfo:
+		return 4
+	default:
+		return 7
+	}
+}
+
+// GotifyNotifier sends push notifications through a Gotify server. Gotify has
+// no action buttons, so tapping a notification opens the /status endpoint at
+// AdminURL instead.
+type GotifyNotifier struct {
+	ServerURL string
+	AppToken  string // Application token
+	AdminURL  string // Base URL of this instance opened when the notification is tapped
+	Degraded  bool   // Also push changes between up and degraded
+	Client    *http.Client
+}
+
+// Send publishes state change and acknowledgement notifications
+func (g *GotifyNotifier) Send(ctx context.Context, event Event) error {
+	if !notifiable(event, g.Degraded) {
+		return nil
+	}
+
+	message := map[string]any{
+		"title":    notificationTitle(event),
+		"message":  event.Summary(),
+		"priority": gotifyPriority(event),
+	}
+	if g.AdminURL != "" {
+		message["extras"] = map[string]any{
+			"client::notification": map[string]any{
+				"click": map[string]string{"url": strings.TrimSuffix(g.AdminURL, "/") + "/status"},
+			},
+		}
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode Gotify message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(g.ServerURL, "/")+"/message", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Gotify request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", g.AppToken)
+
+	return doRequest(g.Client, req)
+}
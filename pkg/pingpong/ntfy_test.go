@@ -0,0 +1,55 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNtfyNotifier(t *testing.T) {
+	var message struct {
+		Topic    string       `json:"topic"`
+		Priority int          `json:"priority"`
+		Actions  []ntfyAction `json:"actions"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&message)
+	}))
+	defer server.Close()
+
+	notifier := &NtfyNotifier{ServerURL: server.URL, Topic: "alerts", AdminURL: "https://pinger/", AdminToken: "secret"}
+	event := Event{Type: EventStateChange, Target: "http://api", State: StateDown, PreviousState: StateUp, Severity: SeverityCritical}
+	if err := notifier.Send(context.Background(), event); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	if message.Topic != "alerts" || message.Priority != 5 {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+	if len(message.Actions) != 2 || message.Actions[0].URL != "https://pinger/api/ack" ||
+		message.Actions[1].URL != "https://pinger/api/silence?duration=1h" ||
+		message.Actions[0].Headers["Authorization"] != "Bearer secret" {
+		t.Errorf("Unexpected actions: %+v", message.Actions)
+	}
+}
+
+func TestGotifyNotifier(t *testing.T) {
+	var key string
+	var message map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("X-Gotify-Key")
+		json.NewDecoder(r.Body).Decode(&message)
+	}))
+	defer server.Close()
+
+	notifier := &GotifyNotifier{ServerURL: server.URL, AppToken: "app"}
+	event := Event{Type: EventStateChange, Target: "http://api", State: StateUp, PreviousState: StateDown}
+	if err := notifier.Send(context.Background(), event); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if key != "app" || message["priority"] != float64(2) || message["title"] != "Target recovered" {
+		t.Errorf("Unexpected message %v with key %q", message, key)
+	}
+}
@@ -0,0 +1,286 @@
+package pingpong
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiOperation describes one admin API operation, including the query
+// parameters it takes and the headers of a successful response. The same
+// table registers the handlers and generates the OpenAPI document, so the two
+// cannot drift apart.
+type apiOperation struct {
+	method   string
+	path     string
+	id       string // operationId, also the method name in the generated client
+	summary  string
+	query    []apiParam
+	headers  []apiParam
+	request  any // Example of the JSON request body, nil for none
+	response any // Example of the JSON response body, nil for none
+	status   int // Status of a successful response
+	handler  func(*Service, http.ResponseWriter, *http.Request)
+}
+
+// apiParam is a query parameter or response header of an admin API
+// operation
+type apiParam struct {
+	name        string
+	kind        string // JSON schema type of the value
+	description string
+}
+
+// adminAPI lists the operations of the admin API
+var adminAPI = []apiOperation{
+	{
+		method: http.MethodPost, path: "/api/ack", id: "acknowledge",
+		summary: "Acknowledge the open incident and notify everyone",
+		status:  http.StatusNoContent, handler: (*Service).ackHandler,
+	},
+	{
+		method: http.MethodPost, path: "/api/silence", id: "silence",
+		summary:  "Pause notifiers; sinks keep receiving events",
+		query:    []apiParam{{"duration", "string", "How long to silence notifiers, e.g. 1h (default 1h)"}},
+		response: SilenceResponse{}, status: http.StatusOK, handler: (*Service).silenceHandler,
+	},
+	{
+		method: http.MethodDelete, path: "/api/silence", id: "unsilence",
+		summary: "Resume notifiers",
+		status:  http.StatusNoContent, handler: (*Service).unsilenceHandler,
+	},
+	{
+		method: http.MethodGet, path: "/api/targets", id: "listTargets",
+		summary:  "List the targets with their effective settings, filtered, sorted and paged",
+		query:    targetQueryParams,
+		headers:  []apiParam{{"X-Total-Count", "integer", "Number of targets matching the filters before paging"}},
+		response: []EffectiveTarget{}, status: http.StatusOK, handler: (*Service).listTargetsHandler,
+	},
+	{
+		method: http.MethodPost, path: "/api/targets", id: "addTargets",
+		summary: "Start checking targets; targets whose name is already in use are skipped",
+		request: []TargetEntry{}, response: AddTargetsResponse{}, status: http.StatusOK, handler: (*Service).addTargetsHandler,
+	},
+	{
+		method: http.MethodDelete, path: "/api/targets", id: "removeTarget",
+		summary: "Stop checking a target, closing its open incident",
+		query:   []apiParam{{"name", "string", "Name of the target to remove"}},
+		status:  http.StatusNoContent, handler: (*Service).removeTargetHandler,
+	},
+	{
+		method: http.MethodGet, path: "/api/state", id: "exportState",
+		summary:  "Snapshot the targets with their state, incidents and counters, and the silence",
+		response: Snapshot{}, status: http.StatusOK, handler: (*Service).exportStateHandler,
+	},
+	{
+		method: http.MethodPost, path: "/api/state", id: "importState",
+		summary: "Apply a snapshot exported by another instance",
+		request: Snapshot{}, response: ImportStateResponse{}, status: http.StatusOK, handler: (*Service).importStateHandler,
+	},
+}
+
+// targetQueryParams are the query parameters of a TargetQuery
+var targetQueryParams = []apiParam{
+	{"state", "string", "Only targets in one of these comma-separated states: up, degraded, down or unknown"},
+	{"search", "string", "Only targets whose name or URL contains this, ignoring case"},
+	{"sort", "string", "Order by name, state, latency, uptime or last_change; prefix with - for descending order"},
+	{"offset", "integer", "Targets skipped after filtering and sorting"},
+	{"limit", "integer", "Maximum number of targets returned"},
+}
+
+// registerAdminAPI serves the admin API operations on mux
+func (s *Service) registerAdminAPI(mux *http.ServeMux) {
+	var paths []string
+	byPath := make(map[string][]apiOperation)
+	for _, op := range adminAPI {
+		if _, ok := byPath[op.path]; !ok {
+			paths = append(paths, op.path)
+		}
+		byPath[op.path] = append(byPath[op.path], op)
+	}
+	for _, path := range paths {
+		ops := byPath[path]
+		methods := make([]string, len(ops))
+		for i, op := range ops {
+			methods[i] = op.method
+		}
+		mux.HandleFunc(path, s.adminOnly(strings.Join(methods, ", "), func(w http.ResponseWriter, r *http.Request) {
+			for _, op := range ops {
+				if op.method == r.Method {
+					op.handler(s, w, r)
+					return
+				}
+			}
+		}))
+	}
+}
+
+// openAPIHandler serves the OpenAPI document of the admin API
+func (s *Service) openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(OpenAPI())
+}
+
+// OpenAPI returns the OpenAPI 3 document describing the admin API, as served
+// at /api/openapi.json
+func OpenAPI() []byte {
+	schemas := make(map[string]any)
+	paths := make(map[string]map[string]any)
+	for _, op := range adminAPI {
+		operation := map[string]any{
+			"operationId": op.id,
+			"summary":     op.summary,
+		}
+		var params []map[string]any
+		for _, param := range op.query {
+			params = append(params, map[string]any{
+				"name":        param.name,
+				"in":          "query",
+				"description": param.description,
+				"schema":      map[string]any{"type": param.kind},
+			})
+		}
+		if params != nil {
+			operation["parameters"] = params
+		}
+		if op.request != nil {
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content":  jsonContent(schemaOf(reflect.TypeOf(op.request), schemas)),
+			}
+		}
+		success := map[string]any{"description": http.StatusText(op.status)}
+		if op.response != nil {
+			success["content"] = jsonContent(schemaOf(reflect.TypeOf(op.response), schemas))
+		}
+		if op.headers != nil {
+			headers := make(map[string]any)
+			for _, header := range op.headers {
+				headers[header.name] = map[string]any{
+					"description": header.description,
+					"schema":      map[string]any{"type": header.kind},
+				}
+			}
+			success["headers"] = headers
+		}
+		operation["responses"] = map[string]any{
+			strconv.Itoa(op.status): success,
+			"400":                   map[string]any{"description": "Invalid request"},
+			"401":                   map[string]any{"description": "Missing or wrong admin token"},
+		}
+		if paths[op.path] == nil {
+			paths[op.path] = make(map[string]any)
+		}
+		paths[op.path][strings.ToLower(op.method)] = operation
+	}
+
+	doc := map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "pingpong admin API",
+			"version": version(),
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"adminToken": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+		"security": []map[string]any{{"adminToken": []string{}}},
+	}
+	data, _ := json.MarshalIndent(doc, "", "  ")
+	return data
+}
+
+// jsonContent wraps a schema as an application/json media type
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(Duration(0))
+)
+
+// schemaOf returns the JSON schema of t as encoded by encoding/json. Named
+// structs are added to schemas and referenced.
+func schemaOf(t reflect.Type, schemas map[string]any) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]any{"type": "string", "description": "Duration such as 30s; a number is read as milliseconds"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := schemaOf(t.Elem(), schemas)
+		if _, ok := schema["$ref"]; ok {
+			return map[string]any{"allOf": []any{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		if _, ok := schemas[t.Name()]; !ok {
+			schemas[t.Name()] = nil // Guards against recursive types
+			schemas[t.Name()] = structSchema(t, schemas)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	return map[string]any{}
+}
+
+// structSchema returns the object schema of a struct's JSON fields
+func structSchema(t reflect.Type, schemas map[string]any) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+				continue
+			}
+			name, options, _ := strings.Cut(tag, ",")
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				addFields(field.Type)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = schemaOf(field.Type, schemas)
+			if !strings.Contains(options, "omitempty") {
+				required = append(required, name)
+			}
+		}
+	}
+	addFields(t)
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if required != nil {
+		schema["required"] = required
+	}
+	return schema
+}
@@ -0,0 +1,46 @@
+package pingpong
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	service := NewService(Config{ServerURL: "http://example.com", Logger: &TestLogger{}})
+	w := httptest.NewRecorder()
+	service.openAPIHandler(w, httptest.NewRequest("GET", "/api/openapi.json", nil))
+
+	var doc struct {
+		OpenAPI    string                               `json:"openapi"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]any `json:"properties"`
+				Required   []string                  `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to decode OpenAPI document: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("Expected an OpenAPI 3 document, got %q", doc.OpenAPI)
+	}
+	for _, op := range adminAPI {
+		operation := doc.Paths[op.path][strings.ToLower(op.method)]
+		if operation == nil || operation["operationId"] != op.id {
+			t.Errorf("Expected %s %s in the document, got %v", op.method, op.path, operation)
+		}
+	}
+
+	// Embedded settings are flattened like encoding/json does
+	entry := doc.Components.Schemas["TargetEntry"]
+	if entry.Properties["interval"]["type"] != "string" || entry.Properties["url"] == nil || len(entry.Required) != 1 || entry.Required[0] != "url" {
+		t.Errorf("Unexpected TargetEntry schema: %+v", entry)
+	}
+	if silence := doc.Components.Schemas["SilenceResponse"]; silence.Properties["silenced_until"]["format"] != "date-time" {
+		t.Errorf("Unexpected SilenceResponse schema: %+v", silence)
+	}
+}
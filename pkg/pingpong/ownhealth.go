@@ -0,0 +1,108 @@
+package pingpong
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// OwnCheckMode controls when the service calls its own health check at
+// OwnURL
+type OwnCheckMode string
+
+const (
+	// OwnCheckAfterSuccess calls OwnURL after every successful ping (default)
+	OwnCheckAfterSuccess OwnCheckMode = "after_success"
+	// OwnCheckAlways calls OwnURL after every ping cycle, successful or not
+	OwnCheckAlways OwnCheckMode = "always"
+	// OwnCheckDisabled never calls OwnURL
+	OwnCheckDisabled OwnCheckMode = "disabled"
+)
+
+// checkOwnURL looks for loops between the own health check and the targets
+// and logs them. The own health check is disabled when OwnURL points at a
+// target, since it would simply ping the target a second time.
+func (s *Service) checkOwnURL() {
+	s.checkLoops(s.targetList())
+	if s.config.OwnURL != "" && s.ownCheck() != OwnCheckDisabled && !isSelf(s.config.OwnURL, s.serverPort()) {
+		s.logger.Warn("OwnURL %s does not point at this instance's server on port %s", s.config.OwnURL, s.serverPort())
+	}
+}
+
+// checkLoops looks for loops between the own health check and targets,
+// including ones added at runtime, disabling the own health check if OwnURL
+// points at one of them
+func (s *Service) checkLoops(targets []*target) {
+	port := s.serverPort()
+	for _, t := range targets {
+		if isSelf(t.URL, port) {
+			s.logger.Warn("Target %s points at this instance's own server, so its health depends on itself", t.Name)
+		}
+		if s.config.OwnURL != "" && s.ownCheck() != OwnCheckDisabled && sameEndpoint(s.config.OwnURL, t.URL) {
+			s.logger.Warn("OwnURL %s points at target %s; disabling the own health check", s.config.OwnURL, t.Name)
+			s.ownCheckOff.Store(true)
+		}
+	}
+}
+
+// ownCheck returns when the own health check is called, which is never
+// once OwnURL turned out to point at a target
+func (s *Service) ownCheck() OwnCheckMode {
+	if s.ownCheckOff.Load() {
+		return OwnCheckDisabled
+	}
+	return OwnCheckMode(valueOr(string(s.config.OwnCheck), string(OwnCheckAfterSuccess)))
+}
+
+// serverPort returns the port the HTTP endpoints are served on
+func (s *Service) serverPort() string {
+	if s.config.Listener != nil {
+		if _, port, err := net.SplitHostPort(s.config.Listener.Addr().String()); err == nil {
+			return port
+		}
+		return ""
+	}
+	return "8080"
+}
+
+// isSelf reports whether rawURL points at a server on this host listening on
+// port
+func isSelf(rawURL, port string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || port == "" || urlPort(u) != port {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" || host == "0.0.0.0" || host == "::" {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+	hostname, _ := os.Hostname()
+	return hostname != "" && strings.EqualFold(host, hostname)
+}
+
+// sameEndpoint reports whether two URLs point at the same host, port and
+// path
+func sameEndpoint(a, b string) bool {
+	ua, errA := url.Parse(a)
+	ub, errB := url.Parse(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Hostname(), ub.Hostname()) && urlPort(ua) == urlPort(ub) &&
+		strings.TrimSuffix(ua.Path, "/") == strings.TrimSuffix(ub.Path, "/")
+}
+
+// urlPort returns the port of a URL, defaulting to the scheme's
+func urlPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
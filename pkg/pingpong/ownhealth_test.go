@@ -0,0 +1,88 @@
+package pingpong
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestService_CheckOwnURL(t *testing.T) {
+	logger := &TestLogger{}
+	service := NewService(Config{
+		Targets: []Target{
+			{Name: "self", URL: "http://localhost:8080/status"},
+			{Name: "api", URL: "https://api.example.com/health"},
+		},
+		OwnURL: "https://api.example.com/health/",
+		Logger: logger,
+	})
+	service.checkOwnURL()
+
+	warnings := strings.Join(logger.WarnLogs, "\n")
+	if !strings.Contains(warnings, "points at this instance's own server") {
+		t.Errorf("Expected a warning about the self-pointing target, got:\n%s", warnings)
+	}
+	if !strings.Contains(warnings, "disabling the own health check") || service.ownCheck() != OwnCheckDisabled {
+		t.Errorf("Expected the own health check to be disabled, got %q:\n%s", service.ownCheck(), warnings)
+	}
+
+	logger = &TestLogger{}
+	service = NewService(Config{ServerURL: "https://api.example.com/health", OwnURL: "http://127.0.0.1:8080/health", Logger: logger})
+	service.checkOwnURL()
+	if len(logger.WarnLogs) != 0 || service.ownCheck() == OwnCheckDisabled {
+		t.Errorf("Expected no warnings for a sane configuration, got %v", logger.WarnLogs)
+	}
+}
+
+func TestService_CheckOwnURL_RuntimeTargets(t *testing.T) {
+	service := NewService(Config{
+		ServerURL:    "https://api.example.com/health",
+		OwnURL:       "http://127.0.0.1:8080/health",
+		PingInterval: time.Hour,
+		Logger:       &TestLogger{},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	service.syncTargets(ctx, "discovery 1", []Target{{URL: "https://b.example.com/health"}})
+	if service.ownCheck() == OwnCheckDisabled {
+		t.Fatal("Expected the own health check to stay enabled for an unrelated target")
+	}
+	service.syncTargets(ctx, "discovery 1", []Target{{URL: "http://127.0.0.1:8080/health"}})
+	if service.ownCheck() != OwnCheckDisabled {
+		t.Error("Expected a discovered target at OwnURL to disable the own health check")
+	}
+
+	service = NewService(Config{ServerURL: "https://api.example.com/health", OwnURL: "http://127.0.0.1:8080/health", Logger: &TestLogger{}})
+	if _, err := service.AddTargets([]Target{{URL: "http://localhost:8080/health"}, {URL: "http://127.0.0.1:8080/health/"}}); err != nil {
+		t.Fatal(err)
+	}
+	if service.ownCheck() != OwnCheckDisabled {
+		t.Error("Expected an added target at OwnURL to disable the own health check")
+	}
+}
+
+func TestService_OwnCheckMode(t *testing.T) {
+	var ownCalls atomic.Int32
+	own := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ownCalls.Add(1)
+	}))
+	defer own.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failing.Close()
+
+	for mode, want := range map[OwnCheckMode]int32{"": 0, OwnCheckAfterSuccess: 0, OwnCheckAlways: 1, OwnCheckDisabled: 0} {
+		ownCalls.Store(0)
+		service := NewService(Config{ServerURL: failing.URL, OwnURL: own.URL, OwnCheck: mode, MaxRetries: 1, Logger: &TestLogger{}})
+		service.pingServer(context.Background(), service.targets[0])
+		if got := ownCalls.Load(); got != want {
+			t.Errorf("OwnCheck %q: expected %d own health checks after a failure, got %d", mode, want, got)
+		}
+	}
+}
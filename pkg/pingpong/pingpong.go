@@ -7,29 +7,93 @@
 // - Custom headers for ping requests
 // - Health check endpoints
 // - Consecutive failure tracking
+// - Multiple targets checked concurrently
 // - Graceful shutdown on repeated failures
 package pingpong
 
 import (
 	"context"
 	"fmt"
+	"math/rand/v2"
+	"net"
 	"net/http"
+	"os"
+	"slices"
+	"sync"
 	"sync/atomic"
 	"time"
 )
 
 // Config represents the configuration for the ping-pong service
 type Config struct {
-	ServerURL           string
+	ServerURL           string // Shorthand for a single target; more can be added with Targets
 	OwnURL              string
-	PingInterval        time.Duration
-	Headers             map[string]string // Custom headers for ping requests
-	MaxConsecutiveFails int               // Maximum number of consecutive failures before shutdown
-	MaxRetries          int               // Maximum number of retries for each ping
-	Logger              Logger            // Custom logger interface
+	OwnCheck            OwnCheckMode                // When OwnURL is called (default after_success)
+	PingInterval        time.Duration               // Time between checks of targets without their own Interval (default 2s)
+	Headers             map[string]string           // Custom headers for ping requests
+	MaxConsecutiveFails int                         // Consecutive failures after which a target is parked, or the service shuts down in single ServerURL mode
+	ShutdownOnMaxFails  bool                        // Shut the service down when any target reaches MaxConsecutiveFails instead of parking it
+	MaxRetries          int                         // Maximum number of retries for each ping
+	Logger              Logger                      // Custom logger interface
+	OnShutdown          func(reason ShutdownReason) // Called once before the service shuts down
+	InstanceID          string                      // Identifies this instance in results and status output (defaults to the hostname)
+	Labels              map[string]string           // Labels such as region or host attached to results and status output
+	Timeout             time.Duration               // Timeout for each ping attempt (default 10s)
+	SoftTimeout         time.Duration               // Successful pings slower than this mark the target degraded (default disabled)
+	ExpectedBody        string                      // If set, the response body must contain this string
+	HashBody            bool                        // Detect response body changes of every target (see Target.HashBody)
+	RetryOn             map[FailureReason]bool      // Overrides which failure reasons are retried (see FailureReason.Retryable)
+	ResetPolicy         ResetPolicy                 // Controls when the consecutive-failure streak is reset
+	FailureThreshold    int                         // Consecutive failed cycles before the target is considered down (default 1)
+	Sinks               []Sink                      // Receive result and state change events
+	Severity            Severity                    // Severity attached to events about the target (default warning)
+	Notifiers           []Sink                      // Alert people about events; paused while notifications are silenced
+	NamedNotifiers      map[string]Sink             // Notifiers that targets added through the admin API refer to by name, like in a targets file
+	AdminToken          string                      // If set, admin API requests must send it as a bearer token
+	Steps               []Step                      // Multi-step check run instead of a GET to ServerURL (see LoadHAR)
+	Targets             []Target                    // Additional targets, each checked on its own goroutine
+	HealthFuncs         map[string]HealthFunc       // Application health checks, each checked like a target named by its key
+	MaxConcurrentChecks int                         // Limits how many target checks run at once (default unlimited)
+	MaxTargets          int                         // Caps discovered and API-added targets; configured targets always run (default unlimited)
+	MaxHistory          int                         // Response samples kept in memory per target for ErrorRatio, oldest dropped first (default unlimited); only these samples are capped, not results sent to sinks such as HistorySink
+	MaxQueuedEvents     int                         // Events buffered for delivery to sinks before new ones are dropped (default 100)
+	CheckTimeout        time.Duration               // Upper bound on one check of a target including retries and steps (default none)
+	Remediations        []Remediation               // Actions run when the ServerURL target keeps failing
+	Discovery           []Discoverer                // Find additional targets at runtime (see PrometheusSD)
+	DiscoveryInterval   time.Duration               // How often discovered targets are refreshed (default 1m)
+	ErrorRatio          *ErrorRatioAlert            // Alert when the share of 4xx/5xx responses rises sharply (default disabled)
+	Peers               []string                    // /status URLs of instances in other regions compared at /regions
+	PeerInterval        time.Duration               // How often peers are polled (default 30s)
+	RegionLabel         string                      // Label naming the region of an instance (default "region", falling back to the instance ID)
+	Listener            net.Listener                // Serves the HTTP endpoints instead of listening on :8080; closed by Stop
+	Reporters           []Reporter                  // Receive a self-report after every ping cycle (see RegistrarReporter)
+	StateFile           string                      // If set, target state such as the last success is persisted here across restarts
+	StartupHealthy      bool                        // Report targets healthy until their first check completes instead of 503
+	StartupGrace        time.Duration               // Report targets healthy for this long after Start while they have no success yet
+	RemovalPolicy       RemovalPolicy               // What happens to the persisted state of removed targets (default purge)
+	Watchdog            *Watchdog                   // Act on targets whose ping routine stops completing cycles (default disabled)
+	DisplayLocation     *time.Location              // Time zone of times in log messages; machine-readable times are always UTC (default local)
+	Seed                int64                       // Seeds the random source of each target so runs are reproducible (default random, logged at Start)
+	Jitter              float64                     // Delays each check by a random share of the target interval up to this fraction, e.g. 0.1 (default none)
 }
 
-// Logger interface for custom logging
+// ShutdownReason describes why the service is shutting down
+type ShutdownReason string
+
+const (
+	// ShutdownStopped means Stop was called by the embedding application
+	ShutdownStopped ShutdownReason = "stopped"
+	// ShutdownContextDone means the context passed to Start was cancelled
+	ShutdownContextDone ShutdownReason = "context_done"
+	// ShutdownMaxFailures means MaxConsecutiveFails was reached in single
+	// ServerURL mode or with ShutdownOnMaxFails
+	ShutdownMaxFailures ShutdownReason = "max_consecutive_failures"
+	// ShutdownWatchdog means the Watchdog found a stalled target and its action is WatchdogExit
+	ShutdownWatchdog ShutdownReason = "watchdog"
+)
+
+// Logger interface for custom logging. Implementations must be safe for
+// concurrent use.
 type Logger interface {
 	Info(format string, args ...interface{})
 	Error(format string, args ...interface{})
@@ -49,12 +113,41 @@ func (l *DefaultLogger) Warn(format string, args ...interface{}) {
 	fmt.Printf("[WARN] "+format+"\n", args...)
 }
 
+// quietLogger discards log output
+type quietLogger struct{}
+
+func (quietLogger) Info(format string, args ...interface{})  {}
+func (quietLogger) Error(format string, args ...interface{}) {}
+func (quietLogger) Warn(format string, args ...interface{})  {}
+
 // Service represents a ping-pong service instance
 type Service struct {
-	config          Config
-	lastPingSuccess int64
-	logger          Logger
-	server          *http.Server
+	config         Config
+	logger         Logger
+	server         *http.Server
+	shutdownOnce   sync.Once
+	done           chan struct{}
+	events         chan Event
+	dispatcherDone chan struct{}
+	slots          *scheduler    // Limits concurrent checks when MaxConcurrentChecks is set
+	reports        chan struct{} // Pending self-report request
+	saves          chan struct{} // Pending state file write
+	started        atomic.Int64  // Unix nanoseconds of Start, for StartupGrace
+	droppedEvents  atomic.Uint64 // Events dropped because the queue was full
+	watchdogStalls atomic.Uint64 // Stalled ping routines found by the Watchdog
+	ownCheckOff    atomic.Bool   // Set once OwnURL turned out to point at a target
+
+	stateMu  sync.Mutex
+	restored map[string]TargetState // Persisted state of targets not created yet
+
+	mu            sync.Mutex
+	silencedUntil time.Time
+	targets       []*target
+	allNotifiers  []Sink // Config.Notifiers and those of targets, without duplicates
+	peers         map[string]*peerState
+	ctx           context.Context // Context of the ping routines once started, for targets added later
+	changed       chan struct{}   // Closed and replaced whenever a result is recorded, waking WaitHealthy
+	remediating   sync.WaitGroup  // Running remediations, which Stop waits for
 }
 
 // NewService creates a new ping-pong service with the given configuration
@@ -65,39 +158,142 @@ func NewService(config Config) *Service {
 	if config.MaxRetries == 0 {
 		config.MaxRetries = 3
 	}
-	return &Service{
-		config: config,
-		logger: config.Logger,
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.PingInterval <= 0 {
+		config.PingInterval = 2 * time.Second
+	}
+	if config.InstanceID == "" {
+		config.InstanceID, _ = os.Hostname()
+	}
+	if config.Severity == "" {
+		config.Severity = SeverityWarning
+	}
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 1
+	}
+	if config.DiscoveryInterval <= 0 {
+		config.DiscoveryInterval = time.Minute
 	}
+	if config.PeerInterval <= 0 {
+		config.PeerInterval = 30 * time.Second
+	}
+	if config.MaxQueuedEvents <= 0 {
+		config.MaxQueuedEvents = eventQueueSize
+	}
+	if config.Seed == 0 {
+		config.Seed = rand.Int64()
+	}
+	s := &Service{
+		config:         config,
+		logger:         config.Logger,
+		done:           make(chan struct{}),
+		events:         make(chan Event, config.MaxQueuedEvents),
+		dispatcherDone: make(chan struct{}),
+		reports:        make(chan struct{}, 1),
+		saves:          make(chan struct{}, 1),
+		targets:        buildTargets(config),
+		peers:          make(map[string]*peerState),
+		restored:       make(map[string]TargetState),
+		changed:        make(chan struct{}),
+	}
+	s.allNotifiers = appendSinks(nil, config.Notifiers...)
+	s.trackNotifiers(s.targets)
+	if config.MaxConcurrentChecks > 0 {
+		s.slots = newScheduler(config.MaxConcurrentChecks)
+	}
+	s.loadState()
+	return s
 }
 
 // Start starts the ping-pong service
 func (s *Service) Start(ctx context.Context) error {
+	s.started.Store(time.Now().UnixNano())
+
+	if s.config.Jitter > 0 {
+		s.logger.Info("Jittering checks with random seed %d", s.config.Seed)
+	}
+
+	// Flag loops between the own health check and the targets
+	s.checkOwnURL()
+
 	// Start the HTTP server
 	if err := s.startServer(); err != nil {
 		return fmt.Errorf("failed to start server: %w", err)
 	}
 
+	// Start delivering events to sinks
+	go s.dispatchEvents()
+
+	// Start sending self-reports
+	if len(s.config.Reporters) > 0 {
+		go s.runReporters()
+	}
+
+	// Start saving target state
+	if s.config.StateFile != "" {
+		go s.runStateSaver()
+	}
+
 	// Start the ping routine
 	go s.startPinging(ctx)
 
 	return nil
 }
 
+// Done returns a channel that is closed once the service has shut down,
+// either because Stop was called or because a ping routine gave up after
+// MaxConsecutiveFails (see ShutdownOnMaxFails)
+func (s *Service) Done() <-chan struct{} {
+	return s.done
+}
+
 // Stop gracefully stops the service
 func (s *Service) Stop() error {
+	s.shutdown(ShutdownStopped)
+	// Shutting down cancels running remediations; none start after this
+	s.mu.Lock()
+	s.mu.Unlock()
+	s.remediating.Wait()
+	s.saveState()
 	if s.server != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
+
+		// Give sinks a chance to receive the last events
+		select {
+		case <-s.dispatcherDone:
+		case <-ctx.Done():
+			s.logger.Warn("Timed out flushing events to sinks")
+		}
 		return s.server.Shutdown(ctx)
 	}
 	return nil
 }
 
+// shutdown runs the OnShutdown hook and closes the done channel. Only the
+// first call has any effect; concurrent callers block until the hook returns.
+func (s *Service) shutdown(reason ShutdownReason) {
+	s.shutdownOnce.Do(func() {
+		s.logger.Info("Shutting down: %s", reason)
+		if s.config.OnShutdown != nil {
+			s.config.OnShutdown(reason)
+		}
+		close(s.done)
+	})
+}
+
 // startServer starts the HTTP server for health checks
 func (s *Service) startServer() error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", s.healthCheckHandler)
+	mux.HandleFunc("/health/wait", s.healthWaitHandler)
+	mux.HandleFunc("/status", s.statusHandler)
+	mux.HandleFunc("/metrics", s.metricsHandler)
+	mux.HandleFunc("/regions", s.regionsHandler)
+	mux.HandleFunc("/api/openapi.json", s.openAPIHandler)
+	s.registerAdminAPI(mux)
 
 	s.server = &http.Server{
 		Addr:    ":8080",
@@ -105,7 +301,13 @@ func (s *Service) startServer() error {
 	}
 
 	go func() {
-		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if s.config.Listener != nil {
+			err = s.server.Serve(s.config.Listener)
+		} else {
+			err = s.server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			s.logger.Error("Server error: %v", err)
 		}
 	}()
@@ -113,81 +315,143 @@ func (s *Service) startServer() error {
 	return nil
 }
 
-// startPinging starts the ping routine
+// startPinging starts one ping routine per target, keeps discovered targets
+// up to date and shuts the service down when the context is cancelled
 func (s *Service) startPinging(ctx context.Context) {
-	ticker := time.NewTicker(s.config.PingInterval)
-	defer ticker.Stop()
-
-	consecutiveFailures := 0
+	s.mu.Lock()
+	s.ctx = ctx
+	targets := slices.Clone(s.targets)
+	s.mu.Unlock()
+	for _, t := range targets {
+		s.startTarget(ctx, t)
+	}
+	if len(s.config.Discovery) > 0 {
+		go s.discover(ctx)
+	}
+	if len(s.config.Peers) > 0 {
+		go s.pollPeers(ctx)
+	}
+	if s.config.Watchdog != nil {
+		go s.runWatchdog(ctx)
+	}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			success := s.pingServer()
-			if success {
-				consecutiveFailures = 0
-			} else {
-				consecutiveFailures++
-				if consecutiveFailures >= s.config.MaxConsecutiveFails {
-					s.logger.Error("Stopping ping routine after %d consecutive failures", s.config.MaxConsecutiveFails)
-					return
-				}
-			}
-		}
+	select {
+	case <-ctx.Done():
+		s.shutdown(ShutdownContextDone)
+	case <-s.done:
 	}
 }
 
-// pingServer attempts to ping the configured server
-func (s *Service) pingServer() bool {
-	s.logger.Info("Pinging server: %s", s.config.ServerURL)
+// recordResult stores the outcome of a ping cycle of a target, updates its
+// failure streak and emits events. It returns false once
+// MaxConsecutiveFails has been reached and the target should stop.
+func (s *Service) recordResult(t *target, result Result) bool {
+	t.mu.Lock()
+	t.lastResult = &result
+	t.lastFinished = time.Now()
+	t.checkCounts[checkKey{success: result.Success, reason: result.Reason}]++
+	lastChange := t.lastChange
+	previous, current := t.observe(result, s.config.ResetPolicy, s.config.FailureThreshold)
+	if previous == StateUnknown && current == StateDown && s.inStartupGrace() {
+		// Still starting up: announce the outage once StartupGrace has elapsed
+		t.state, t.lastChange, current = StateUnknown, lastChange, StateUnknown
+	}
+	if current == StateDown && previous != StateDown {
+		t.incidentID = newEventID()
+	}
+	incidentID := t.incidentID
+	if current != StateDown {
+		t.incidentID = ""
+	}
+	consecutiveFailures := t.consecutiveFailures
+	// Health functions are never parked: /health fails while they are down,
+	// so they must be able to recover
+	keepGoing := result.Success || t.Health != nil || consecutiveFailures < s.config.MaxConsecutiveFails
+	t.mu.Unlock()
 
-	for i := 0; i < s.config.MaxRetries; i++ {
-		s.logger.Info("Attempt %d of %d", i+1, s.config.MaxRetries)
+	event := s.newEvent(t, EventResult, &result)
+	event.IncidentID = incidentID
+	s.emit(event)
+	s.notifyChanged()
+	if !result.Success {
+		s.remediate(t, event, consecutiveFailures)
+	}
+	if change := s.checkErrorRatio(t, result.Time); change != nil {
+		event := s.newEvent(t, EventErrorRatio, &result)
+		event.ErrorRatio = change
+		s.emit(event)
+	}
+	if change := s.checkContent(t, result); change != nil {
+		event := s.newEvent(t, EventContentChanged, &result)
+		event.ContentChange = change
+		s.emit(event)
+	}
+	if current != previous {
+		s.logger.Info("Target %s is now %s", t.Name, current)
+		event := s.newEvent(t, EventStateChange, &result)
+		event.State = current
+		event.PreviousState = previous
+		event.IncidentID = incidentID
+		s.emit(event)
+	}
+	return keepGoing
+}
 
-		req, err := http.NewRequest("GET", s.config.ServerURL, nil)
-		if err != nil {
-			s.logger.Error("Error creating request: %v", err)
-			continue
+// pingServer attempts to ping a target, retrying transient failures, then
+// calls the own health check according to OwnCheck
+func (s *Service) pingServer(ctx context.Context, t *target) Result {
+	s.logger.Info("Pinging server: %s", t.Name)
+	result := s.newResult(t)
+	defer func() {
+		if result.Success || s.ownCheck() == OwnCheckAlways {
+			s.callOwnHealthCheck()
 		}
+	}()
 
-		// Add custom headers
-		for key, value := range s.config.Headers {
-			req.Header.Set(key, value)
-		}
+	for i := 0; i < s.config.MaxRetries; i++ {
+		s.logger.Info("Attempt %d of %d", i+1, s.config.MaxRetries)
+		result.Attempts = i + 1
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			s.logger.Error("Error pinging server: %v", err)
-			if i < s.config.MaxRetries-1 {
-				time.Sleep(1 * time.Second)
-				continue
+		ok := s.pingOnce(ctx, t, &result)
+		s.recordStatusCode(t, time.Now(), result.StatusCode)
+		if ok {
+			atomic.StoreInt64(&t.lastPingSuccess, time.Now().Unix())
+			if t.SoftTimeout > 0 && result.Latency > t.SoftTimeout {
+				result.Degraded = true
+				s.logger.Warn("Ping of %s took %s, more than the soft timeout of %s", t.Name, result.Latency, t.SoftTimeout)
 			}
-			return false
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode == http.StatusOK {
-			atomic.StoreInt64(&s.lastPingSuccess, time.Now().Unix())
 			s.logger.Info("Ping successful!")
-			s.callOwnHealthCheck()
-			return true
+			return result
 		}
 
-		s.logger.Error("Ping failed with status code: %d", resp.StatusCode)
+		s.logger.Error("Ping failed (%s): %s", result.Reason, result.Error)
+		if !s.retryable(result.Reason) {
+			s.logger.Warn("Not retrying %s failure", result.Reason)
+			break
+		}
 		if i < s.config.MaxRetries-1 {
-			time.Sleep(1 * time.Second)
-			continue
+			select {
+			case <-time.After(1 * time.Second):
+			case <-ctx.Done():
+				return result
+			}
 		}
 	}
-	return false
+	return result
+}
+
+// retryable reports whether a failure of the given class should be retried,
+// honouring the RetryOn overrides
+func (s *Service) retryable(reason FailureReason) bool {
+	if retry, ok := s.config.RetryOn[reason]; ok {
+		return retry
+	}
+	return reason.Retryable()
 }
 
 // callOwnHealthCheck calls the service's own health check endpoint
 func (s *Service) callOwnHealthCheck() {
-	if s.config.OwnURL == "" {
+	if s.config.OwnURL == "" || s.ownCheck() == OwnCheckDisabled {
 		return
 	}
 
@@ -207,16 +471,82 @@ func (s *Service) callOwnHealthCheck() {
 
 // healthCheckHandler handles health check requests
 func (s *Service) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	lastPing := atomic.LoadInt64(&s.lastPingSuccess)
-	if lastPing == 0 {
-		http.Error(w, "No successful pings yet", http.StatusServiceUnavailable)
+	if healthy, reason := s.health(); !healthy {
+		http.Error(w, reason, http.StatusServiceUnavailable)
 		return
 	}
 
-	if time.Since(time.Unix(lastPing, 0)) > 15*time.Minute {
-		http.Error(w, "Last successful ping was too long ago", http.StatusServiceUnavailable)
-		return
+	fmt.Fprintln(w, "Ping-Pong-Go Server is healthy")
+}
+
+// health reports whether the service is healthy, with a reason when it is
+// not. Every target must have been pinged successfully recently, except
+// targets still starting up (see StartupHealthy and StartupGrace).
+func (s *Service) health() (bool, string) {
+	targets := s.targetList()
+	if len(targets) == 0 {
+		return false, "No targets"
+	}
+	for _, t := range targets {
+		if healthy, reason := s.targetHealth(t); !healthy {
+			return false, s.describe(t, reason)
+		}
 	}
 
-	fmt.Fprintln(w, "Ping-Pong-Go Server is healthy")
+	return true, ""
+}
+
+// targetHealth reports whether a single target is healthy, with a reason
+// when it is not. A HealthFunc target is unhealthy as soon as it is down, as
+// the application reports its own health through it.
+func (s *Service) targetHealth(t *target) (bool, string) {
+	if t.Health != nil {
+		t.mu.Lock()
+		state := t.state
+		t.mu.Unlock()
+		if state == StateDown {
+			return false, "Health check failing"
+		}
+	}
+	lastPing := t.lastSuccess()
+	if lastPing.IsZero() {
+		if s.starting(t) {
+			return true, ""
+		}
+		return false, "No successful pings yet"
+	}
+	if time.Since(lastPing) > 15*time.Minute {
+		return false, "Last successful ping was too long ago"
+	}
+	return true, ""
+}
+
+// starting reports whether a target without a successful ping yet counts as
+// healthy under StartupHealthy or StartupGrace
+func (s *Service) starting(t *target) bool {
+	if s.config.StartupHealthy {
+		t.mu.Lock()
+		checked := t.lastResult != nil
+		t.mu.Unlock()
+		if !checked {
+			return true
+		}
+	}
+	return s.inStartupGrace()
+}
+
+// inStartupGrace reports whether the service started less than StartupGrace
+// ago
+func (s *Service) inStartupGrace() bool {
+	started := s.started.Load()
+	return s.config.StartupGrace > 0 && started != 0 && time.Since(time.Unix(0, started)) < s.config.StartupGrace
+}
+
+// describe prefixes a message with the target name when there is more than
+// one target
+func (s *Service) describe(t *target, message string) string {
+	if len(s.targetList()) > 1 {
+		return t.Name + ": " + message
+	}
+	return message
 }
@@ -2,8 +2,13 @@ package pingpong
 
 import (
 	"context"
+	"encoding/json"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -11,18 +16,25 @@ import (
 
 // TestLogger implements the Logger interface for testing
 type TestLogger struct {
+	mu        sync.Mutex
 	InfoLogs  []string
 	ErrorLogs []string
 	WarnLogs  []string
 }
 
 func (l *TestLogger) Info(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.InfoLogs = append(l.InfoLogs, format)
 }
 func (l *TestLogger) Error(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.ErrorLogs = append(l.ErrorLogs, format)
 }
 func (l *TestLogger) Warn(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.WarnLogs = append(l.WarnLogs, format)
 }
 
@@ -104,7 +116,7 @@ func TestService_HealthCheck(t *testing.T) {
 	}
 
 	// Simulate a successful ping
-	atomic.StoreInt64(&service.lastPingSuccess, time.Now().Unix())
+	atomic.StoreInt64(&service.targets[0].lastPingSuccess, time.Now().Unix())
 
 	// Create a test request
 	req := httptest.NewRequest("GET", "/health", nil)
@@ -123,3 +135,210 @@ func TestService_HealthCheck(t *testing.T) {
 		t.Errorf("Stop returned error: %v", err)
 	}
 }
+
+func TestService_Listener(t *testing.T) {
+	for _, network := range []string{"tcp", "unix"} {
+		t.Run(network, func(t *testing.T) {
+			address := "127.0.0.1:0"
+			if network == "unix" {
+				address = filepath.Join(t.TempDir(), "pingpong.sock")
+			}
+			listener, err := net.Listen(network, address)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			service := NewService(Config{ServerURL: "http://127.0.0.1:1", PingInterval: time.Hour, Listener: listener, Logger: &TestLogger{}})
+			if err := service.Start(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			defer service.Stop()
+
+			client := &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, network, listener.Addr().String())
+				},
+			}}
+			resp, err := client.Get("http://pingpong/status")
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Expected status 200 from the provided listener, got %d", resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestService_StartupHealth(t *testing.T) {
+	service := NewService(Config{ServerURL: "http://example.com", MaxConsecutiveFails: 10, StartupHealthy: true, Logger: &TestLogger{}})
+	if healthy, reason := service.health(); !healthy {
+		t.Errorf("Expected StartupHealthy to report healthy before the first check, got %q", reason)
+	}
+	service.recordResult(service.targets[0], Result{Success: false, Time: time.Now()})
+	if healthy, _ := service.health(); healthy {
+		t.Error("Expected StartupHealthy to stop applying once the first check failed")
+	}
+
+	service = NewService(Config{ServerURL: "http://example.com", MaxConsecutiveFails: 10, StartupGrace: time.Minute, Logger: &TestLogger{}})
+	if healthy, _ := service.health(); healthy {
+		t.Error("Expected the grace period to start with Start")
+	}
+	service.started.Store(time.Now().UnixNano())
+	service.recordResult(service.targets[0], Result{Success: false, Time: time.Now()})
+	if healthy, reason := service.health(); !healthy {
+		t.Errorf("Expected failures within the grace period to report healthy, got %q", reason)
+	}
+	if state := service.Status().State; state != StateUnknown {
+		t.Errorf("Expected a target failing within the grace period to stay unknown, got %s", state)
+	}
+	service.started.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+	if healthy, _ := service.health(); healthy {
+		t.Error("Expected the grace period to expire")
+	}
+
+	// A target still down once the grace period is over is announced to notifiers
+	sink := &recordingSink{}
+	service.config.Sinks = []Sink{sink}
+	service.recordResult(service.targets[0], Result{Success: false, Time: time.Now()})
+	service.shutdown(ShutdownStopped)
+	service.dispatchEvents()
+	if changes := sink.ofType(EventStateChange); len(changes) != 1 || !notifiable(changes[0], false) || changes[0].PreviousState != StateUnknown {
+		t.Errorf("Expected a notifiable change from unknown to down, got %+v", changes)
+	}
+}
+
+func TestService_OnShutdownMaxFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	reasons := make(chan ShutdownReason, 2)
+	config := Config{
+		ServerURL:           server.URL,
+		PingInterval:        10 * time.Millisecond,
+		MaxConsecutiveFails: 2,
+		MaxRetries:          1,
+		Logger:              &TestLogger{},
+		OnShutdown: func(reason ShutdownReason) {
+			reasons <- reason
+		},
+	}
+
+	service := NewService(config)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := service.Start(ctx); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	select {
+	case <-service.Done():
+	case <-ctx.Done():
+		t.Fatal("service did not shut down after consecutive failures")
+	}
+
+	if err := service.Stop(); err != nil {
+		t.Errorf("Stop returned error: %v", err)
+	}
+
+	if len(reasons) != 1 {
+		t.Fatalf("Expected OnShutdown to be called once, got %d calls", len(reasons))
+	}
+	if reason := <-reasons; reason != ShutdownMaxFailures {
+		t.Errorf("Expected reason %q, got %q", ShutdownMaxFailures, reason)
+	}
+}
+
+func TestService_Status(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := Config{
+		ServerURL:  server.URL,
+		MaxRetries: 1,
+		InstanceID: "probe-1",
+		Labels:     map[string]string{"region": "eu-west"},
+		Logger:     &TestLogger{},
+	}
+
+	service := NewService(config)
+	service.recordResult(service.targets[0], service.pingServer(context.Background(), service.targets[0]))
+
+	req := httptest.NewRequest("GET", "/status", nil)
+	w := httptest.NewRecorder()
+	service.statusHandler(w, req)
+
+	var status Status
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+	if status.InstanceID != "probe-1" || status.Labels["region"] != "eu-west" {
+		t.Errorf("Expected instance identity in status, got %+v", status)
+	}
+	if !status.Healthy || status.LastResult == nil || !status.LastResult.Success {
+		t.Errorf("Expected healthy status with successful last result, got %+v", status)
+	}
+	if status.LastResult.InstanceID != "probe-1" {
+		t.Errorf("Expected instance ID on result, got %q", status.LastResult.InstanceID)
+	}
+}
+
+// pingConfig runs a single ping cycle of the first target of a service built
+// from config
+func pingConfig(config Config) Result {
+	service := NewService(config)
+	return service.pingServer(context.Background(), service.targets[0])
+}
+
+func TestService_WaitHealthy(t *testing.T) {
+	service := NewService(Config{
+		ServerURL:           "http://example.com",
+		Targets:             []Target{{Name: "db", URL: "http://db.example.com"}},
+		MaxConsecutiveFails: 10,
+		Logger:              &TestLogger{},
+	})
+
+	// A success from before the wait does not count
+	atomic.StoreInt64(&service.targets[1].lastPingSuccess, time.Now().Unix())
+	service.recordResult(service.targets[1], Result{Success: true, Time: time.Now()})
+	w := httptest.NewRecorder()
+	service.healthWaitHandler(w, httptest.NewRequest("GET", "/health/wait?timeout=100ms&target=db", nil))
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "No check finished") {
+		t.Errorf("Expected %d for a success recorded before the wait, got %d: %s", http.StatusServiceUnavailable, w.Code, w.Body)
+	}
+
+	waited := make(chan *httptest.ResponseRecorder)
+	go func() {
+		w := httptest.NewRecorder()
+		service.healthWaitHandler(w, httptest.NewRequest("GET", "/health/wait?timeout=5s&target=db", nil))
+		waited <- w
+	}()
+	// A check that started before the wait counts once it finishes, and a
+	// degraded target is healthy as it is everywhere else
+	started := time.Now()
+	time.Sleep(50 * time.Millisecond)
+	atomic.StoreInt64(&service.targets[1].lastPingSuccess, time.Now().Unix())
+	service.recordResult(service.targets[1], Result{Success: true, Degraded: true, Time: started})
+
+	select {
+	case w := <-waited:
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected %d once the target is healthy, got %d: %s", http.StatusOK, w.Code, w.Body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the wait to return once the target became healthy")
+	}
+
+	// The service as a whole waits for a new check of every target
+	w = httptest.NewRecorder()
+	service.healthWaitHandler(w, httptest.NewRequest("GET", "/health/wait?timeout=100ms", nil))
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "No check finished") {
+		t.Errorf("Expected %d with the reason after the timeout, got %d: %s", http.StatusServiceUnavailable, w.Code, w.Body)
+	}
+}
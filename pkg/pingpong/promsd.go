@@ -0,0 +1,93 @@
+package pingpong
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PrometheusSD discovers targets from Prometheus HTTP service discovery
+// endpoints and file_sd files, so existing discovery pipelines can feed
+// pingpong directly. Targets given as host:port are checked at
+// Scheme://host:port/Path; targets that already are URLs, as used with the
+// blackbox exporter, are checked as they are.
+type PrometheusSD struct {
+	URL     string            // HTTP SD endpoint returning a JSON list of target groups
+	Files   []string          // file_sd files in JSON or YAML; glob patterns are expanded
+	Scheme  string            // Scheme for host:port targets (default http, overridden by the __scheme__ label)
+	Path    string            // Path checked on host:port targets (default /)
+	Headers map[string]string // Sent to the HTTP SD endpoint, e.g. Authorization
+	Client  *http.Client
+}
+
+// promTargetGroup is a Prometheus SD target group
+type promTargetGroup struct {
+	Targets []string          `json:"targets" yaml:"targets"`
+	Labels  map[string]string `json:"labels" yaml:"labels"`
+}
+
+// Discover fetches the HTTP SD endpoint and reads every file_sd file
+func (p *PrometheusSD) Discover(ctx context.Context) ([]Target, error) {
+	var groups []promTargetGroup
+	if p.URL != "" {
+		fetched, err := p.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, fetched...)
+	}
+
+	for _, pattern := range p.Files {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file_sd pattern %q: %w", pattern, err)
+		}
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			var fileGroups []promTargetGroup
+			if err := yaml.Unmarshal(data, &fileGroups); err != nil {
+				return nil, fmt.Errorf("parsing %s: %w", path, err)
+			}
+			groups = append(groups, fileGroups...)
+		}
+	}
+
+	var targets []Target
+	for _, group := range groups {
+		labels := make(map[string]string)
+		for name, value := range group.Labels {
+			if !strings.HasPrefix(name, "__") {
+				labels[name] = value
+			}
+		}
+		for _, address := range group.Targets {
+			targets = append(targets, Target{URL: p.targetURL(address, group.Labels), Labels: labels})
+		}
+	}
+	return targets, nil
+}
+
+// fetch reads target groups from the HTTP SD endpoint
+func (p *PrometheusSD) fetch(ctx context.Context) ([]promTargetGroup, error) {
+	var groups []promTargetGroup
+	if err := fetchJSON(ctx, p.Client, p.URL, p.Headers, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// targetURL turns a target address into the URL to check
+func (p *PrometheusSD) targetURL(address string, labels map[string]string) string {
+	if strings.Contains(address, "://") {
+		return address
+	}
+	return backendURL(address, valueOr(labels["__scheme__"], p.Scheme), p.Path)
+}
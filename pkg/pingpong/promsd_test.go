@@ -0,0 +1,52 @@
+package pingpong
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPrometheusSD_Discover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sd" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[{"targets":["10.0.0.1:8080","https://web.example.com/healthz"],"labels":{"job":"api","__meta_zone":"a","__scheme__":"https"}}]`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "nas.yml"), []byte("- targets: [nas.lan:5000]\n  labels:\n    job: nas\n"), 0o600)
+
+	sd := &PrometheusSD{
+		URL:     server.URL,
+		Files:   []string{filepath.Join(dir, "*.yml")},
+		Path:    "health",
+		Headers: map[string]string{"Authorization": "Bearer sd"},
+	}
+	targets, err := sd.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover returned error: %v", err)
+	}
+	want := []string{"https://10.0.0.1:8080/health", "https://web.example.com/healthz", "http://nas.lan:5000/health"}
+	if len(targets) != len(want) {
+		t.Fatalf("Expected %d targets, got %+v", len(want), targets)
+	}
+	for i, target := range targets {
+		if target.URL != want[i] {
+			t.Errorf("Target %d: expected %s, got %s", i, want[i], target.URL)
+		}
+	}
+	if targets[0].Labels["job"] != "api" || targets[0].Labels["__meta_zone"] != "" {
+		t.Errorf("Expected only public labels, got %+v", targets[0].Labels)
+	}
+
+	sd.Headers = nil
+	if _, err := sd.Discover(context.Background()); err == nil {
+		t.Error("Expected an error when the SD endpoint rejects the request")
+	}
+}
@@ -0,0 +1,126 @@
+package pingpong
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// TraefikDiscovery creates a target for every server of every HTTP service
+// known to a Traefik instance, read from its API
+type TraefikDiscovery struct {
+	URL     string            // Traefik API base URL, e.g. http://traefik:8080
+	Path    string            // Path checked on each server (default /)
+	Headers map[string]string // Sent to the API, e.g. Authorization
+	Client  *http.Client
+}
+
+// traefikService is an entry of the Traefik /api/http/services response
+type traefikService struct {
+	Name         string `json:"name"`
+	Provider     string `json:"provider"`
+	LoadBalancer *struct {
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+	} `json:"loadBalancer"`
+}
+
+// Discover lists the servers behind Traefik's HTTP services
+func (d *TraefikDiscovery) Discover(ctx context.Context) ([]Target, error) {
+	var services []traefikService
+	if err := fetchJSON(ctx, d.Client, strings.TrimSuffix(d.URL, "/")+"/api/http/services", d.Headers, &services); err != nil {
+		return nil, err
+	}
+
+	var targets []Target
+	for _, service := range services {
+		if service.LoadBalancer == nil {
+			continue
+		}
+		for _, server := range service.LoadBalancer.Servers {
+			targets = append(targets, Target{
+				URL:    backendURL(server.URL, "", d.Path),
+				Labels: map[string]string{"service": service.Name, "provider": service.Provider},
+			})
+		}
+	}
+	return targets, nil
+}
+
+// CaddyDiscovery creates a target for every reverse proxy upstream of a
+// Caddy server, read from its admin API
+type CaddyDiscovery struct {
+	URL     string            // Caddy admin API base URL (default http://localhost:2019)
+	Scheme  string            // Scheme used to reach upstreams (default http)
+	Path    string            // Path checked on each upstream (default /)
+	Headers map[string]string // Sent to the admin API
+	Client  *http.Client
+}
+
+// Discover lists Caddy's reverse proxy upstreams
+func (d *CaddyDiscovery) Discover(ctx context.Context) ([]Target, error) {
+	var upstreams []struct {
+		Address string `json:"address"`
+	}
+	url := strings.TrimSuffix(valueOr(d.URL, "http://localhost:2019"), "/") + "/reverse_proxy/upstreams"
+	if err := fetchJSON(ctx, d.Client, url, d.Headers, &upstreams); err != nil {
+		return nil, err
+	}
+
+	var targets []Target
+	for _, upstream := range upstreams {
+		targets = append(targets, Target{
+			URL:    backendURL(upstream.Address, d.Scheme, d.Path),
+			Labels: map[string]string{"upstream": upstream.Address},
+		})
+	}
+	return targets, nil
+}
+
+// NginxDiscovery creates a target for every peer of every HTTP upstream of
+// an NGINX Plus instance, read from its API
+type NginxDiscovery struct {
+	URL        string            // NGINX Plus API base URL, e.g. http://nginx:8080/api
+	APIVersion int               // API version (default 9)
+	Scheme     string            // Scheme used to reach peers (default http)
+	Path       string            // Path checked on each peer (default /)
+	Headers    map[string]string // Sent to the API
+	Client     *http.Client
+}
+
+// Discover lists the peers of NGINX's HTTP upstreams
+func (d *NginxDiscovery) Discover(ctx context.Context) ([]Target, error) {
+	version := d.APIVersion
+	if version == 0 {
+		version = 9
+	}
+	var upstreams map[string]struct {
+		Peers []struct {
+			Server string `json:"server"`
+		} `json:"peers"`
+	}
+	url := fmt.Sprintf("%s/%d/http/upstreams", strings.TrimSuffix(d.URL, "/"), version)
+	if err := fetchJSON(ctx, d.Client, url, d.Headers, &upstreams); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(upstreams))
+	for name := range upstreams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var targets []Target
+	for _, name := range names {
+		for _, peer := range upstreams[name].Peers {
+			targets = append(targets, Target{
+				URL:    backendURL(peer.Server, d.Scheme, d.Path),
+				Labels: map[string]string{"upstream": name},
+			})
+		}
+	}
+	return targets, nil
+}
@@ -0,0 +1,59 @@
+package pingpong
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProxyDiscovery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/http/services":
+			w.Write([]byte(`[{"name":"api@docker","provider":"docker","loadBalancer":{"servers":[{"url":"http://172.17.0.2:80"},{"url":"http://172.17.0.3:80/"}]}},
+				{"name":"dashboard@internal","provider":"internal"}]`))
+		case "/reverse_proxy/upstreams":
+			w.Write([]byte(`[{"address":"localhost:9000","num_requests":0,"fails":0}]`))
+		case "/api/9/http/upstreams":
+			w.Write([]byte(`{"shop":{"peers":[{"id":0,"server":"10.0.0.5:8080","name":"shop-1"}]},"auth":{"peers":[{"id":0,"server":"10.0.0.9:80"}]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		discoverer Discoverer
+		want       []string
+		label      string
+		value      string
+	}{
+		{"traefik", &TraefikDiscovery{URL: server.URL, Path: "/ping"},
+			[]string{"http://172.17.0.2:80/ping", "http://172.17.0.3:80/ping"}, "service", "api@docker"},
+		{"caddy", &CaddyDiscovery{URL: server.URL, Scheme: "https"},
+			[]string{"https://localhost:9000/"}, "upstream", "localhost:9000"},
+		{"nginx", &NginxDiscovery{URL: server.URL + "/api", Path: "healthz"},
+			[]string{"http://10.0.0.9:80/healthz", "http://10.0.0.5:8080/healthz"}, "upstream", "auth"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := tt.discoverer.Discover(context.Background())
+			if err != nil {
+				t.Fatalf("Discover returned error: %v", err)
+			}
+			if len(targets) != len(tt.want) {
+				t.Fatalf("Expected %v, got %+v", tt.want, targets)
+			}
+			for i, target := range targets {
+				if target.URL != tt.want[i] {
+					t.Errorf("Target %d: expected %s, got %s", i, tt.want[i], target.URL)
+				}
+			}
+			if targets[0].Labels[tt.label] != tt.value {
+				t.Errorf("Expected %s=%s, got %+v", tt.label, tt.value, targets[0].Labels)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package pingpong
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// PubSubSink publishes events to a Google Cloud Pub/Sub topic. Messages use
+// the target as ordering key so subscribers with message ordering enabled see
+// each target's events in order.
+type PubSubSink struct {
+	Project          string
+	Topic            string
+	TokenSource      GCPTokenSource // Defaults to GCPMetadataToken
+	StateChangesOnly bool           // Skip result events
+	Endpoint         string         // Overrides https://pubsub.googleapis.com, e.g. for a regional endpoint
+	Client           *http.Client
+
+	defaultToken GCPTokenSource // Created once, so concurrent sends share its cached token
+	tokenOnce    sync.Once
+}
+
+// pubSubMessage is a single message in a Pub/Sub publish request
+type pubSubMessage struct {
+	Data        []byte            `json:"data"`
+	Attributes  map[string]string `json:"attributes"`
+	OrderingKey string            `json:"orderingKey,omitempty"`
+}
+
+// token returns the configured token source, or the default one
+func (p *PubSubSink) token() GCPTokenSource {
+	if p.TokenSource != nil {
+		return p.TokenSource
+	}
+	p.tokenOnce.Do(func() {
+		p.defaultToken = GCPMetadataToken()
+	})
+	return p.defaultToken
+}
+
+// Send publishes the event to the configured topic
+func (p *PubSubSink) Send(ctx context.Context, event Event) error {
+	if event.Type != EventStateChange && p.StateChangesOnly {
+		return nil
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	message := pubSubMessage{
+		Data: data,
+		Attributes: map[string]string{
+			"type":        string(event.Type),
+			"target":      event.Target,
+			"instance_id": event.InstanceID,
+		},
+		OrderingKey: event.Target,
+	}
+	if event.State != "" {
+		message.Attributes["state"] = string(event.State)
+	}
+	body, err := json.Marshal(map[string][]pubSubMessage{"messages": {message}})
+	if err != nil {
+		return fmt.Errorf("failed to encode publish request: %w", err)
+	}
+
+	token, err := p.token()(ctx)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/v1/projects/%s/topics/%s:publish", valueOr(p.Endpoint, "https://pubsub.googleapis.com"), p.Project, p.Topic)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create publish request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return doRequest(p.Client, req)
+}
@@ -0,0 +1,55 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestPubSubSink(t *testing.T) {
+	var path, auth string
+	var body map[string][]pubSubMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"messageIds":["1"]}`))
+	}))
+	defer server.Close()
+
+	sink := &PubSubSink{Project: "proj", Topic: "health", TokenSource: StaticGCPToken("tok"), Endpoint: server.URL}
+	event := Event{Type: EventStateChange, Target: "http://api", State: StateDown, IncidentID: "inc-1"}
+	if err := sink.Send(context.Background(), event); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	if path != "/v1/projects/proj/topics/health:publish" || auth != "Bearer tok" {
+		t.Errorf("Unexpected request path %q or auth %q", path, auth)
+	}
+	message := body["messages"][0]
+	if message.OrderingKey != "http://api" || message.Attributes["state"] != "down" {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+	var decoded Event
+	if err := json.Unmarshal(message.Data, &decoded); err != nil || decoded.IncidentID != "inc-1" {
+		t.Errorf("Expected event as message data, got %s (%v)", message.Data, err)
+	}
+}
+
+func TestPubSubSink_DefaultTokenConcurrent(t *testing.T) {
+	sink := &PubSubSink{}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if sink.token() == nil {
+				t.Error("Expected a default token source")
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,164 @@
+package pingpong
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TargetSort is the order of targets selected by a TargetQuery
+type TargetSort string
+
+const (
+	// SortName orders targets by name
+	SortName TargetSort = "name"
+	// SortState orders targets worst state first: down, degraded, unknown, up
+	SortState TargetSort = "state"
+	// SortLatency orders targets by the latency of their last result, fastest
+	// first; targets without a result come last
+	SortLatency TargetSort = "latency"
+	// SortUptime orders targets by availability, least available first
+	SortUptime TargetSort = "uptime"
+	// SortLastChange orders targets by when they entered their current state,
+	// least recent first; targets that never changed state come last
+	SortLastChange TargetSort = "last_change"
+)
+
+// stateRank orders states for SortState
+var stateRank = map[State]int{StateDown: 0, StateDegraded: 1, StateUnknown: 2, StateUp: 3}
+
+// TargetQuery filters, orders and pages the targets listed by /status and
+// /api/targets, so large target sets stay navigable
+type TargetQuery struct {
+	States     []State    // Only targets in one of these states (default all)
+	Search     string     // Only targets whose name or URL contains this, ignoring case
+	Sort       TargetSort // Order of the targets (default configuration order)
+	Descending bool       // Reverse the order
+	Offset     int        // Targets skipped after filtering and sorting
+	Limit      int        // Maximum number of targets returned (default all)
+}
+
+// ParseTargetQuery reads a TargetQuery from the query parameters state
+// (comma-separated), search, sort (prefixed with - for descending order),
+// offset and limit
+func ParseTargetQuery(values url.Values) (TargetQuery, error) {
+	var q TargetQuery
+	for _, state := range strings.Split(values.Get("state"), ",") {
+		if state = strings.TrimSpace(state); state == "" {
+			continue
+		}
+		if _, ok := stateRank[State(state)]; !ok {
+			return q, fmt.Errorf("invalid state %q: expected up, degraded, down or unknown", state)
+		}
+		q.States = append(q.States, State(state))
+	}
+	q.Search = values.Get("search")
+	if sort := values.Get("sort"); sort != "" {
+		sort, q.Descending = strings.CutPrefix(sort, "-")
+		switch q.Sort = TargetSort(sort); q.Sort {
+		case SortName, SortState, SortLatency, SortUptime, SortLastChange:
+		default:
+			return q, fmt.Errorf("invalid sort %q: expected name, state, latency, uptime or last_change", sort)
+		}
+	}
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"offset", &q.Offset}, {"limit", &q.Limit}} {
+		if value := values.Get(param.name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return q, fmt.Errorf("invalid %s %q: expected a whole number", param.name, value)
+			}
+			*param.value = n
+		}
+	}
+	return q, nil
+}
+
+// Apply returns the targets selected by the query and the number of targets
+// matching the filters before paging
+func (q TargetQuery) Apply(targets []TargetStatus) ([]TargetStatus, int) {
+	search := strings.ToLower(q.Search)
+	selected := make([]TargetStatus, 0, len(targets))
+	for _, target := range targets {
+		if len(q.States) > 0 && !slices.Contains(q.States, target.State) {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(target.Name), search) && !strings.Contains(strings.ToLower(target.URL), search) {
+			continue
+		}
+		selected = append(selected, target)
+	}
+
+	if q.Sort != "" {
+		slices.SortStableFunc(selected, func(a, b TargetStatus) int {
+			c := q.compare(a, b)
+			if q.Descending {
+				c = -c
+			}
+			return c
+		})
+	}
+
+	total := len(selected)
+	selected = selected[min(q.Offset, total):]
+	if q.Limit > 0 && q.Limit < len(selected) {
+		selected = selected[:q.Limit]
+	}
+	return selected, total
+}
+
+// compare orders two targets by the sort key of the query. Targets missing
+// the key, such as a latency before their first check, sort last in either
+// direction.
+func (q TargetQuery) compare(a, b TargetStatus) int {
+	switch q.Sort {
+	case SortState:
+		return cmp.Compare(stateRank[a.State], stateRank[b.State])
+	case SortLatency:
+		return q.compareMissing(a.LastResult == nil, b.LastResult == nil, func() int {
+			return cmp.Compare(a.LastResult.Latency, b.LastResult.Latency)
+		})
+	case SortUptime:
+		return cmp.Compare(a.Availability, b.Availability)
+	case SortLastChange:
+		return q.compareMissing(a.LastChange == nil, b.LastChange == nil, func() int {
+			return a.LastChange.Compare(*b.LastChange)
+		})
+	}
+	return strings.Compare(a.Name, b.Name)
+}
+
+// compareMissing compares two targets with compare unless either lacks the
+// sort key, which puts the one lacking it last regardless of direction
+func (q TargetQuery) compareMissing(aMissing, bMissing bool, compare func() int) int {
+	last := 1
+	if q.Descending {
+		last = -1 // Undone by the reversal in Apply
+	}
+	switch {
+	case aMissing && bMissing:
+		return 0
+	case aMissing:
+		return last
+	case bMissing:
+		return -last
+	}
+	return compare()
+}
+
+// targetQuery parses the target query of a request, answering 400 and
+// returning false if it is invalid
+func targetQuery(w http.ResponseWriter, r *http.Request) (TargetQuery, bool) {
+	q, err := ParseTargetQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return q, false
+	}
+	return q, true
+}
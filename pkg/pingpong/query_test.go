@@ -0,0 +1,106 @@
+package pingpong
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTargetQuery_Apply(t *testing.T) {
+	now := time.Now()
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	targets := []TargetStatus{
+		{Name: "api", URL: "http://api", State: StateUp, Availability: 1, LastResult: &Result{Latency: 30 * time.Millisecond}, LastChange: at(-time.Hour)},
+		{Name: "db", URL: "http://db", State: StateDown, Availability: 0.5, LastResult: &Result{Latency: 10 * time.Millisecond}, LastChange: at(-time.Minute)},
+		{Name: "cache", URL: "http://cache", State: StateUnknown},
+		{Name: "search", URL: "http://search", State: StateDegraded, Availability: 0.9, LastResult: &Result{Latency: 20 * time.Millisecond}, LastChange: at(-time.Second)},
+	}
+	names := func(targets []TargetStatus) []string {
+		var names []string
+		for _, target := range targets {
+			names = append(names, target.Name)
+		}
+		return names
+	}
+
+	for _, tc := range []struct {
+		query string
+		names []string
+		total int
+	}{
+		{"", []string{"api", "db", "cache", "search"}, 4},
+		{"sort=name", []string{"api", "cache", "db", "search"}, 4},
+		{"sort=-name", []string{"search", "db", "cache", "api"}, 4},
+		{"sort=state", []string{"db", "search", "cache", "api"}, 4},
+		{"sort=latency", []string{"db", "search", "api", "cache"}, 4},
+		{"sort=-latency", []string{"api", "search", "db", "cache"}, 4},
+		{"sort=uptime", []string{"cache", "db", "search", "api"}, 4},
+		{"sort=-last_change", []string{"search", "db", "api", "cache"}, 4},
+		{"state=down,degraded", []string{"db", "search"}, 2},
+		{"search=A", []string{"api", "cache", "search"}, 3},
+		{"sort=name&offset=1&limit=2", []string{"cache", "db"}, 4},
+		{"offset=10", nil, 4},
+	} {
+		values, _ := url.ParseQuery(tc.query)
+		q, err := ParseTargetQuery(values)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.query, err)
+		}
+		selected, total := q.Apply(targets)
+		if got := names(selected); total != tc.total || !slices.Equal(got, tc.names) {
+			t.Errorf("%q: expected %v of %d, got %v of %d", tc.query, tc.names, tc.total, got, total)
+		}
+	}
+
+	for _, query := range []string{"state=sideways", "sort=color", "limit=-1", "offset=x"} {
+		values, _ := url.ParseQuery(query)
+		if _, err := ParseTargetQuery(values); err == nil {
+			t.Errorf("Expected an error for %q", query)
+		}
+	}
+}
+
+func TestService_TargetQueryEndpoints(t *testing.T) {
+	service := NewService(Config{
+		Targets:             []Target{{Name: "api", URL: "http://api"}, {Name: "db", URL: "http://db"}, {Name: "cache", URL: "http://cache"}},
+		MaxConsecutiveFails: 10,
+		Logger:              &TestLogger{},
+	})
+	service.recordResult(service.targets[0], Result{Success: true, Time: time.Now()})
+	service.recordResult(service.targets[1], Result{Success: false, Time: time.Now()})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", service.statusHandler)
+	service.registerAdminAPI(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/status?state=down,up&sort=-name&limit=1", nil))
+	var status Status
+	json.NewDecoder(w.Body).Decode(&status)
+	if len(status.Targets) != 1 || status.Targets[0].Name != "db" || w.Header().Get("X-Total-Count") != "2" {
+		t.Errorf("Expected db of 2 matching targets, got %+v with total %s", status.Targets, w.Header().Get("X-Total-Count"))
+	}
+	if status.State != StateDown || status.Targets[0].LastChange == nil {
+		t.Errorf("Expected the summary to cover every target and db to have changed state, got %+v", status)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, newLocalRequest("GET", "/api/targets?sort=state", nil))
+	var targets []EffectiveTarget
+	json.NewDecoder(w.Body).Decode(&targets)
+	if len(targets) != 3 || targets[0].Name != "db" || targets[1].Name != "cache" || targets[2].Name != "api" {
+		t.Errorf("Expected targets worst state first, got %+v", targets)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/status?sort=color", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid sort, got %d", w.Code)
+	}
+}
@@ -0,0 +1,38 @@
+package pingpong
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand/v2"
+	"time"
+)
+
+// newRand returns the random source of the ping routine of a target. It is
+// derived from Config.Seed and the target name, so every target draws the
+// same sequence in every run with the same seed, however the goroutines of
+// the targets are scheduled.
+func (s *Service) newRand(t *target) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(t.Name))
+	return rand.New(rand.NewPCG(uint64(s.config.Seed), h.Sum64()))
+}
+
+// jitter waits for a random share of the target interval up to
+// Config.Jitter before a check, spreading out targets started together. It
+// returns false if the service shuts down while waiting.
+func (s *Service) jitter(ctx context.Context, t *target, rng *rand.Rand) bool {
+	if s.config.Jitter <= 0 {
+		return true
+	}
+	delay := time.Duration(rng.Float64() * s.config.Jitter * float64(t.Interval))
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-s.done:
+		return false
+	}
+}
@@ -0,0 +1,54 @@
+package pingpong
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestService_NewRand(t *testing.T) {
+	draw := func(seed int64, name string) [3]uint64 {
+		service := NewService(Config{Targets: []Target{{Name: name, URL: "http://" + name}}, Seed: seed, Logger: &TestLogger{}})
+		rng := service.newRand(service.targets[0])
+		return [3]uint64{rng.Uint64(), rng.Uint64(), rng.Uint64()}
+	}
+	if draw(42, "api") != draw(42, "api") {
+		t.Error("Expected the same seed to draw the same sequence")
+	}
+	if draw(42, "api") == draw(42, "db") {
+		t.Error("Expected targets to draw different sequences")
+	}
+	if draw(42, "api") == draw(43, "api") {
+		t.Error("Expected seeds to draw different sequences")
+	}
+	if service := NewService(Config{ServerURL: "http://api", Logger: &TestLogger{}}); service.config.Seed == 0 {
+		t.Error("Expected a random seed by default")
+	}
+}
+
+func TestService_Jitter(t *testing.T) {
+	service := NewService(Config{
+		Targets: []Target{{Name: "api", URL: "http://api", Interval: 100 * time.Millisecond}},
+		Seed:    1,
+		Jitter:  0.5,
+		Logger:  &TestLogger{},
+	})
+	target := service.targets[0]
+	rng := service.newRand(target)
+	expected := time.Duration(service.newRand(target).Float64() * 0.5 * float64(target.Interval))
+
+	start := time.Now()
+	if !service.jitter(context.Background(), target, rng) {
+		t.Fatal("Expected the jitter to complete")
+	}
+	if elapsed := time.Since(start); elapsed < expected || elapsed > 50*time.Millisecond+expected {
+		t.Errorf("Expected a delay of %s, got %s", expected, elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	service.config.Jitter = 1
+	if service.jitter(ctx, target, rng) {
+		t.Error("Expected the jitter to be cut short by a cancelled context")
+	}
+}
@@ -0,0 +1,219 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RegionComparison compares the same targets as seen by this instance and
+// its peers in other regions. It is served by the /regions endpoint.
+type RegionComparison struct {
+	Targets []TargetComparison `json:"targets"`
+	Peers   []PeerStatus       `json:"peers"`
+}
+
+// TargetComparison is the state of one target in every region checking it
+type TargetComparison struct {
+	Target         string         `json:"target"`
+	Regions        []RegionStatus `json:"regions"`
+	RegionalOutage bool           `json:"regional_outage"` // Down in some regions but up in others
+	DownRegions    []string       `json:"down_regions,omitempty"`
+}
+
+// RegionStatus is the state of a target as seen from one region
+type RegionStatus struct {
+	Region       string        `json:"region"`
+	InstanceID   string        `json:"instance_id"`
+	State        State         `json:"state"`
+	Latency      time.Duration `json:"latency_ns"` // Latency of the last result
+	Availability float64       `json:"availability"`
+}
+
+// PeerStatus reports whether a peer could be reached
+type PeerStatus struct {
+	URL        string     `json:"url"`
+	Region     string     `json:"region,omitempty"`
+	InstanceID string     `json:"instance_id,omitempty"`
+	LastSeen   *time.Time `json:"last_seen,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// peerState is the last status fetched from a peer
+type peerState struct {
+	url      string
+	status   *Status
+	lastSeen time.Time
+	err      error
+}
+
+// pollPeers fetches the status of every peer every PeerInterval until ctx is
+// done
+func (s *Service) pollPeers(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PeerInterval)
+	defer ticker.Stop()
+	for {
+		s.refreshPeers(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// refreshPeers fetches the status of every peer. A peer that cannot be
+// reached keeps its last known status.
+func (s *Service) refreshPeers(ctx context.Context) {
+	client := &http.Client{Timeout: s.config.Timeout}
+	for _, url := range s.config.Peers {
+		var status Status
+		err := fetchJSON(ctx, client, url, nil, &status)
+		if err != nil {
+			s.logger.Warn("Error fetching status of peer %s: %v", url, err)
+		}
+
+		s.mu.Lock()
+		peer := s.peers[url]
+		if peer == nil {
+			peer = &peerState{url: url}
+			s.peers[url] = peer
+		}
+		peer.err = err
+		if err == nil {
+			peer.status = &status
+			peer.lastSeen = timestamp()
+		}
+		s.mu.Unlock()
+	}
+}
+
+// region returns the region of an instance from its labels, falling back to
+// its instance ID
+func (s *Service) region(status Status) string {
+	return valueOr(status.Labels[valueOr(s.config.RegionLabel, "region")], status.InstanceID)
+}
+
+// Regions compares the targets of this instance with those of its peers
+func (s *Service) Regions() RegionComparison {
+	statuses := []Status{s.Status()}
+	var comparison RegionComparison
+	s.mu.Lock()
+	for _, url := range s.config.Peers {
+		peer := PeerStatus{URL: url}
+		if state := s.peers[url]; state != nil {
+			if state.err != nil {
+				peer.Error = state.err.Error()
+			}
+			if state.status != nil {
+				statuses = append(statuses, *state.status)
+				peer.Region = s.region(*state.status)
+				peer.InstanceID = state.status.InstanceID
+				lastSeen := state.lastSeen
+				peer.LastSeen = &lastSeen
+			}
+		} else {
+			peer.Error = "not fetched yet"
+		}
+		comparison.Peers = append(comparison.Peers, peer)
+	}
+	s.mu.Unlock()
+
+	byTarget := make(map[string]*TargetComparison)
+	var names []string
+	for _, status := range statuses {
+		for _, target := range status.Targets {
+			c := byTarget[target.Name]
+			if c == nil {
+				c = &TargetComparison{Target: target.Name}
+				byTarget[target.Name] = c
+				names = append(names, target.Name)
+			}
+			region := RegionStatus{
+				Region:       s.region(status),
+				InstanceID:   status.InstanceID,
+				State:        target.State,
+				Availability: target.Availability,
+			}
+			if target.LastResult != nil {
+				region.Latency = target.LastResult.Latency
+			}
+			c.Regions = append(c.Regions, region)
+		}
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		c := byTarget[name]
+		up := 0
+		for _, region := range c.Regions {
+			switch region.State {
+			case StateDown:
+				c.DownRegions = append(c.DownRegions, region.Region)
+			case StateUp, StateDegraded:
+				up++
+			}
+		}
+		c.RegionalOutage = len(c.DownRegions) > 0 && up > 0
+		comparison.Targets = append(comparison.Targets, *c)
+	}
+	return comparison
+}
+
+// regionsHandler serves the region comparison as JSON
+func (s *Service) regionsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.Regions()); err != nil {
+		s.logger.Error("Error encoding region comparison: %v", err)
+	}
+}
+
+// renderRegionMetrics renders the region comparison in the Prometheus text
+// format
+func (s *Service) renderRegionMetrics(b *strings.Builder) {
+	comparison := s.Regions()
+
+	b.WriteString("# HELP pingpong_region_up Whether a target is up as seen from a region (1 up or degraded, 0 down or unknown).\n")
+	b.WriteString("# TYPE pingpong_region_up gauge\n")
+	for _, target := range comparison.Targets {
+		for _, region := range target.Regions {
+			up := 0
+			if region.State == StateUp || region.State == StateDegraded {
+				up = 1
+			}
+			fmt.Fprintf(b, "pingpong_region_up%s %d\n", formatLabels([]string{"target", target.Target, "region", region.Region}), up)
+		}
+	}
+
+	b.WriteString("# HELP pingpong_region_latency_seconds Latency of the last check of a target from a region.\n")
+	b.WriteString("# TYPE pingpong_region_latency_seconds gauge\n")
+	for _, target := range comparison.Targets {
+		for _, region := range target.Regions {
+			fmt.Fprintf(b, "pingpong_region_latency_seconds%s %g\n", formatLabels([]string{"target", target.Target, "region", region.Region}), region.Latency.Seconds())
+		}
+	}
+
+	b.WriteString("# HELP pingpong_region_availability_ratio Share of successful checks of a target from a region.\n")
+	b.WriteString("# TYPE pingpong_region_availability_ratio gauge\n")
+	for _, target := range comparison.Targets {
+		for _, region := range target.Regions {
+			fmt.Fprintf(b, "pingpong_region_availability_ratio%s %g\n", formatLabels([]string{"target", target.Target, "region", region.Region}), region.Availability)
+		}
+	}
+
+	b.WriteString("# HELP pingpong_regional_outage Whether a target is down in some regions but up in others.\n")
+	b.WriteString("# TYPE pingpong_regional_outage gauge\n")
+	for _, target := range comparison.Targets {
+		outage := 0
+		if target.RegionalOutage {
+			outage = 1
+		}
+		fmt.Fprintf(b, "pingpong_regional_outage%s %d\n", formatLabels([]string{"target", target.Target}), outage)
+	}
+}
@@ -0,0 +1,67 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegions(t *testing.T) {
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Status{
+			InstanceID: "probe-us",
+			Labels:     map[string]string{"region": "us-east"},
+			Targets: []TargetStatus{
+				{Name: "http://api", State: StateDown, Availability: 0.5, LastResult: &Result{Latency: time.Second}},
+				{Name: "http://db", State: StateUp, Availability: 1},
+			},
+		})
+	}))
+	defer peer.Close()
+
+	service := NewService(Config{
+		InstanceID: "probe-eu",
+		Labels:     map[string]string{"region": "eu-west"},
+		Targets:    []Target{{URL: "http://api"}, {URL: "http://db"}},
+		Peers:      []string{peer.URL, "http://127.0.0.1:1/status"},
+		Logger:     &TestLogger{},
+	})
+	for _, target := range service.targets {
+		service.recordResult(target, Result{Success: true, Latency: 10 * time.Millisecond, Time: time.Now()})
+	}
+	service.refreshPeers(context.Background())
+
+	comparison := service.Regions()
+	if len(comparison.Targets) != 2 {
+		t.Fatalf("Expected two targets, got %+v", comparison.Targets)
+	}
+	api := comparison.Targets[0]
+	if api.Target != "http://api" || len(api.Regions) != 2 || !api.RegionalOutage || len(api.DownRegions) != 1 || api.DownRegions[0] != "us-east" {
+		t.Errorf("Expected a regional outage of api in us-east, got %+v", api)
+	}
+	if api.Regions[0].Region != "eu-west" || api.Regions[0].Availability != 1 || api.Regions[1].Latency != time.Second {
+		t.Errorf("Unexpected regions: %+v", api.Regions)
+	}
+	if db := comparison.Targets[1]; db.RegionalOutage {
+		t.Errorf("Expected no outage of db, got %+v", db)
+	}
+	if len(comparison.Peers) != 2 || comparison.Peers[0].Region != "us-east" || comparison.Peers[1].Error == "" {
+		t.Errorf("Unexpected peers: %+v", comparison.Peers)
+	}
+
+	metrics := service.renderMetrics()
+	for _, want := range []string{
+		`pingpong_region_up{target="http://api",region="us-east"} 0`,
+		`pingpong_region_up{target="http://api",region="eu-west"} 1`,
+		`pingpong_regional_outage{target="http://api"} 1`,
+		`pingpong_regional_outage{target="http://db"} 0`,
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("Expected metrics to contain %s, got:\n%s", want, metrics)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package pingpong
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Action is something the service does to fix a failing target, such as
+// calling a restart hook or running a script
+type Action interface {
+	Run(ctx context.Context, event Event) error
+}
+
+// Remediation runs an Action once a target has failed After consecutive
+// ping cycles. Cooldown and MaxExecutions keep a remediation that does not
+// help from running in a loop.
+type Remediation struct {
+	Name          string        // Identifies the remediation in logs and events (defaults to "remediation N")
+	Action        Action        // Action to run
+	After         int           // Consecutive failures before the action runs (default FailureThreshold, should be below MaxConsecutiveFails)
+	Cooldown      time.Duration // Minimum time between two runs (default 5m)
+	MaxExecutions int           // Maximum number of runs over the lifetime of the service (0 for unlimited)
+	Timeout       time.Duration // Upper bound on one run (default 1m)
+}
+
+// RemediationOutcome describes a completed remediation run
+type RemediationOutcome struct {
+	Name     string        `json:"name"`
+	Success  bool          `json:"success"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+	Run      int           `json:"run"` // Number of this run, starting at 1
+}
+
+// remediationState tracks the runs of one remediation of a target
+type remediationState struct {
+	lastRun time.Time
+	runs    int
+	running bool
+}
+
+// after returns the number of consecutive failures before a remediation runs
+func (s *Service) after(remediation Remediation) int {
+	if remediation.After > 0 {
+		return remediation.After
+	}
+	return s.config.FailureThreshold
+}
+
+// checkRemediations warns about remediations of a target that are due no
+// earlier than MaxConsecutiveFails: by then the target is parked, or the
+// service shuts down, so they never run or their effect goes unnoticed
+func (s *Service) checkRemediations(t *target) {
+	for i, remediation := range t.Remediations {
+		if after := s.after(remediation); after >= s.config.MaxConsecutiveFails {
+			name := valueOr(remediation.Name, fmt.Sprintf("remediation %d", i+1))
+			s.logger.Warn("Remediation %s of %s runs after %d failures, but checks stop after MaxConsecutiveFails of %d",
+				name, t.Name, after, s.config.MaxConsecutiveFails)
+		}
+	}
+}
+
+// remediate starts every remediation of a target that is due after the
+// failed ping cycle reported by trigger. Remediations are tracked so that
+// Stop waits for them; none start once the service is shutting down.
+func (s *Service) remediate(t *target, trigger Event, consecutiveFailures int) {
+	for i := range t.Remediations {
+		remediation := t.Remediations[i]
+		after := s.after(remediation)
+		cooldown := remediation.Cooldown
+		if cooldown == 0 {
+			cooldown = 5 * time.Minute
+		}
+		if consecutiveFailures < after {
+			continue
+		}
+
+		t.mu.Lock()
+		state := &t.remediations[i]
+		due := !state.running &&
+			(remediation.MaxExecutions <= 0 || state.runs < remediation.MaxExecutions) &&
+			(state.lastRun.IsZero() || trigger.Result.Time.Sub(state.lastRun) >= cooldown)
+		if due {
+			state.running = true
+			state.lastRun = trigger.Result.Time
+			state.runs++
+		}
+		run := state.runs
+		t.mu.Unlock()
+		if !due {
+			continue
+		}
+
+		// Checked under s.mu so that Stop, which takes it after shutting
+		// down, waits for every remediation added before
+		s.mu.Lock()
+		select {
+		case <-s.done:
+			s.mu.Unlock()
+			t.mu.Lock()
+			t.remediations[i].running = false
+			t.mu.Unlock()
+			return
+		default:
+		}
+		s.remediating.Add(1)
+		s.mu.Unlock()
+
+		name := valueOr(remediation.Name, fmt.Sprintf("remediation %d", i+1))
+		go func() {
+			defer s.remediating.Done()
+			s.runRemediation(t, i, name, run, remediation, trigger)
+		}()
+	}
+}
+
+// runRemediation runs a remediation action with the result event that
+// triggered it and emits a remediation event with its outcome. The action
+// is cancelled when the service shuts down.
+func (s *Service) runRemediation(t *target, index int, name string, run int, remediation Remediation, trigger Event) {
+	timeout := remediation.Timeout
+	if timeout == 0 {
+		timeout = time.Minute
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	s.logger.Warn("Running remediation %s for %s (run %d)", name, t.Name, run)
+	start := time.Now()
+	err := remediation.Action.Run(ctx, trigger)
+	outcome := &RemediationOutcome{Name: name, Success: err == nil, Duration: time.Since(start), Run: run}
+	if err != nil {
+		outcome.Error = err.Error()
+		s.logger.Error("Remediation %s for %s failed: %v", name, t.Name, err)
+	} else {
+		s.logger.Info("Remediation %s for %s completed", name, t.Name)
+	}
+
+	t.mu.Lock()
+	t.remediations[index].running = false
+	t.mu.Unlock()
+
+	event := s.newEvent(t, EventRemediation, trigger.Result)
+	event.IncidentID = trigger.IncidentID
+	event.Remediation = outcome
+	s.emit(event)
+}
+
+// HTTPAction calls an HTTP endpoint, such as a restart hook, with the event
+// that triggered it as JSON body
+type HTTPAction struct {
+	URL     string
+	Method  string // Defaults to POST
+	Headers map[string]string
+	Client  *http.Client
+}
+
+// Run calls the endpoint
+func (a *HTTPAction) Run(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, valueOr(a.Method, http.MethodPost), a.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range a.Headers {
+		req.Header.Set(key, value)
+	}
+	return doRequest(a.Client, req)
+}
+
+// ExecAction runs a command. The triggering event is passed as JSON on
+// stdin and summarized in PINGPONG_* environment variables.
+type ExecAction struct {
+	Command []string // Program and arguments
+	Dir     string   // Working directory (defaults to the current one)
+}
+
+// Run runs the command and waits for it to exit
+func (a *ExecAction) Run(ctx context.Context, event Event) error {
+	if len(a.Command) == 0 {
+		return fmt.Errorf("no command configured")
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, a.Command[0], a.Command[1:]...)
+	cmd.Dir = a.Dir
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"PINGPONG_TARGET="+event.Target,
+		"PINGPONG_INSTANCE_ID="+event.InstanceID,
+		"PINGPONG_INCIDENT_ID="+event.IncidentID,
+		"PINGPONG_SEVERITY="+string(event.Severity),
+	)
+	if event.Result != nil {
+		cmd.Env = append(cmd.Env,
+			"PINGPONG_REASON="+string(event.Result.Reason),
+			"PINGPONG_STATUS_CODE="+strconv.Itoa(event.Result.StatusCode),
+		)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%w: %s", err, truncate(msg, 512))
+		}
+		return err
+	}
+	return nil
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,177 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingAction records how often it ran
+type countingAction struct {
+	mu     sync.Mutex
+	events []Event
+	err    error
+}
+
+func (a *countingAction) Run(ctx context.Context, event Event) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, event)
+	return a.err
+}
+
+func (a *countingAction) runs() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.events)
+}
+
+func TestService_RemediationLimits(t *testing.T) {
+	action := &countingAction{err: errors.New("restart hook unavailable")}
+	sink := &recordingSink{}
+	service := NewService(Config{
+		ServerURL:           "http://example.invalid",
+		MaxConsecutiveFails: 100,
+		Sinks:               []Sink{sink},
+		Logger:              &TestLogger{},
+		Remediations: []Remediation{
+			{Name: "restart", Action: action, After: 2, Cooldown: time.Minute, MaxExecutions: 2},
+		},
+	})
+	go service.dispatchEvents()
+
+	start := time.Now()
+	record := func(offset time.Duration) {
+		service.recordResult(service.targets[0], Result{Time: start.Add(offset), Reason: FailureHTTP5xx, Error: "status 503"})
+		time.Sleep(20 * time.Millisecond)
+	}
+	record(0)
+	if action.runs() != 0 {
+		t.Fatalf("Expected no remediation before After failures, got %d runs", action.runs())
+	}
+	record(time.Second)
+	record(2 * time.Second)
+	if action.runs() != 1 {
+		t.Fatalf("Expected the cooldown to hold back a second run, got %d runs", action.runs())
+	}
+	record(2 * time.Minute)
+	record(4 * time.Minute)
+	if action.runs() != 2 {
+		t.Fatalf("Expected MaxExecutions to cap runs at 2, got %d", action.runs())
+	}
+	if trigger := action.events[0]; trigger.Type != EventResult || trigger.Result.Reason != FailureHTTP5xx {
+		t.Errorf("Expected the failed result to trigger the action, got %+v", trigger)
+	}
+
+	service.shutdown(ShutdownStopped)
+	<-service.dispatcherDone
+	events := sink.ofType(EventRemediation)
+	if len(events) != 2 || events[0].Remediation.Success || events[1].Remediation.Run != 2 ||
+		!strings.HasSuffix(events[0].Summary(), "restart failed: restart hook unavailable (http_5xx: status 503)") {
+		t.Errorf("Unexpected remediation events: %+v", events)
+	}
+}
+
+func TestHTTPAction(t *testing.T) {
+	var got Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("X-Key") != "k" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	action := &HTTPAction{URL: server.URL, Headers: map[string]string{"X-Key": "k"}}
+	if err := action.Run(context.Background(), Event{Target: "api"}); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if got.Target != "api" {
+		t.Errorf("Expected event body, got %+v", got)
+	}
+}
+
+func TestExecAction(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	action := &ExecAction{Command: []string{"sh", "-c", `echo "$PINGPONG_TARGET $PINGPONG_REASON" > "$OUT"; cat >> "$OUT"`}}
+	t.Setenv("OUT", out)
+	event := Event{Target: "api", Result: &Result{Reason: FailureConnect}}
+	if err := action.Run(context.Background(), event); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	data, _ := os.ReadFile(out)
+	if !strings.HasPrefix(string(data), "api connect_error\n{") {
+		t.Errorf("Unexpected command output: %q", data)
+	}
+
+	action = &ExecAction{Command: []string{"sh", "-c", "echo boom >&2; exit 3"}}
+	if err := action.Run(context.Background(), event); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected failing command error with output, got %v", err)
+	}
+}
+
+// blockingAction runs until its context is cancelled
+type blockingAction struct {
+	started chan struct{}
+}
+
+func (a *blockingAction) Run(ctx context.Context, event Event) error {
+	close(a.started)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestService_StopWaitsForRemediations(t *testing.T) {
+	action := &blockingAction{started: make(chan struct{})}
+	sink := &recordingSink{}
+	service := NewService(Config{
+		ServerURL:           "http://example.invalid",
+		MaxConsecutiveFails: 100,
+		Sinks:               []Sink{sink},
+		Logger:              &TestLogger{},
+		Remediations:        []Remediation{{Name: "restart", Action: action, After: 1, Timeout: time.Minute}},
+	})
+	go service.dispatchEvents()
+
+	service.recordResult(service.targets[0], Result{Time: time.Now(), Reason: FailureHTTP5xx})
+	<-action.started
+	stopped := make(chan error)
+	go func() { stopped <- service.Stop() }()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Stop to cancel the running remediation and return")
+	}
+
+	<-service.dispatcherDone
+	if events := sink.ofType(EventRemediation); len(events) != 1 || events[0].Remediation.Success {
+		t.Errorf("Expected the cancelled remediation to be reported, got %+v", events)
+	}
+}
+
+func TestService_RemediationAfterMaxConsecutiveFails(t *testing.T) {
+	logger := &TestLogger{}
+	service := NewService(Config{
+		ServerURL:           "http://example.invalid",
+		MaxConsecutiveFails: 3,
+		Logger:              logger,
+		Remediations:        []Remediation{{Action: &countingAction{}, After: 3}},
+	})
+	service.checkRemediations(service.targets[0])
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if !slices.ContainsFunc(logger.WarnLogs, func(log string) bool { return strings.Contains(log, "checks stop after MaxConsecutiveFails") }) {
+		t.Errorf("Expected a warning about the remediation, got %v", logger.WarnLogs)
+	}
+}
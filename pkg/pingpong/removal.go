@@ -0,0 +1,88 @@
+package pingpong
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// RemovalPolicy controls what happens to the persisted state of a removed
+// target
+type RemovalPolicy string
+
+const (
+	// RemovalPurge forgets the state of a removed target (the default)
+	RemovalPurge RemovalPolicy = "purge"
+	// RemovalRetain keeps the state of a removed target in the state file, so
+	// it picks up its last success and result if it is added again
+	RemovalRetain RemovalPolicy = "retain"
+)
+
+// RemoveTarget stops checking a target, closes its open incident and emits a
+// decommissioned event. It returns an error if no target has the name.
+func (s *Service) RemoveTarget(name string) error {
+	s.mu.Lock()
+	i := slices.IndexFunc(s.targets, func(t *target) bool { return t.Name == name })
+	if i < 0 {
+		s.mu.Unlock()
+		return fmt.Errorf("unknown target %q", name)
+	}
+	t := s.targets[i]
+	s.targets = slices.Delete(slices.Clone(s.targets), i, i+1)
+	s.mu.Unlock()
+
+	s.logger.Info("Removed target %s", t.Name)
+	s.decommission(t)
+	return nil
+}
+
+// decommission winds down a target already taken out of s.targets: it waits
+// for its ping routine to stop, closes its open incident, emits a
+// decommissioned event and applies the RemovalPolicy to its state
+func (s *Service) decommission(t *target) {
+	s.mu.Lock()
+	cancel, stopped := t.cancel, t.stopped
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-stopped
+	}
+
+	t.mu.Lock()
+	previous := t.state
+	incidentID := t.incidentID
+	t.incidentID = ""
+	t.acknowledged = ""
+	t.mu.Unlock()
+
+	if incidentID != "" {
+		s.logger.Info("Closed incident %s of removed target %s", incidentID, t.Name)
+	}
+	event := s.newEvent(t, EventDecommissioned, nil)
+	event.PreviousState = previous
+	event.IncidentID = incidentID
+	event.notifiers = t.Notifiers
+	s.emit(event)
+
+	if s.config.RemovalPolicy == RemovalRetain {
+		state := t.persistedState()
+		s.stateMu.Lock()
+		s.restored[t.Name] = state
+		s.stateMu.Unlock()
+	}
+	s.saveState()
+}
+
+// removeTargetHandler removes the target named by ?name
+func (s *Service) removeTargetHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "Missing name", http.StatusBadRequest)
+		return
+	}
+	if err := s.RemoveTarget(name); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
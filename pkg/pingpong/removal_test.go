@@ -0,0 +1,93 @@
+package pingpong
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestService_RemoveTarget(t *testing.T) {
+	for _, policy := range []RemovalPolicy{RemovalPurge, RemovalRetain} {
+		t.Run(string(policy), func(t *testing.T) {
+			sink := &recordingSink{}
+			digested := &recordingSink{}
+			notifier := &DigestNotifier{Notifier: digested, Window: time.Hour}
+			service := NewService(Config{
+				Targets:             []Target{{Name: "api", URL: "http://api"}, {Name: "db", URL: "http://db"}},
+				MaxConsecutiveFails: 10,
+				PingInterval:        time.Hour,
+				Sinks:               []Sink{sink},
+				Notifiers:           []Sink{notifier},
+				StateFile:           filepath.Join(t.TempDir(), "state.json"),
+				RemovalPolicy:       policy,
+				Logger:              &TestLogger{},
+			})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			for _, target := range service.targetList() {
+				service.startTarget(ctx, target)
+			}
+
+			api := service.targets[0]
+			service.recordResult(api, Result{Success: true, Time: time.Now()})
+			service.recordResult(api, Result{Success: false, Time: time.Now(), Error: "refused"})
+			incidentID := api.incidentID
+
+			if err := service.RemoveTarget("nope"); err == nil {
+				t.Error("Expected an error for an unknown target")
+			}
+			if err := service.RemoveTarget("api"); err != nil {
+				t.Fatal(err)
+			}
+			if targets := service.targetList(); len(targets) != 1 || targets[0].Name != "db" {
+				t.Fatalf("Expected only db to be left, got %d targets", len(targets))
+			}
+			service.shutdown(ShutdownStopped)
+			service.dispatchEvents()
+
+			events := sink.ofType(EventDecommissioned)
+			if len(events) != 1 || events[0].IncidentID != incidentID || events[0].PreviousState != StateDown {
+				t.Fatalf("Expected a decommissioned event closing %s, got %+v", incidentID, events)
+			}
+			// The buffered down notification is delivered before the decommissioned one
+			var notified []Event
+			for _, event := range digested.events {
+				if notifiable(event, false) {
+					notified = append(notified, event)
+				}
+			}
+			if len(notified) != 2 || notified[0].State != StateDown || notified[1].Type != EventDecommissioned {
+				t.Errorf("Expected the down notification to be flushed first, got %+v", notified)
+			}
+
+			_, retained := service.restored["api"]
+			if retained != (policy == RemovalRetain) {
+				t.Errorf("Expected state retained to be %v with policy %s", !retained, policy)
+			}
+		})
+	}
+}
+
+func TestService_RemoveTargetAPI(t *testing.T) {
+	service := NewService(Config{Targets: []Target{{Name: "api", URL: "http://api"}}, Logger: &TestLogger{}})
+	mux := http.NewServeMux()
+	service.registerAdminAPI(mux)
+
+	for _, tc := range []struct {
+		query string
+		code  int
+	}{
+		{"", http.StatusBadRequest},
+		{"?name=db", http.StatusNotFound},
+		{"?name=api", http.StatusNoContent},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, newLocalRequest("DELETE", "/api/targets"+tc.query, nil))
+		if w.Code != tc.code {
+			t.Errorf("Expected %d for %q, got %d", tc.code, tc.query, w.Code)
+		}
+	}
+}
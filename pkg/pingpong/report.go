@@ -0,0 +1,112 @@
+package pingpong
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// modulePath is the module path used to find the version of pingpong
+const modulePath = "github.com/SumonRayy/ping-pong-go"
+
+// SelfReport is the structured report an instance sends about itself after
+// every ping cycle: its state, the last result of every target and its
+// version
+type SelfReport struct {
+	Status
+	Version    string    `json:"version"`
+	ReportedAt time.Time `json:"reported_at"`
+}
+
+// Reporter receives self-reports, so a central inventory always knows the
+// health of every instance. Where OwnURL merely GETs a URL, reporters get
+// the full state of the instance.
+type Reporter interface {
+	Report(ctx context.Context, report SelfReport) error
+}
+
+// RegistrarReporter posts self-reports as JSON to a registrar endpoint
+type RegistrarReporter struct {
+	URL     string
+	Token   string            // Sent as a bearer token if set
+	Headers map[string]string // Extra headers such as an API key
+	Client  *http.Client
+}
+
+// Report posts the report to the registrar URL
+func (r *RegistrarReporter) Report(ctx context.Context, report SelfReport) error {
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create report request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	}
+	for key, value := range r.Headers {
+		req.Header.Set(key, value)
+	}
+	return doRequest(r.Client, req)
+}
+
+// requestReport asks the reporting routine to send a self-report. Requests
+// made while a report is being sent are merged into the next one.
+func (s *Service) requestReport() {
+	if len(s.config.Reporters) == 0 {
+		return
+	}
+	select {
+	case s.reports <- struct{}{}:
+	default:
+	}
+}
+
+// runReporters sends a self-report to every reporter whenever one is
+// requested, until the service shuts down
+func (s *Service) runReporters() {
+	for {
+		select {
+		case <-s.reports:
+			s.report()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// report sends the current state to every reporter
+func (s *Service) report() {
+	report := SelfReport{Status: s.Status(), Version: version(), ReportedAt: timestamp()}
+	for _, reporter := range s.config.Reporters {
+		ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+		if err := reporter.Report(ctx, report); err != nil {
+			s.logger.Error("Error sending self-report to %T: %v", reporter, err)
+		}
+		cancel()
+	}
+}
+
+// version returns the version of pingpong the binary was built with
+func version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,74 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestService_Reporters(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+
+	reports := make(chan SelfReport, 10)
+	registrar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var report SelfReport
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reports <- report
+	}))
+	defer registrar.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewService(Config{
+		ServerURL:    target.URL,
+		InstanceID:   "probe-1",
+		PingInterval: 10 * time.Millisecond,
+		Listener:     listener,
+		Reporters:    []Reporter{&RegistrarReporter{URL: registrar.URL, Token: "secret"}},
+		Logger:       &TestLogger{},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := service.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer service.Stop()
+
+	select {
+	case report := <-reports:
+		if report.InstanceID != "probe-1" || report.Version == "" || report.ReportedAt.IsZero() {
+			t.Errorf("Unexpected report: %+v", report)
+		}
+		if len(report.Targets) != 1 || report.Targets[0].LastResult == nil {
+			t.Errorf("Expected the report to include the last result, got %+v", report.Targets)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("No self-report received")
+	}
+}
+
+func TestRegistrarReporter_Error(t *testing.T) {
+	registrar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer registrar.Close()
+
+	reporter := &RegistrarReporter{URL: registrar.URL, Headers: map[string]string{"X-Api-Key": "key"}}
+	if err := reporter.Report(context.Background(), SelfReport{}); err == nil {
+		t.Error("Expected an error for a rejected report")
+	}
+}
@@ -0,0 +1,68 @@
+package pingpong
+
+import "time"
+
+// ResetPolicy controls when a consecutive-failure streak is forgiven. By
+// default the first success resets the streak, which lets a single lucky 200
+// hide a flapping target.
+type ResetPolicy struct {
+	// Successes is the number of consecutive successes required to reset the
+	// streak. Defaults to 1 unless QuietPeriod is set.
+	Successes int
+	// QuietPeriod resets the streak on a success once no failure has been seen
+	// for at least this long.
+	QuietPeriod time.Duration
+}
+
+// shouldReset reports whether the failure streak should be reset after
+// successes consecutive successes, the last failure having happened at lastFailure
+func (p ResetPolicy) shouldReset(successes int, lastFailure, now time.Time) bool {
+	required := p.Successes
+	if required <= 0 && p.QuietPeriod <= 0 {
+		required = 1
+	}
+	if required > 0 && successes >= required {
+		return true
+	}
+	return p.QuietPeriod > 0 && now.Sub(lastFailure) >= p.QuietPeriod
+}
+
+// failureTracker follows the failure streak and up/down state of a target
+type failureTracker struct {
+	consecutiveFailures int
+	successStreak       int
+	lastFailure         time.Time
+	state               State
+	lastChange          time.Time // Time of the result that changed state
+}
+
+// observe updates the streaks with a result and returns the state before
+// and after it. The target goes down after threshold consecutive failures
+// and back up once the policy resets the failure streak. A target that is
+// not down is degraded while its last check was slower than the soft timeout.
+func (f *failureTracker) observe(result Result, policy ResetPolicy, threshold int) (previous, current State) {
+	if result.Success {
+		f.successStreak++
+		if f.consecutiveFailures > 0 && policy.shouldReset(f.successStreak, f.lastFailure, result.Time) {
+			f.consecutiveFailures = 0
+		}
+	} else {
+		f.successStreak = 0
+		f.lastFailure = result.Time
+		f.consecutiveFailures++
+	}
+
+	previous = f.state
+	switch {
+	case f.consecutiveFailures >= threshold:
+		f.state = StateDown
+	case f.consecutiveFailures == 0 && result.Degraded:
+		f.state = StateDegraded
+	case f.consecutiveFailures == 0:
+		f.state = StateUp
+	}
+	if f.state != previous {
+		f.lastChange = result.Time
+	}
+	return previous, f.state
+}
@@ -0,0 +1,57 @@
+package pingpong
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordResult_ResetPolicy(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		policy   ResetPolicy
+		results  []Result
+		expected int
+	}{
+		{
+			name:     "first success",
+			results:  []Result{{Time: now}, {Time: now}, {Time: now, Success: true}},
+			expected: 0,
+		},
+		{
+			name:     "not enough successes",
+			policy:   ResetPolicy{Successes: 2},
+			results:  []Result{{Time: now}, {Time: now}, {Time: now, Success: true}},
+			expected: 2,
+		},
+		{
+			name:     "enough successes",
+			policy:   ResetPolicy{Successes: 2},
+			results:  []Result{{Time: now}, {Time: now, Success: true}, {Time: now, Success: true}},
+			expected: 0,
+		},
+		{
+			name:     "within quiet period",
+			policy:   ResetPolicy{QuietPeriod: time.Minute},
+			results:  []Result{{Time: now}, {Time: now.Add(30 * time.Second), Success: true}},
+			expected: 1,
+		},
+		{
+			name:     "after quiet period",
+			policy:   ResetPolicy{QuietPeriod: time.Minute},
+			results:  []Result{{Time: now}, {Time: now.Add(30 * time.Second), Success: true}, {Time: now.Add(time.Minute), Success: true}},
+			expected: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(Config{MaxConsecutiveFails: 10, ResetPolicy: tt.policy, Logger: &TestLogger{}})
+			for _, result := range tt.results {
+				service.recordResult(service.targets[0], result)
+			}
+			if failures := service.Status().ConsecutiveFailures; failures != tt.expected {
+				t.Errorf("Expected %d consecutive failures, got %d", tt.expected, failures)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package pingpong
+
+import "time"
+
+// Result describes the outcome of a single ping cycle, including retries
+type Result struct {
+	InstanceID string            `json:"instance_id"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Target     string            `json:"target"`
+	Time       time.Time         `json:"time"`
+	Success    bool              `json:"success"`
+	StatusCode int               `json:"status_code,omitempty"`
+	Latency    time.Duration     `json:"latency_ns"` // Round-trip time of the last attempt
+	Attempts   int               `json:"attempts"`
+	Reason     FailureReason     `json:"reason,omitempty"` // Set when Success is false
+	Error      string            `json:"error,omitempty"`
+	RemoteAddr string            `json:"remote_addr,omitempty"` // Address of the last connection attempt
+	Steps      []StepResult      `json:"steps,omitempty"`       // Per-step outcome of the last attempt of a multi-step check
+	Degraded   bool              `json:"degraded,omitempty"`    // Succeeded but slower than the soft timeout
+	BodyHash   string            `json:"body_hash,omitempty"`   // SHA-256 of the response body when Target.HashBody is set
+}
+
+// StepResult describes the outcome of one step of a multi-step check
+type StepResult struct {
+	Name       string        `json:"name"`
+	Success    bool          `json:"success"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Latency    time.Duration `json:"latency_ns"`
+	Reason     FailureReason `json:"reason,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// newResult creates a Result for a target stamped with the instance identity
+func (s *Service) newResult(t *target) Result {
+	return Result{
+		InstanceID: s.config.InstanceID,
+		Labels:     t.Labels,
+		Target:     t.Name,
+		Time:       timestamp(),
+	}
+}
+
+// LastResult returns the most recent ping result of any target, if any
+func (s *Service) LastResult() (Result, bool) {
+	var last *Result
+	for _, t := range s.targetList() {
+		t.mu.Lock()
+		if t.lastResult != nil && (last == nil || t.lastResult.Time.After(last.Time)) {
+			last = t.lastResult
+		}
+		t.mu.Unlock()
+	}
+
+	if last == nil {
+		return Result{}, false
+	}
+	return *last, true
+}
@@ -0,0 +1,99 @@
+package pingpong
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// commonCause looks for attributes shared by every failed target in events,
+// such as the same host, subnet, label or failure reason, and describes them
+// as a probable common cause. skipLabel is ignored since targets were grouped
+// by it. It returns an empty string when nothing is shared.
+func commonCause(events []Event, skipLabel string) string {
+	if len(events) < 2 {
+		return ""
+	}
+
+	var causes []string
+	if host := shared(events, func(e Event) string { return targetHost(e.Target) }); host != "" {
+		causes = append(causes, "host "+host)
+	} else if subnet := shared(events, func(e Event) string { return remoteSubnet(e) }); subnet != "" {
+		causes = append(causes, "subnet "+subnet)
+	}
+
+	names := make(map[string]bool)
+	for name := range events[0].Labels {
+		if name != skipLabel {
+			names[name] = true
+		}
+	}
+	var labels []string
+	for name := range names {
+		if value := shared(events, func(e Event) string { return e.Labels[name] }); value != "" {
+			labels = append(labels, name+"="+value)
+		}
+	}
+	sort.Strings(labels)
+	causes = append(causes, labels...)
+
+	if reason := shared(events, func(e Event) string {
+		if e.Result == nil {
+			return ""
+		}
+		return string(e.Result.Reason)
+	}); reason != "" {
+		causes = append(causes, "failure reason "+reason)
+	}
+
+	if len(causes) == 0 {
+		return ""
+	}
+	return "all share " + strings.Join(causes, ", ")
+}
+
+// shared returns the value of attr if it is non-empty and the same for every event
+func shared(events []Event, attr func(Event) string) string {
+	value := attr(events[0])
+	if value == "" {
+		return ""
+	}
+	for _, event := range events[1:] {
+		if attr(event) != value {
+			return ""
+		}
+	}
+	return value
+}
+
+// targetHost returns the host name of a target URL
+func targetHost(target string) string {
+	u, err := url.Parse(target)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// remoteSubnet returns the /24 (IPv4) or /64 (IPv6) network of the address
+// the event's result connected to
+func remoteSubnet(event Event) string {
+	if event.Result == nil || event.Result.RemoteAddr == "" {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(event.Result.RemoteAddr)
+	if err != nil {
+		host = event.Result.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return ""
+	}
+	bits := 64
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), 24
+	}
+	network := net.IPNet{IP: ip.Mask(net.CIDRMask(bits, len(ip)*8)), Mask: net.CIDRMask(bits, len(ip)*8)}
+	return network.String()
+}
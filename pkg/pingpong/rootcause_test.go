@@ -0,0 +1,47 @@
+package pingpong
+
+import "testing"
+
+func TestCommonCause(t *testing.T) {
+	failed := func(target, remote string, labels map[string]string, reason FailureReason) Event {
+		return Event{Target: target, Labels: labels, Result: &Result{RemoteAddr: remote, Reason: reason}}
+	}
+
+	tests := []struct {
+		name     string
+		events   []Event
+		expected string
+	}{
+		{
+			name: "same host",
+			events: []Event{
+				failed("http://db1:8080/a", "10.0.0.1:8080", nil, FailureHTTP5xx),
+				failed("http://db1:9090/b", "10.0.0.1:9090", nil, FailureConnect),
+			},
+			expected: "all share host db1",
+		},
+		{
+			name: "same subnet and dependency",
+			events: []Event{
+				failed("http://a", "10.0.1.5:80", map[string]string{"group": "eu", "dependency": "postgres"}, FailureHTTP5xx),
+				failed("http://b", "10.0.1.9:80", map[string]string{"group": "eu", "dependency": "postgres"}, FailureHTTP5xx),
+			},
+			expected: "all share subnet 10.0.1.0/24, dependency=postgres, failure reason http_5xx",
+		},
+		{
+			name: "nothing shared",
+			events: []Event{
+				failed("http://a", "10.0.1.5:80", map[string]string{"dependency": "redis"}, FailureDNS),
+				failed("http://b", "10.0.2.9:80", map[string]string{"dependency": "postgres"}, FailureHTTP5xx),
+			},
+			expected: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if hint := commonCause(tt.events, "group"); hint != tt.expected {
+				t.Errorf("Expected hint %q, got %q", tt.expected, hint)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package pingpong
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// priorities lists severities from the most to the least urgent, the order
+// in which waiting checks get a slot
+var priorities = []Severity{SeverityCritical, SeverityWarning, SeverityInfo}
+
+// priority returns the index of severity in priorities; unknown severities
+// rank like warning
+func priority(severity Severity) int {
+	for i, p := range priorities {
+		if p == severity {
+			return i
+		}
+	}
+	return 1
+}
+
+// scheduler hands out the MaxConcurrentChecks check slots. When all slots are
+// taken, waiting checks get the next free slot by severity, so critical
+// targets keep being checked on time under overload while minor ones are
+// delayed or skipped.
+type scheduler struct {
+	mu      sync.Mutex
+	free    int
+	waiting [][]chan struct{} // Waiting checks by priority, oldest first
+	delayed []uint64          // Checks that waited for a slot, by priority
+	skipped []uint64          // Checks given up after waiting a full interval, by priority
+}
+
+// newScheduler creates a scheduler with n slots
+func newScheduler(n int) *scheduler {
+	return &scheduler{
+		free:    n,
+		waiting: make([][]chan struct{}, len(priorities)),
+		delayed: make([]uint64, len(priorities)),
+		skipped: make([]uint64, len(priorities)),
+	}
+}
+
+// acquire waits for a slot for a check of the given severity. A check that
+// waits longer than maxWait is skipped, since the next one of the same target
+// is due by then. It returns false if the check got no slot.
+func (q *scheduler) acquire(ctx context.Context, done <-chan struct{}, severity Severity, maxWait time.Duration) bool {
+	p := priority(severity)
+	q.mu.Lock()
+	if q.free > 0 {
+		q.free--
+		q.mu.Unlock()
+		return true
+	}
+	granted := make(chan struct{})
+	q.waiting[p] = append(q.waiting[p], granted)
+	q.mu.Unlock()
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	skipped := false
+	select {
+	case <-granted:
+		q.mu.Lock()
+		q.delayed[p]++
+		q.mu.Unlock()
+		return true
+	case <-timer.C:
+		skipped = true
+	case <-ctx.Done():
+	case <-done:
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, ch := range q.waiting[p] {
+		if ch == granted {
+			q.waiting[p] = append(q.waiting[p][:i], q.waiting[p][i+1:]...)
+			if skipped {
+				q.skipped[p]++
+			}
+			return false
+		}
+	}
+	// The slot was handed over while giving up; use it anyway
+	q.delayed[p]++
+	return true
+}
+
+// release frees a slot, handing it to the most urgent waiting check
+func (q *scheduler) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for p, waiting := range q.waiting {
+		if len(waiting) > 0 {
+			close(waiting[0])
+			q.waiting[p] = waiting[1:]
+			return
+		}
+	}
+	q.free++
+}
+
+// counts returns the delayed and skipped checks by severity
+func (q *scheduler) counts() (delayed, skipped map[Severity]uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delayed = make(map[Severity]uint64, len(priorities))
+	skipped = make(map[Severity]uint64, len(priorities))
+	for i, severity := range priorities {
+		delayed[severity] = q.delayed[i]
+		skipped[severity] = q.skipped[i]
+	}
+	return delayed, skipped
+}
@@ -0,0 +1,86 @@
+package pingpong
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScheduler_CriticalFirst(t *testing.T) {
+	q := newScheduler(1)
+	ctx := context.Background()
+	if !q.acquire(ctx, nil, SeverityInfo, time.Minute) {
+		t.Fatal("Expected the free slot")
+	}
+
+	// An info check queues before a critical one, but the critical one is served first
+	var mu sync.Mutex
+	var order []Severity
+	var wg sync.WaitGroup
+	for _, severity := range []Severity{SeverityInfo, SeverityCritical} {
+		wg.Add(1)
+		go func(severity Severity) {
+			defer wg.Done()
+			if q.acquire(ctx, nil, severity, time.Minute) {
+				mu.Lock()
+				order = append(order, severity)
+				mu.Unlock()
+				q.release()
+			}
+		}(severity)
+		for queued := false; !queued; {
+			time.Sleep(time.Millisecond)
+			q.mu.Lock()
+			queued = len(q.waiting[priority(severity)]) == 1
+			q.mu.Unlock()
+		}
+	}
+	q.release()
+	wg.Wait()
+
+	if len(order) != 2 || order[0] != SeverityCritical || order[1] != SeverityInfo {
+		t.Errorf("Expected critical before info, got %v", order)
+	}
+	delayed, skipped := q.counts()
+	if delayed[SeverityCritical] != 1 || delayed[SeverityInfo] != 1 || delayed[SeverityWarning] != 0 {
+		t.Errorf("Unexpected delayed counts %v", delayed)
+	}
+	if skipped[SeverityInfo] != 0 {
+		t.Errorf("Unexpected skipped counts %v", skipped)
+	}
+}
+
+func TestScheduler_Skip(t *testing.T) {
+	q := newScheduler(1)
+	ctx := context.Background()
+	q.acquire(ctx, nil, SeverityCritical, time.Minute)
+
+	if q.acquire(ctx, nil, SeverityInfo, 10*time.Millisecond) {
+		t.Fatal("Expected the check to be skipped while the slot is busy")
+	}
+	_, skipped := q.counts()
+	if skipped[SeverityInfo] != 1 {
+		t.Errorf("Expected 1 skipped info check, got %v", skipped)
+	}
+
+	// The skipped check left the queue, so the slot is free again after release
+	q.release()
+	if !q.acquire(ctx, nil, SeverityWarning, 10*time.Millisecond) {
+		t.Error("Expected the released slot")
+	}
+}
+
+func TestScheduler_Shutdown(t *testing.T) {
+	q := newScheduler(1)
+	q.acquire(context.Background(), nil, SeverityCritical, time.Minute)
+
+	done := make(chan struct{})
+	close(done)
+	if q.acquire(context.Background(), done, SeverityCritical, time.Minute) {
+		t.Error("Expected no slot after shutdown")
+	}
+	if _, skipped := q.counts(); skipped[SeverityCritical] != 0 {
+		t.Error("Expected shutdown not to count as skipped")
+	}
+}
@@ -0,0 +1,70 @@
+package pingpong
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries the HMAC signature of webhook deliveries
+const SignatureHeader = "X-Pingpong-Signature"
+
+// SignWebhook returns the signature header value for a webhook body sent at
+// the given time: "t=<unix seconds>,v1=<hex HMAC-SHA256>", with one v1 entry
+// per secret. The HMAC covers "<unix seconds>.<body>", so a captured delivery
+// cannot be replayed later with a new timestamp. Signing with both the old
+// and the new secret while a key is rotated lets receivers accept either.
+func SignWebhook(body []byte, at time.Time, secrets []string) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	parts := []string{"t=" + timestamp}
+	for _, secret := range secrets {
+		parts = append(parts, "v1="+webhookMAC(secret, timestamp, body))
+	}
+	return strings.Join(parts, ",")
+}
+
+// VerifyWebhook checks a signature header produced by SignWebhook against
+// the body, accepting a match with any of secrets. Signatures older than
+// tolerance are rejected; a tolerance of 0 skips the age check.
+func VerifyWebhook(header string, body []byte, secrets []string, tolerance time.Duration) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return errors.New("malformed webhook signature")
+	}
+	if age := time.Since(time.Unix(unix, 0)); tolerance > 0 && (age > tolerance || age < -tolerance) {
+		return fmt.Errorf("webhook signature timestamp is %s off", age.Round(time.Second))
+	}
+	for _, secret := range secrets {
+		expected := webhookMAC(secret, timestamp, body)
+		for _, signature := range signatures {
+			if hmac.Equal([]byte(signature), []byte(expected)) {
+				return nil
+			}
+		}
+	}
+	return errors.New("webhook signature does not match")
+}
+
+// webhookMAC returns the hex HMAC-SHA256 of "<timestamp>.<body>"
+func webhookMAC(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,131 @@
+package pingpong
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Simulation replays recorded results against proposed thresholds to show
+// how many alerts and shutdowns they would have caused
+type Simulation struct {
+	FailureThreshold    int           // Consecutive failed cycles before a target is considered down (default 1)
+	MaxConsecutiveFails int           // Consecutive failures before the service shuts down (0 never shuts down)
+	ResetPolicy         ResetPolicy   // Controls when the failure streak is reset
+	Window              time.Duration // Down transitions within this window of the first one count as one alert
+}
+
+// SimulationReport summarises what a Simulation would have done
+type SimulationReport struct {
+	Results   int
+	Failures  int
+	Outages   int           // Transitions to down
+	Alerts    int           // Notifications sent, after grouping outages within Window
+	Shutdowns int           // Times MaxConsecutiveFails was reached
+	Downtime  time.Duration // Time spent down, summed over targets
+	Targets   []TargetSimulation
+}
+
+// TargetSimulation is the part of a SimulationReport concerning one target
+type TargetSimulation struct {
+	Target    string
+	Results   int
+	Failures  int
+	Outages   int
+	Shutdowns int
+	Downtime  time.Duration
+}
+
+// Simulate replays history in time order. A shutdown is assumed to be
+// followed by a restart, so the target's failure streak starts over.
+func (sim Simulation) Simulate(history []Result) SimulationReport {
+	threshold := sim.FailureThreshold
+	if threshold <= 0 {
+		threshold = 1
+	}
+	history = append([]Result(nil), history...)
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Time.Before(history[j].Time)
+	})
+
+	var report SimulationReport
+	trackers := make(map[string]*failureTracker)
+	targets := make(map[string]*TargetSimulation)
+	downSince := make(map[string]time.Time)
+	lastSeen := make(map[string]time.Time)
+	var alertOpened time.Time
+	for _, result := range history {
+		tracker, ok := trackers[result.Target]
+		if !ok {
+			tracker = &failureTracker{state: StateUnknown}
+			trackers[result.Target] = tracker
+			targets[result.Target] = &TargetSimulation{Target: result.Target}
+		}
+		lastSeen[result.Target] = result.Time
+		stats := targets[result.Target]
+		stats.Results++
+		if !result.Success {
+			stats.Failures++
+		}
+
+		previous, current := tracker.observe(result, sim.ResetPolicy, threshold)
+		if current == StateDown && previous != StateDown {
+			stats.Outages++
+			downSince[result.Target] = result.Time
+			if alertOpened.IsZero() || result.Time.Sub(alertOpened) >= sim.Window {
+				report.Alerts++
+				alertOpened = result.Time
+			}
+		}
+		if previous == StateDown && current != StateDown {
+			stats.Downtime += result.Time.Sub(downSince[result.Target])
+		}
+
+		if sim.MaxConsecutiveFails > 0 && tracker.consecutiveFailures >= sim.MaxConsecutiveFails {
+			stats.Shutdowns++
+			if current == StateDown {
+				stats.Downtime += result.Time.Sub(downSince[result.Target])
+			}
+			*tracker = failureTracker{state: StateUnknown}
+		}
+	}
+
+	// Targets still down count as down until their last result
+	for name, tracker := range trackers {
+		if tracker.state == StateDown {
+			targets[name].Downtime += lastSeen[name].Sub(downSince[name])
+		}
+	}
+
+	for _, stats := range targets {
+		report.Results += stats.Results
+		report.Failures += stats.Failures
+		report.Outages += stats.Outages
+		report.Shutdowns += stats.Shutdowns
+		report.Downtime += stats.Downtime
+		report.Targets = append(report.Targets, *stats)
+	}
+	sort.Slice(report.Targets, func(i, j int) bool {
+		return report.Targets[i].Target < report.Targets[j].Target
+	})
+	return report
+}
+
+// String formats the report for the terminal
+func (r SimulationReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Replayed %d results (%d failed)\n", r.Results, r.Failures)
+	fmt.Fprintf(&b, "Outages:   %d\n", r.Outages)
+	fmt.Fprintf(&b, "Alerts:    %d\n", r.Alerts)
+	fmt.Fprintf(&b, "Shutdowns: %d\n", r.Shutdowns)
+	fmt.Fprintf(&b, "Downtime:  %s\n", r.Downtime)
+	if len(r.Targets) > 1 {
+		b.WriteString("\n")
+		for _, t := range r.Targets {
+			fmt.Fprintf(&b, "%s: %d results, %d failed, %d outages, %d shutdowns, %s down\n",
+				t.Target, t.Results, t.Failures, t.Outages, t.Shutdowns, t.Downtime)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package pingpong
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistoryRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	sink := &HistorySink{Path: path}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, ok := range []bool{true, false} {
+		result := Result{Target: "api", Time: start.Add(time.Duration(i) * time.Second), Success: ok}
+		if err := sink.Send(context.Background(), Event{Type: EventResult, Result: &result}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Only results are recorded
+	if err := sink.Send(context.Background(), Event{Type: EventStateChange, Result: &Result{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || !history[0].Success || history[1].Success || !history[1].Time.Equal(start.Add(time.Second)) {
+		t.Errorf("unexpected history: %+v", history)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var history []Result
+	add := func(target, pattern string) {
+		for i, c := range pattern {
+			history = append(history, Result{
+				Target:  target,
+				Time:    start.Add(time.Duration(i) * time.Second),
+				Success: c == '.',
+			})
+		}
+	}
+	// Two blips, then a long outage
+	add("api", "..x...xx....xxxxxx...")
+	add("db", "......x..............")
+
+	report := Simulation{FailureThreshold: 1}.Simulate(history)
+	if report.Results != 42 || report.Failures != 10 {
+		t.Errorf("unexpected totals: %+v", report)
+	}
+	if report.Outages != 4 || report.Alerts != 4 || report.Shutdowns != 0 {
+		t.Errorf("threshold 1: unexpected report %+v", report)
+	}
+
+	// A higher threshold rides out the blips
+	report = Simulation{FailureThreshold: 3}.Simulate(history)
+	if report.Outages != 1 || report.Alerts != 1 || report.Downtime != 4*time.Second {
+		t.Errorf("threshold 3: unexpected report %+v", report)
+	}
+
+	// Outages within the window are grouped into one alert
+	report = Simulation{FailureThreshold: 1, Window: 2 * time.Minute}.Simulate(history)
+	if report.Outages != 4 || report.Alerts != 1 {
+		t.Errorf("window: unexpected report %+v", report)
+	}
+
+	// The long outage reaches MaxConsecutiveFails twice
+	report = Simulation{FailureThreshold: 3, MaxConsecutiveFails: 3}.Simulate(history)
+	if report.Shutdowns != 2 {
+		t.Errorf("max fails: unexpected report %+v", report)
+	}
+	if len(report.Targets) != 2 || report.Targets[0].Target != "api" || report.Targets[0].Shutdowns != 2 {
+		t.Errorf("unexpected per-target report: %+v", report.Targets)
+	}
+}
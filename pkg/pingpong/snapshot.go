@@ -0,0 +1,286 @@
+package pingpong
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Snapshot is the operational state of a running instance: its targets with
+// their state, open incidents and counters, and the notification silence.
+// Importing it into another instance moves monitoring to a new host without
+// losing context.
+type Snapshot struct {
+	ExportedAt    time.Time        `json:"exported_at"`
+	InstanceID    string           `json:"instance_id"`
+	SilencedUntil *time.Time       `json:"silenced_until,omitempty"`
+	Targets       []TargetSnapshot `json:"targets"`
+}
+
+// TargetSnapshot is the state of one target in a Snapshot
+type TargetSnapshot struct {
+	Entry    *TargetEntry    `json:"entry,omitempty"` // Set for targets added through the admin API, which the importing instance recreates
+	State    TargetState     `json:"state"`
+	Counters *TargetCounters `json:"counters,omitempty"` // Unset for state kept for targets not created yet
+}
+
+// TargetCounters are the metric counters of a target
+type TargetCounters struct {
+	Checks    []CheckCount   `json:"checks,omitempty"`
+	Responses map[int]uint64 `json:"responses,omitempty"` // By status code, across all attempts
+	CheckTime Duration       `json:"check_time"`          // Total wall time spent checking
+}
+
+// CheckCount is the number of ping cycles of a target with one result and
+// failure reason
+type CheckCount struct {
+	Success bool          `json:"success"`
+	Reason  FailureReason `json:"reason,omitempty"`
+	Count   uint64        `json:"count"`
+}
+
+// ImportStateResponse is the response of the importState operation
+type ImportStateResponse struct {
+	Restored []string `json:"restored"` // Targets of this instance whose state was replaced
+	Added    []string `json:"added"`    // Targets recreated from their admin API entry
+	Redacted []string `json:"redacted"` // Targets whose secrets were redacted on export: added without their secret headers, or not added at all without their URL credentials
+	Pending  []string `json:"pending"`  // Targets not known yet, whose state applies once they are configured or discovered
+}
+
+// ExportState returns a snapshot of the state of the service
+func (s *Service) ExportState() Snapshot {
+	snapshot := Snapshot{ExportedAt: timestamp(), InstanceID: s.config.InstanceID}
+	s.mu.Lock()
+	if until := s.silencedUntil; until.After(snapshot.ExportedAt) {
+		snapshot.SilencedUntil = &until
+	}
+	s.mu.Unlock()
+
+	for _, t := range s.targetList() {
+		counters := t.counters()
+		snapshot.Targets = append(snapshot.Targets, TargetSnapshot{Entry: t.entry(), State: t.persistedState(), Counters: &counters})
+	}
+	s.stateMu.Lock()
+	for _, state := range s.restored {
+		snapshot.Targets = append(snapshot.Targets, TargetSnapshot{State: state})
+	}
+	s.stateMu.Unlock()
+	return snapshot
+}
+
+// ImportState applies a snapshot taken by ExportState, typically on another
+// host. The state of known targets is replaced and the snapshot counters are
+// added to theirs, replacing counters imported earlier from the same
+// instance so importing a snapshot twice counts it once. Targets added
+// through the admin API of the exporting instance are recreated without the
+// secret headers redacted on export; those whose URL credentials were
+// redacted are not, as they would fail with the placeholder. The state of
+// other unknown targets is kept until they are configured or discovered,
+// while their counters are dropped. A silence is extended to the one of the
+// snapshot.
+func (s *Service) ImportState(snapshot Snapshot) (ImportStateResponse, error) {
+	response := ImportStateResponse{Restored: []string{}, Added: []string{}, Redacted: []string{}, Pending: []string{}}
+	var entries []TargetEntry
+	var missing []TargetSnapshot
+	for _, ts := range snapshot.Targets {
+		if s.targetNamed(ts.State.Name) != nil {
+			continue
+		}
+		missing = append(missing, ts)
+		if ts.Entry == nil {
+			continue
+		}
+		if strings.Contains(ts.Entry.URL, redacted) {
+			s.logger.Warn("URL credentials of %s were redacted on export: add it again with them", ts.Entry.Name)
+			response.Redacted = append(response.Redacted, ts.Entry.Name)
+			continue
+		}
+		entry, redacted := withoutRedacted(*ts.Entry)
+		if redacted {
+			s.logger.Warn("Secret headers of %s were redacted on export: remove and add it again with them", entry.Name)
+			response.Redacted = append(response.Redacted, entry.Name)
+		}
+		entries = append(entries, entry)
+	}
+	specs, err := TargetsFile{Targets: entries}.Resolve(nil)
+	if err != nil {
+		return response, err
+	}
+
+	// Stash the state of missing targets first, so recreated targets start
+	// from it rather than checking from scratch. What was stashed before,
+	// such as by an earlier import, is put back if adding them fails.
+	s.stateMu.Lock()
+	previous := make(map[string]TargetState)
+	for _, ts := range missing {
+		if state, ok := s.restored[ts.State.Name]; ok {
+			previous[ts.State.Name] = state
+		}
+		s.restored[ts.State.Name] = ts.State
+	}
+	s.stateMu.Unlock()
+	added, err := s.AddTargets(specs)
+	if err != nil {
+		s.stateMu.Lock()
+		for _, ts := range missing {
+			if state, ok := previous[ts.State.Name]; ok {
+				s.restored[ts.State.Name] = state
+			} else {
+				delete(s.restored, ts.State.Name)
+			}
+		}
+		s.stateMu.Unlock()
+		return response, err
+	}
+	response.Added = append(response.Added, added...)
+
+	for _, ts := range snapshot.Targets {
+		t := s.targetNamed(ts.State.Name)
+		if t == nil {
+			response.Pending = append(response.Pending, ts.State.Name)
+			continue
+		}
+		if !slices.Contains(added, t.Name) {
+			t.applyState(ts.State)
+			response.Restored = append(response.Restored, t.Name)
+		}
+		if ts.Counters != nil {
+			t.importCounters(snapshot.InstanceID, *ts.Counters)
+		}
+	}
+
+	if snapshot.SilencedUntil != nil {
+		s.mu.Lock()
+		if snapshot.SilencedUntil.After(s.silencedUntil) {
+			s.silencedUntil = *snapshot.SilencedUntil
+		}
+		s.mu.Unlock()
+	}
+	s.logger.Info("Imported the state of %d targets from %s (%d added, %d pending)",
+		len(snapshot.Targets), valueOr(snapshot.InstanceID, "a snapshot"), len(response.Added), len(response.Pending))
+	s.saveState()
+	return response, nil
+}
+
+// entry returns the admin API entry recreating a target added through the
+// admin API, nil for configured and discovered targets and HealthFuncs.
+// Secret headers and URL credentials are redacted like in EffectiveConfig,
+// since snapshots end up in files and tickets.
+func (t *target) entry() *TargetEntry {
+	if t.source != "api" || t.Health != nil {
+		return nil
+	}
+	return &TargetEntry{
+		Name: t.Name,
+		URL:  redactURL(t.URL),
+		TargetSettings: TargetSettings{
+			Interval:     Duration(t.Interval),
+			Timeout:      Duration(t.Timeout),
+			SoftTimeout:  Duration(t.SoftTimeout),
+			Severity:     t.Severity,
+			ExpectedBody: t.ExpectedBody,
+			Headers:      redactHeaders(t.Headers),
+			Labels:       t.Labels,
+			HashBody:     t.HashBody,
+		},
+	}
+}
+
+// withoutRedacted drops the header values of an entry redacted by entry, so
+// the headers of the importing instance apply instead of the placeholder,
+// and reports whether the entry had any
+func withoutRedacted(entry TargetEntry) (TargetEntry, bool) {
+	redactedSecrets := false
+	headers := make(map[string]string, len(entry.Headers))
+	for name, value := range entry.Headers {
+		if value == redacted {
+			redactedSecrets = true
+			continue
+		}
+		headers[name] = value
+	}
+	entry.Headers = headers
+	return entry, redactedSecrets
+}
+
+// counters returns the metric counters of a target
+func (t *target) counters() TargetCounters {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	counters := TargetCounters{Responses: make(map[int]uint64, len(t.statusCounts)), CheckTime: Duration(t.checkTime)}
+	for key, count := range t.checkCounts {
+		counters.Checks = append(counters.Checks, CheckCount{Success: key.success, Reason: key.reason, Count: count})
+	}
+	for code, count := range t.statusCounts {
+		counters.Responses[code] = count
+	}
+	return counters
+}
+
+// importCounters adds counters imported from an instance to the ones of a
+// target, taking back the counters imported from it before
+func (t *target) importCounters(instanceID string, counters TargetCounters) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if previous, ok := t.imported[instanceID]; ok {
+		t.subtractCounters(previous)
+	}
+	t.addCounters(counters)
+	if t.imported == nil {
+		t.imported = make(map[string]TargetCounters)
+	}
+	t.imported[instanceID] = counters
+}
+
+// addCounters adds counters to the ones of a target. The caller must hold
+// t.mu.
+func (t *target) addCounters(counters TargetCounters) {
+	for _, check := range counters.Checks {
+		t.checkCounts[checkKey{success: check.Success, reason: check.Reason}] += check.Count
+	}
+	for code, count := range counters.Responses {
+		t.statusCounts[code] += count
+	}
+	t.checkTime += time.Duration(counters.CheckTime)
+}
+
+// subtractCounters takes back counters added by addCounters. The caller must
+// hold t.mu.
+func (t *target) subtractCounters(counters TargetCounters) {
+	for _, check := range counters.Checks {
+		t.checkCounts[checkKey{success: check.Success, reason: check.Reason}] -= check.Count
+	}
+	for code, count := range counters.Responses {
+		t.statusCounts[code] -= count
+	}
+	t.checkTime -= time.Duration(counters.CheckTime)
+}
+
+// exportStateHandler serves a snapshot of the service state
+func (s *Service) exportStateHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.ExportState())
+}
+
+// importStateHandler applies a snapshot from the request body
+func (s *Service) importStateHandler(w http.ResponseWriter, r *http.Request) {
+	var snapshot Snapshot
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&snapshot); err != nil {
+		http.Error(w, "Invalid snapshot: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	response, err := s.ImportState(snapshot)
+	switch {
+	case errors.Is(err, ErrLimitExceeded):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Invalid snapshot: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
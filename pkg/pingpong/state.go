@@ -0,0 +1,186 @@
+package pingpong
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// TargetState is the persisted state of a target, in the state file and in
+// snapshots. The consecutive-failure streak is left out on purpose: a process
+// that stopped at MaxConsecutiveFails would otherwise stop again on the first
+// failure after a restart or import. A target restored down starts over with
+// a streak of one, so it only comes back up once ResetPolicy allows.
+type TargetState struct {
+	Name          string    `json:"name"`
+	LastSuccess   time.Time `json:"last_success,omitempty"`
+	State         State     `json:"state"`
+	SuccessStreak int       `json:"success_streak"`
+	LastFailure   time.Time `json:"last_failure,omitempty"`
+	LastChange    time.Time `json:"last_change,omitempty"`
+	IncidentID    string    `json:"incident_id,omitempty"`
+	Acknowledged  string    `json:"acknowledged,omitempty"`
+	LastResult    *Result   `json:"last_result,omitempty"`
+	BodyHash      string    `json:"body_hash,omitempty"`
+}
+
+// savedState is the content of the state file
+type savedState struct {
+	SavedAt time.Time     `json:"saved_at"`
+	Targets []TargetState `json:"targets"`
+}
+
+// loadState reads the state file, if configured, so targets can pick up
+// where the previous run left off
+func (s *Service) loadState() {
+	if s.config.StateFile == "" {
+		return
+	}
+	data, err := os.ReadFile(s.config.StateFile)
+	if os.IsNotExist(err) {
+		return
+	}
+	var saved savedState
+	if err == nil {
+		err = json.Unmarshal(data, &saved)
+	}
+	if err != nil {
+		s.logger.Warn("Ignoring state file %s: %v", s.config.StateFile, err)
+		return
+	}
+	for _, state := range saved.Targets {
+		s.restored[state.Name] = state
+	}
+	for _, t := range s.targets {
+		s.restoreTarget(t)
+	}
+}
+
+// restoreTarget applies the persisted state of a target, if any
+func (s *Service) restoreTarget(t *target) {
+	s.stateMu.Lock()
+	state, ok := s.restored[t.Name]
+	delete(s.restored, t.Name)
+	s.stateMu.Unlock()
+	if ok {
+		t.applyState(state)
+	}
+}
+
+// applyState replaces the state of a target with a persisted one
+func (t *target) applyState(state TargetState) {
+	if !state.LastSuccess.IsZero() {
+		atomic.StoreInt64(&t.lastPingSuccess, state.LastSuccess.Unix())
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.state = state.State
+	t.consecutiveFailures = 0
+	if state.State == StateDown {
+		t.consecutiveFailures = 1
+	}
+	t.successStreak = state.SuccessStreak
+	t.lastFailure = state.LastFailure
+	t.lastChange = state.LastChange
+	t.incidentID = state.IncidentID
+	t.acknowledged = state.Acknowledged
+	t.lastResult = state.LastResult
+	t.bodyHash = state.BodyHash
+}
+
+// persistedState returns the state of a target to persist
+func (t *target) persistedState() TargetState {
+	state := TargetState{Name: t.Name, LastSuccess: t.lastSuccess()}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state.State = t.state
+	state.SuccessStreak = t.successStreak
+	state.LastFailure = t.lastFailure
+	state.LastChange = t.lastChange
+	state.IncidentID = t.incidentID
+	state.Acknowledged = t.acknowledged
+	state.LastResult = t.lastResult
+	state.BodyHash = t.bodyHash
+	return state
+}
+
+// stateSaveInterval is the shortest time between two writes of the state
+// file; results recorded in between are saved together
+const stateSaveInterval = time.Second
+
+// requestSave asks the saving routine to write the state file. Requests made
+// within stateSaveInterval of the last write are merged into the next one.
+func (s *Service) requestSave() {
+	if s.config.StateFile == "" {
+		return
+	}
+	select {
+	case s.saves <- struct{}{}:
+	default:
+	}
+}
+
+// runStateSaver writes the state file whenever it is requested, at most
+// once per stateSaveInterval, and a last time when the service shuts down
+func (s *Service) runStateSaver() {
+	for {
+		select {
+		case <-s.saves:
+			s.saveState()
+		case <-s.done:
+			s.saveState()
+			return
+		}
+		select {
+		case <-time.After(stateSaveInterval):
+		case <-s.done:
+			s.saveState()
+			return
+		}
+	}
+}
+
+// saveState writes the state of every target to the state file, replacing
+// it atomically. Restored state of targets not seen yet, such as targets
+// still to be discovered, is kept.
+func (s *Service) saveState() {
+	if s.config.StateFile == "" {
+		return
+	}
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	saved := savedState{SavedAt: timestamp()}
+	for _, t := range s.targetList() {
+		saved.Targets = append(saved.Targets, t.persistedState())
+	}
+	for _, state := range s.restored {
+		saved.Targets = append(saved.Targets, state)
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(s.config.StateFile, data)
+	}
+	if err != nil {
+		s.logger.Error("Error saving state to %s: %v", s.config.StateFile, err)
+	}
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it over path, so readers never see a partial file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
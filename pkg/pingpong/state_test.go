@@ -0,0 +1,127 @@
+package pingpong
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestService_StateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	config := Config{
+		Targets:             []Target{{Name: "api", URL: "http://api"}, {Name: "db", URL: "http://db"}},
+		MaxConsecutiveFails: 10,
+		FailureThreshold:    2,
+		StateFile:           path,
+		Logger:              &TestLogger{},
+	}
+	service := NewService(config)
+	if healthy, _ := service.health(); healthy {
+		t.Fatal("Expected a fresh service without state to be unhealthy")
+	}
+	api, db := service.targets[0], service.targets[1]
+	service.recordResult(api, Result{Success: true, Time: time.Now()})
+	atomic.StoreInt64(&api.lastPingSuccess, time.Now().Unix())
+	service.recordResult(db, Result{Success: false, Time: time.Now()})
+	service.recordResult(db, Result{Success: false, Time: time.Now()})
+	atomic.StoreInt64(&db.lastPingSuccess, time.Now().Add(-time.Minute).Unix())
+	service.saveState()
+
+	// A restarted service is healthy straight away and keeps the open incident
+	restarted := NewService(config)
+	if healthy, reason := restarted.health(); !healthy {
+		t.Errorf("Expected the restarted service to be healthy, got %q", reason)
+	}
+	status := restarted.Status()
+	if status.Targets[0].State != StateUp || status.Targets[1].State != StateDown {
+		t.Errorf("Expected target state to be restored, got %+v", status.Targets)
+	}
+	if status.Targets[1].ConsecutiveFailures != 1 {
+		t.Errorf("Expected the failure streak of a down target to start over at one, got %d", status.Targets[1].ConsecutiveFailures)
+	}
+	if status.Targets[1].IncidentID == "" || status.Targets[1].IncidentID != service.targets[1].incidentID {
+		t.Errorf("Expected the open incident to be restored, got %q", status.Targets[1].IncidentID)
+	}
+	if status.Targets[0].LastResult == nil || !status.Targets[0].LastResult.Success {
+		t.Errorf("Expected the last result to be restored, got %+v", status.Targets[0].LastResult)
+	}
+
+	// State of targets that are not configured yet is kept for later
+	restarted = NewService(Config{Targets: []Target{{Name: "api", URL: "http://api"}}, StateFile: path, Logger: &TestLogger{}})
+	restarted.saveState()
+	restarted = NewService(config)
+	if restarted.Status().Targets[1].State != StateDown {
+		t.Error("Expected the state of db to survive a run without it")
+	}
+
+	os.WriteFile(path, []byte("{"), 0o644)
+	logger := &TestLogger{}
+	NewService(Config{ServerURL: "http://api", StateFile: path, Logger: logger})
+	if len(logger.WarnLogs) != 1 {
+		t.Errorf("Expected a warning about the corrupt state file, got %v", logger.WarnLogs)
+	}
+}
+
+func TestService_StateFile_ResetPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	config := Config{
+		Targets:             []Target{{Name: "db", URL: "http://db"}},
+		MaxConsecutiveFails: 10,
+		ResetPolicy:         ResetPolicy{Successes: 2},
+		StateFile:           path,
+		Logger:              &TestLogger{},
+	}
+	service := NewService(config)
+	service.recordResult(service.targets[0], Result{Success: false, Time: time.Now()})
+	service.saveState()
+
+	// A target restored down still needs the successes the policy requires
+	restarted := NewService(config)
+	db := restarted.targets[0]
+	restarted.recordResult(db, Result{Success: true, Time: time.Now()})
+	if state := restarted.Status().Targets[0].State; state != StateDown {
+		t.Errorf("Expected the restored target to stay down after one success, got %s", state)
+	}
+	restarted.recordResult(db, Result{Success: true, Time: time.Now()})
+	if state := restarted.Status().Targets[0].State; state != StateUp {
+		t.Errorf("Expected the restored target to be up after two successes, got %s", state)
+	}
+}
+
+func TestService_StateSaver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	service := NewService(Config{Targets: []Target{{Name: "db", URL: "http://db"}}, StateFile: path, Logger: &TestLogger{}})
+	written := func(within time.Duration) bool {
+		for start := time.Now(); time.Since(start) < within; time.Sleep(5 * time.Millisecond) {
+			if _, err := os.Stat(path); err == nil {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Requests are merged into a single pending write
+	for i := 0; i < 10; i++ {
+		service.requestSave()
+	}
+	if len(service.saves) != 1 {
+		t.Errorf("Expected a single pending write, got %d", len(service.saves))
+	}
+	go service.runStateSaver()
+	if !written(time.Second) {
+		t.Fatal("Expected the state file to be written")
+	}
+
+	// The next request waits for stateSaveInterval, unless the service stops
+	os.Remove(path)
+	service.requestSave()
+	if written(100 * time.Millisecond) {
+		t.Error("Expected writes to be spaced by stateSaveInterval")
+	}
+	service.shutdown(ShutdownStopped)
+	if !written(time.Second) {
+		t.Error("Expected the state file to be written when the service shuts down")
+	}
+}
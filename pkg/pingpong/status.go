@@ -0,0 +1,155 @@
+package pingpong
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Status is the payload served by the /status endpoint. The top-level
+// state summarizes all targets: the service is down if any target is down
+// and degraded if any is degraded.
+type Status struct {
+	InstanceID          string            `json:"instance_id"`
+	Labels              map[string]string `json:"labels,omitempty"`
+	Healthy             bool              `json:"healthy"`
+	State               State             `json:"state"`
+	Reason              string            `json:"reason,omitempty"`
+	LastSuccess         *time.Time        `json:"last_success,omitempty"` // Least recent last success across targets
+	ConsecutiveFailures int               `json:"consecutive_failures"`   // Highest across targets
+	LastResult          *Result           `json:"last_result,omitempty"`  // Most recent result of any target
+	IncidentID          string            `json:"incident_id,omitempty"`  // Open incident of the first down target
+	Acknowledged        bool              `json:"acknowledged,omitempty"`
+	SilencedUntil       *time.Time        `json:"silenced_until,omitempty"`
+	Targets             []TargetStatus    `json:"targets"`
+}
+
+// TargetStatus is the state of a single target
+type TargetStatus struct {
+	Name                string            `json:"name"`
+	URL                 string            `json:"url"`
+	Labels              map[string]string `json:"labels,omitempty"`
+	State               State             `json:"state"`
+	LastSuccess         *time.Time        `json:"last_success,omitempty"`
+	LastChange          *time.Time        `json:"last_change,omitempty"` // When the target entered its current state
+	ConsecutiveFailures int               `json:"consecutive_failures"`
+	Parked              bool              `json:"parked,omitempty"` // No longer checked after reaching MaxConsecutiveFails
+	LastResult          *Result           `json:"last_result,omitempty"`
+	IncidentID          string            `json:"incident_id,omitempty"`
+	Acknowledged        bool              `json:"acknowledged,omitempty"`
+	CheckDuration       time.Duration     `json:"check_duration_ns"` // Wall time of the last check, including retries
+	Availability        float64           `json:"availability"`      // Share of successful checks since startup
+}
+
+// status returns a snapshot of the target state
+func (t *target) status() TargetStatus {
+	status := TargetStatus{
+		Name:   t.Name,
+		URL:    t.URL,
+		Labels: t.Labels,
+	}
+	if lastSuccess := t.lastSuccess(); !lastSuccess.IsZero() {
+		status.LastSuccess = &lastSuccess
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status.State = t.state
+	if !t.lastChange.IsZero() {
+		lastChange := t.lastChange
+		status.LastChange = &lastChange
+	}
+	status.ConsecutiveFailures = t.consecutiveFailures
+	status.Parked = t.parked.Load()
+	status.IncidentID = t.incidentID
+	status.Acknowledged = t.incidentID != "" && t.acknowledged == t.incidentID
+	status.CheckDuration = t.checkDuration
+	var checks, successes uint64
+	for key, count := range t.checkCounts {
+		checks += count
+		if key.success {
+			successes += count
+		}
+	}
+	if checks > 0 {
+		status.Availability = float64(successes) / float64(checks)
+	}
+	if t.lastResult != nil {
+		result := *t.lastResult
+		status.LastResult = &result
+	}
+	return status
+}
+
+// Status returns a snapshot of the service state
+func (s *Service) Status() Status {
+	healthy, reason := s.health()
+	status := Status{
+		InstanceID: s.config.InstanceID,
+		Labels:     s.config.Labels,
+		Healthy:    healthy,
+		State:      StateUnknown,
+		Reason:     reason,
+	}
+	targets := s.targetList()
+	status.Targets = make([]TargetStatus, 0, len(targets))
+
+	up := 0
+	for i, t := range targets {
+		target := t.status()
+		status.Targets = append(status.Targets, target)
+
+		if i == 0 || (status.LastSuccess != nil && (target.LastSuccess == nil || target.LastSuccess.Before(*status.LastSuccess))) {
+			status.LastSuccess = target.LastSuccess
+		}
+		status.ConsecutiveFailures = max(status.ConsecutiveFailures, target.ConsecutiveFailures)
+		if target.LastResult != nil && (status.LastResult == nil || target.LastResult.Time.After(status.LastResult.Time)) {
+			status.LastResult = target.LastResult
+		}
+		switch target.State {
+		case StateDown:
+			if status.State != StateDown {
+				status.State = StateDown
+				status.IncidentID = target.IncidentID
+				status.Acknowledged = target.Acknowledged
+			}
+		case StateDegraded:
+			if status.State != StateDown {
+				status.State = StateDegraded
+			}
+			up++
+		case StateUp:
+			up++
+		}
+	}
+	if status.State == StateUnknown && up > 0 && up == len(targets) {
+		status.State = StateUp
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Now().Before(s.silencedUntil) {
+		until := s.silencedUntil
+		status.SilencedUntil = &until
+	}
+	return status
+}
+
+// statusHandler serves the service state as JSON. The summary covers every
+// target, while the target list follows the TargetQuery of the request, with
+// the number of matching targets in X-Total-Count.
+func (s *Service) statusHandler(w http.ResponseWriter, r *http.Request) {
+	q, ok := targetQuery(w, r)
+	if !ok {
+		return
+	}
+	status := s.Status()
+	var total int
+	status.Targets, total = q.Apply(status.Targets)
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		s.logger.Error("Error encoding status: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package pingpong
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// StatuspageSink keeps Statuspage.io components in sync with target state so
+// the public status page reflects reality without manual updates
+type StatuspageSink struct {
+	PageID      string
+	APIKey      string
+	Components  map[string]string // Maps target URLs to component IDs
+	ComponentID string            // Component used for targets missing from Components
+	APIURL      string            // Defaults to https://api.statuspage.io
+	Client      *http.Client
+}
+
+// statuspageStatus maps a target state to a Statuspage component status
+func statuspageStatus(state State) string {
+	switch state {
+	case StateUp:
+		return "operational"
+	case StateDegraded:
+		return "degraded_performance"
+	case StateDown:
+		return "major_outage"
+	default:
+		return ""
+	}
+}
+
+// Send updates the component mapped to the event's target on state changes
+func (s *StatuspageSink) Send(ctx context.Context, event Event) error {
+	if event.Type != EventStateChange {
+		return nil
+	}
+	componentID, ok := s.Components[event.Target]
+	if !ok {
+		componentID = s.ComponentID
+	}
+	status := statuspageStatus(event.State)
+	if componentID == "" || status == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]map[string]string{"component": {"status": status}})
+	if err != nil {
+		return fmt.Errorf("failed to encode component update: %w", err)
+	}
+	url := fmt.Sprintf("%s/v1/pages/%s/components/%s", valueOr(s.APIURL, "https://api.statuspage.io"), s.PageID, componentID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create component update request: %w", err)
+	}
+	req.Header.Set("Authorization", "OAuth "+s.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	return doRequest(s.Client, req)
+}
@@ -0,0 +1,53 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatuspageSink(t *testing.T) {
+	updates := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.Header.Get("Authorization") != "OAuth key" {
+			t.Errorf("Unexpected %s request with auth %q", r.Method, r.Header.Get("Authorization"))
+		}
+		var body map[string]map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		updates[r.URL.Path] = body["component"]["status"]
+	}))
+	defer server.Close()
+
+	sink := &StatuspageSink{
+		PageID:      "page",
+		APIKey:      "key",
+		Components:  map[string]string{"http://api": "api-component"},
+		ComponentID: "default-component",
+		APIURL:      server.URL,
+	}
+	events := []Event{
+		{Type: EventResult, Target: "http://api"},
+		{Type: EventStateChange, Target: "http://api", State: StateDown},
+		{Type: EventStateChange, Target: "http://web", State: StateUp},
+	}
+	for _, event := range events {
+		if err := sink.Send(context.Background(), event); err != nil {
+			t.Fatalf("Send returned error: %v", err)
+		}
+	}
+
+	expected := map[string]string{
+		"/v1/pages/page/components/api-component":     "major_outage",
+		"/v1/pages/page/components/default-component": "operational",
+	}
+	if len(updates) != len(expected) {
+		t.Fatalf("Expected %d updates, got %v", len(expected), updates)
+	}
+	for path, status := range expected {
+		if updates[path] != status {
+			t.Errorf("Expected %s to be %s, got %q", path, status, updates[path])
+		}
+	}
+}
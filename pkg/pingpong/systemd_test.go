@@ -0,0 +1,115 @@
+package pingpong
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSystemdListeners(t *testing.T) {
+	// Pass two sockets the way systemd would
+	var files []*os.File
+	for i := 0; i < 2; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := l.(*net.TCPListener).File()
+		l.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+	var names []string
+	newFile := func(fd uintptr, name string) *os.File {
+		names = append(names, name)
+		return files[fd-listenFdsStart]
+	}
+
+	env := map[string]string{"LISTEN_PID": "42", "LISTEN_FDS": "2", "LISTEN_FDNAMES": "http"}
+	getenv := func(key string) string { return env[key] }
+	listeners, err := systemdListeners(42, getenv, newFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listeners) != 2 || len(names) != 2 || names[0] != "http" || names[1] != "LISTEN_FD_4" {
+		t.Fatalf("Expected 2 listeners, got %d named %v", len(listeners), names)
+	}
+
+	listener := newMultiListener(listeners)
+	go http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer listener.Close()
+	for _, l := range listeners {
+		resp, err := http.Get("http://" + l.Addr().String())
+		if err != nil {
+			t.Fatalf("Expected %s to be served: %v", l.Addr(), err)
+		}
+		resp.Body.Close()
+	}
+
+	// Sockets passed to another process are ignored
+	if listeners, err := systemdListeners(7, getenv, newFile); err != nil || listeners != nil {
+		t.Errorf("Expected no listeners for another process, got %v, %v", listeners, err)
+	}
+	env["LISTEN_FDS"] = "x"
+	if _, err := systemdListeners(42, getenv, newFile); err == nil {
+		t.Error("Expected an error for an invalid LISTEN_FDS")
+	}
+}
+
+// flakyListener fails its first Accept calls before accepting from the
+// wrapped listener
+type flakyListener struct {
+	net.Listener
+	failures atomic.Int32
+}
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	if l.failures.Add(-1) >= 0 {
+		return nil, errors.New("accept: too many open files")
+	}
+	return l.Listener.Accept()
+}
+
+func TestMultiListener_RetriesAcceptErrors(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	flaky := &flakyListener{Listener: inner}
+	flaky.failures.Store(3)
+	m := newMultiListener([]net.Listener{flaky})
+	defer m.Close()
+
+	client, err := net.Dial("tcp", inner.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	accepted := make(chan error, 1)
+	go func() {
+		conn, err := m.Accept()
+		if err == nil {
+			conn.Close()
+		}
+		accepted <- err
+	}()
+	select {
+	case err := <-accepted:
+		if err != nil {
+			t.Fatalf("Expected the connection after transient errors, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the listener to keep accepting after transient errors")
+	}
+
+	m.Close()
+	if _, err := m.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Expected net.ErrClosed after Close, got %v", err)
+	}
+}
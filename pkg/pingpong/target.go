@@ -0,0 +1,317 @@
+package pingpong
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Target is an endpoint checked on its own goroutine, so a slow or hanging
+// target cannot delay the others
+type Target struct {
+	Name         string            // Identifies the target in results, events and metrics (defaults to its URL)
+	URL          string            // URL to ping (defaults to the first step's URL)
+	Steps        []Step            // Multi-step check run instead of a GET to URL
+	ExpectedBody string            // If set, the response body must contain this string
+	Severity     Severity          // Severity attached to events about the target (defaults to Config.Severity)
+	Labels       map[string]string // Merged over Config.Labels
+	Remediations []Remediation     // Actions run when the target keeps failing
+	SoftTimeout  time.Duration     // Slower successful checks mark the target degraded (defaults to Config.SoftTimeout)
+	Timeout      time.Duration     // Hard timeout of each attempt, after which it fails (defaults to Config.Timeout)
+	Interval     time.Duration     // Time between checks (defaults to Config.PingInterval)
+	Headers      map[string]string // Merged over Config.Headers
+	Notifiers    []Sink            // Alerted about this target instead of Config.Notifiers when set
+	HashBody     *bool             // Hash the response body of the last step and emit content_changed events when it changes (nil inherits Config.HashBody)
+	Health       HealthFunc        // Application health check run instead of HTTP requests; the target needs a Name
+}
+
+// target holds the runtime state of a Target
+type target struct {
+	Target
+	lastPingSuccess int64
+	source          string             // Discovery source that found the target, empty for configured targets
+	cancel          context.CancelFunc // Stops the ping routine; guarded by Service.mu
+	stopped         chan struct{}      // Closed once the ping routine has returned; guarded by Service.mu
+	lastCycle       int64              // Unix nanoseconds of the last completed cycle, for the Watchdog
+	stalled         atomic.Bool        // The Watchdog has reported the target stalled
+	parked          atomic.Bool        // No longer checked after reaching MaxConsecutiveFails
+	healthBusy      atomic.Bool        // A call of the HealthFunc has not returned yet
+
+	mu sync.Mutex
+	failureTracker
+	lastResult      *Result
+	lastFinished    time.Time // When lastResult was recorded, as its Time is when the check started
+	incidentID      string
+	acknowledged    string
+	checkCounts     map[checkKey]uint64
+	checkDuration   time.Duration // Wall time of the last check, including retries
+	checkTime       time.Duration // Total wall time spent checking
+	remediations    []remediationState
+	statusCounts    map[int]uint64    // Responses by status code, across all attempts
+	statusSamples   []statusSample    // Recent responses for ErrorRatioAlert
+	errorRatio      float64           // Share of error responses within the ErrorRatioAlert window
+	errorRatioAlert *ErrorRatioChange // Open error ratio alert
+	historyCapped   bool              // MaxHistory was reached and logged
+	bodyHash        string            // Body hash of the last check that read one, for HashBody
+
+	// Counters imported per exporting instance, taken back on re-import
+	imported map[string]TargetCounters
+}
+
+// hashBody reports whether the response body of the target is hashed
+func (t Target) hashBody() bool {
+	return t.HashBody != nil && *t.HashBody
+}
+
+// buildTargets returns the configured targets to check: the ServerURL/Steps
+// shorthand followed by Config.Targets and Config.HealthFuncs
+func buildTargets(config Config) []*target {
+	var specs []Target
+	if config.ServerURL != "" || len(config.Steps) > 0 || (len(config.Targets) == 0 && len(config.Discovery) == 0 && len(config.HealthFuncs) == 0) {
+		specs = append(specs, Target{
+			URL:          config.ServerURL,
+			Steps:        config.Steps,
+			ExpectedBody: config.ExpectedBody,
+			Remediations: config.Remediations,
+		})
+	}
+	specs = append(specs, config.Targets...)
+	specs = append(specs, healthFuncTargets(config.HealthFuncs)...)
+
+	targets := make([]*target, 0, len(specs))
+	for _, spec := range specs {
+		targets = append(targets, newTarget(config, spec))
+	}
+	return targets
+}
+
+// newTarget creates the runtime state of a target, applying defaults from
+// config
+func newTarget(config Config, spec Target) *target {
+	if spec.URL == "" && len(spec.Steps) > 0 {
+		spec.URL = spec.Steps[0].URL
+	}
+	spec.Name = valueOr(spec.Name, spec.URL)
+	spec.Severity = Severity(valueOr(string(spec.Severity), string(config.Severity)))
+	spec.Labels = mergeLabels(config.Labels, spec.Labels)
+	if spec.SoftTimeout <= 0 {
+		spec.SoftTimeout = config.SoftTimeout
+	}
+	if spec.Timeout <= 0 {
+		spec.Timeout = config.Timeout
+	}
+	if spec.Interval <= 0 {
+		spec.Interval = config.PingInterval
+	}
+	spec.Headers = mergeLabels(config.Headers, spec.Headers)
+	if spec.HashBody == nil {
+		hashBody := config.HashBody
+		spec.HashBody = &hashBody
+	}
+	return &target{
+		Target:         spec,
+		failureTracker: failureTracker{state: StateUnknown},
+		checkCounts:    make(map[checkKey]uint64),
+		remediations:   make([]remediationState, len(spec.Remediations)),
+		statusCounts:   make(map[int]uint64),
+	}
+}
+
+// targetList returns a snapshot of the current targets
+func (s *Service) targetList() []*target {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.targets)
+}
+
+// startTarget starts the ping routine of a target. It stops when ctx is
+// cancelled or the target is removed.
+func (s *Service) startTarget(ctx context.Context, t *target) {
+	ctx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	s.mu.Lock()
+	t.cancel, t.stopped = cancel, stopped
+	s.mu.Unlock()
+	t.heartbeat(time.Now())
+	s.checkRemediations(t)
+	go func() {
+		defer close(stopped)
+		s.runTarget(ctx, t)
+	}()
+}
+
+// AddTargets starts checking targets at runtime, for example ones imported
+// through the admin API. Targets whose name or URL is already in use are
+// skipped, like TargetsFile.Merge does. It returns the names of the added
+// targets, or an error wrapping ErrLimitExceeded without adding any if they
+// would exceed MaxTargets.
+func (s *Service) AddTargets(specs []Target) ([]string, error) {
+	s.mu.Lock()
+	known := newKnownTargets()
+	for _, t := range s.targets {
+		known.add(t.Name, t.URL)
+	}
+	var added []*target
+	for _, spec := range specs {
+		t := newTarget(s.config, spec)
+		if known.has(t.Name, t.URL) {
+			continue
+		}
+		known.add(t.Name, t.URL)
+		t.source = "api"
+		added = append(added, t)
+	}
+	if room := s.roomForTargets(); room >= 0 && len(added) > room {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("%w: adding %d targets would exceed MaxTargets of %d by %d",
+			ErrLimitExceeded, len(added), s.config.MaxTargets, len(added)-room)
+	}
+	s.targets = append(s.targets, added...)
+	s.trackNotifiers(added)
+	ctx := s.ctx
+	s.mu.Unlock()
+
+	s.checkLoops(added)
+	names := make([]string, 0, len(added))
+	for _, t := range added {
+		s.logger.Info("Added target %s", t.Name)
+		s.restoreTarget(t)
+		if ctx != nil {
+			s.startTarget(ctx, t)
+		}
+		names = append(names, t.Name)
+	}
+	return names, nil
+}
+
+// targetNamed returns the target with the given name, if any
+func (s *Service) targetNamed(name string) *target {
+	for _, t := range s.targetList() {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// mergeLabels returns base overridden by extra, reusing base when there is
+// nothing to merge
+func mergeLabels(base, extra map[string]string) map[string]string {
+	if len(extra) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(extra))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range extra {
+		merged[key] = value
+	}
+	return merged
+}
+
+// lastSuccess returns the time of the target's last successful check
+func (t *target) lastSuccess() time.Time {
+	if lastPing := atomic.LoadInt64(&t.lastPingSuccess); lastPing != 0 {
+		return time.Unix(lastPing, 0).UTC()
+	}
+	return time.Time{}
+}
+
+// runTarget checks a target every Interval until the service shuts down or
+// the target is parked after MaxConsecutiveFails. Ticks that arrive while a
+// check is still running are dropped, so a slow target is checked less often
+// rather than queueing up work.
+func (s *Service) runTarget(ctx context.Context, t *target) {
+	ticker := time.NewTicker(t.Interval)
+	defer ticker.Stop()
+	rng := s.newRand(t)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if !s.jitter(ctx, t, rng) {
+				return
+			}
+			if !s.acquireSlot(ctx, t) {
+				t.heartbeat(time.Now())
+				continue // Shutting down, or skipped under overload
+			}
+			result := s.checkTarget(ctx, t)
+			s.releaseSlot()
+			if ctx.Err() != nil {
+				return // Cut short by removal or shutdown, so not a real failure
+			}
+			keepGoing := s.recordResult(t, result)
+			t.heartbeat(time.Now())
+			s.requestReport()
+			s.requestSave()
+			if !keepGoing {
+				if s.shutdownOnMaxFailures() {
+					s.logger.Error("Stopping ping routine after %d consecutive failures of %s", s.config.MaxConsecutiveFails, t.Name)
+					s.shutdown(ShutdownMaxFailures)
+					return
+				}
+				s.logger.Error("Parking %s after %d consecutive failures: it is no longer checked", t.Name, s.config.MaxConsecutiveFails)
+				t.parked.Store(true)
+				return
+			}
+		}
+	}
+}
+
+// shutdownOnMaxFailures reports whether a target reaching
+// MaxConsecutiveFails shuts the whole service down rather than being parked:
+// always with ShutdownOnMaxFails, and in the single ServerURL mode where
+// that target is all there is to monitor
+func (s *Service) shutdownOnMaxFailures() bool {
+	if s.config.ShutdownOnMaxFails {
+		return true
+	}
+	return len(s.config.Targets) == 0 && len(s.config.Discovery) == 0 && len(s.config.HealthFuncs) == 0 &&
+		len(s.targetList()) == 1
+}
+
+// checkTarget runs one check of a target, bounded by CheckTimeout, and
+// records how long it took
+func (s *Service) checkTarget(ctx context.Context, t *target) Result {
+	if s.config.CheckTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.CheckTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	result := s.pingServer(ctx, t)
+	elapsed := time.Since(start)
+
+	t.mu.Lock()
+	t.checkDuration = elapsed
+	t.checkTime += elapsed
+	t.mu.Unlock()
+	return result
+}
+
+// acquireSlot waits for a free check slot when MaxConcurrentChecks is set.
+// It returns false when the service stops first or when the check is skipped
+// because all slots stayed busy for a full interval.
+func (s *Service) acquireSlot(ctx context.Context, t *target) bool {
+	if s.slots == nil {
+		return true
+	}
+	return s.slots.acquire(ctx, s.done, t.Severity, t.Interval)
+}
+
+// releaseSlot frees a check slot taken by acquireSlot
+func (s *Service) releaseSlot() {
+	if s.slots != nil {
+		s.slots.release()
+	}
+}
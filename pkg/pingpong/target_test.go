@@ -0,0 +1,162 @@
+package pingpong
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestService_TargetIsolation(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer fast.Close()
+
+	service := NewService(Config{
+		Targets: []Target{
+			{Name: "slow", URL: slow.URL},
+			{Name: "fast", URL: fast.URL, Labels: map[string]string{"tier": "web"}},
+		},
+		PingInterval:        10 * time.Millisecond,
+		MaxRetries:          1,
+		MaxConsecutiveFails: 100,
+		CheckTimeout:        150 * time.Millisecond,
+		Logger:              &TestLogger{},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go service.startPinging(ctx)
+
+	time.Sleep(300 * time.Millisecond)
+	status := service.Status()
+	if len(status.Targets) != 2 {
+		t.Fatalf("Expected 2 targets, got %+v", status.Targets)
+	}
+	slowStatus, fastStatus := status.Targets[0], status.Targets[1]
+	if fastStatus.State != StateUp || fastStatus.LastResult == nil {
+		t.Errorf("Expected fast target to be up despite the slow one, got %+v", fastStatus)
+	}
+	if slowStatus.State != StateDown || slowStatus.LastResult.Reason != FailureDeadline {
+		t.Errorf("Expected slow target to hit the check timeout, got %+v", slowStatus)
+	}
+	if status.State != StateDown || status.Healthy {
+		t.Errorf("Expected service to be down while a target is down, got %s (healthy %v)", status.State, status.Healthy)
+	}
+
+	metrics := service.renderMetrics()
+	for _, want := range []string{
+		`pingpong_checks_total{instance_id="` + service.config.InstanceID + `",target="fast",tier="web",result="success"}`,
+		`pingpong_last_check_duration_seconds{instance_id="` + service.config.InstanceID + `",target="slow"} 0.1`,
+		`pingpong_check_duration_seconds_total{instance_id="` + service.config.InstanceID + `",target="fast"`,
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("Expected metrics to contain %s, got:\n%s", want, metrics)
+		}
+	}
+}
+
+func TestService_SoftAndHardTimeout(t *testing.T) {
+	var delay atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Duration(delay.Load())):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	service := NewService(Config{
+		Targets:             []Target{{URL: server.URL, SoftTimeout: 50 * time.Millisecond, Timeout: 200 * time.Millisecond}},
+		MaxRetries:          1,
+		MaxConsecutiveFails: 10,
+		Logger:              &TestLogger{},
+	})
+	target := service.targets[0]
+	check := func(d time.Duration) State {
+		delay.Store(int64(d))
+		service.recordResult(target, service.pingServer(context.Background(), target))
+		return service.Status().State
+	}
+
+	if state := check(0); state != StateUp {
+		t.Errorf("Expected a fast target to be up, got %s", state)
+	}
+	if state := check(100 * time.Millisecond); state != StateDegraded || !target.lastResult.Degraded {
+		t.Errorf("Expected a slow target to be degraded, got %s", state)
+	}
+	if state := check(time.Second); state != StateDown || target.lastResult.Reason != FailureConnectTimeout && target.lastResult.Reason != FailureDeadline {
+		t.Errorf("Expected a dead target to be down, got %s (%s)", state, target.lastResult.Reason)
+	}
+	if state := check(0); state != StateUp {
+		t.Errorf("Expected the target to recover, got %s", state)
+	}
+}
+
+func TestService_ParkTargetAtMaxFailures(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	var fastChecks atomic.Int64
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fastChecks.Add(1)
+	}))
+	defer fast.Close()
+
+	service := NewService(Config{
+		Targets:             []Target{{Name: "failing", URL: failing.URL}, {Name: "fast", URL: fast.URL}},
+		PingInterval:        10 * time.Millisecond,
+		MaxRetries:          1,
+		MaxConsecutiveFails: 2,
+		Watchdog:            &Watchdog{Multiple: 1, Action: WatchdogExit},
+		Logger:              &TestLogger{},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, target := range service.targets {
+		service.startTarget(ctx, target)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	select {
+	case <-service.Done():
+		t.Fatal("Expected one failing target not to shut the service down")
+	default:
+	}
+	failingStatus := service.Status().Targets[0]
+	if !failingStatus.Parked || failingStatus.State != StateDown || failingStatus.ConsecutiveFailures != 2 {
+		t.Errorf("Expected the failing target to be parked after 2 failures, got %+v", failingStatus)
+	}
+	checks := fastChecks.Load()
+	time.Sleep(50 * time.Millisecond)
+	if fastChecks.Load() == checks {
+		t.Error("Expected the other target to keep being checked")
+	}
+
+	// Parked targets are stopped on purpose, so the watchdog ignores them
+	cancel()
+	now := time.Now().Add(time.Minute)
+	service.targets[1].heartbeat(now)
+	service.checkWatchdog(context.Background(), now)
+	select {
+	case <-service.Done():
+		t.Error("Expected the watchdog to ignore the parked target")
+	default:
+	}
+
+	service.config.ShutdownOnMaxFails = true
+	if !service.shutdownOnMaxFailures() {
+		t.Error("Expected ShutdownOnMaxFails to restore the global shutdown")
+	}
+}
@@ -0,0 +1,240 @@
+package pingpong
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParseTargetList reads targets for bulk import, either as a plain list with
+// one URL per line or as CSV whose header row names the columns: url
+// (required), name, interval, timeout, soft_timeout, severity and tags. Tags
+// are key=value pairs separated by spaces or semicolons and become labels; a
+// bare tag becomes a label set to "true". Blank lines and lines starting with
+// # are ignored. Every problem found is reported, not just the first.
+func ParseTargetList(r io.Reader) ([]TargetEntry, error) {
+	var lines []string
+	var numbers []int
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+		numbers = append(numbers, number)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	var entries []TargetEntry
+	var errs []error
+	header, err := csv.NewReader(strings.NewReader(lines[0])).Read()
+	if err != nil || !isTargetHeader(header) {
+		for i, line := range lines {
+			entries = append(entries, TargetEntry{URL: line})
+			errs = append(errs, validateEntry(numbers[i], entries[i]))
+		}
+		return entries, errors.Join(errs...)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		switch column {
+		case "url", "name", "interval", "timeout", "soft_timeout", "severity", "tags":
+			columns[column] = i
+		default:
+			errs = append(errs, fmt.Errorf("line %d: unknown column %q", numbers[0], column))
+		}
+	}
+	for i, line := range lines[1:] {
+		number := numbers[i+1]
+		record, err := csv.NewReader(strings.NewReader(line)).Read()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", number, err))
+			continue
+		}
+		field := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		entry := TargetEntry{Name: field("name"), URL: field("url")}
+		entry.Severity = Severity(field("severity"))
+		entry.Labels = parseTags(field("tags"))
+		durations := []struct {
+			column string
+			value  *Duration
+		}{{"interval", &entry.Interval}, {"timeout", &entry.Timeout}, {"soft_timeout", &entry.SoftTimeout}}
+		for _, duration := range durations {
+			column, value := duration.column, duration.value
+			if raw := field(column); raw != "" {
+				d, err := ParseDuration(raw)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("line %d: %s: %w", number, column, err))
+				}
+				*value = Duration(d)
+			}
+		}
+		entries = append(entries, entry)
+		errs = append(errs, validateEntry(number, entry))
+	}
+	return entries, errors.Join(errs...)
+}
+
+// isTargetHeader reports whether a CSV record is a header naming a url column
+func isTargetHeader(record []string) bool {
+	for _, column := range record {
+		if strings.EqualFold(strings.TrimSpace(column), "url") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTags turns "team=payments;prod" into labels
+func parseTags(value string) map[string]string {
+	fields := strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ' ' })
+	if len(fields) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(fields))
+	for _, tag := range fields {
+		key, value, ok := strings.Cut(tag, "=")
+		if !ok {
+			value = "true"
+		}
+		labels[key] = value
+	}
+	return labels
+}
+
+// validateEntry checks an imported entry like a targets file entry
+func validateEntry(line int, entry TargetEntry) error {
+	if _, err := entry.resolve(TargetSettings{}, nil); err != nil {
+		return fmt.Errorf("line %d: %w", line, err)
+	}
+	return nil
+}
+
+// knownTargets are the names and URLs of targets in use. Imports skip an
+// entry when either is taken, so merging into a file and adding through the
+// admin API agree on what is already there.
+type knownTargets struct {
+	names map[string]bool
+	urls  map[string]bool
+}
+
+// newKnownTargets returns an empty set of known targets
+func newKnownTargets() knownTargets {
+	return knownTargets{names: make(map[string]bool), urls: make(map[string]bool)}
+}
+
+// has reports whether a target with the given name or URL is known
+func (k knownTargets) has(name, url string) bool {
+	return k.names[name] || (url != "" && k.urls[url])
+}
+
+// add records a target
+func (k knownTargets) add(name, url string) {
+	k.names[name] = true
+	if url != "" {
+		k.urls[url] = true
+	}
+}
+
+// Merge adds the entries whose name and URL are not in the file yet to its
+// targets. It returns how many were added.
+func (f *TargetsFile) Merge(entries []TargetEntry) int {
+	known := newKnownTargets()
+	for _, group := range f.Groups {
+		for _, entry := range group.Targets {
+			known.add(valueOr(entry.Name, entry.URL), entry.URL)
+		}
+	}
+	for _, entry := range f.Targets {
+		known.add(valueOr(entry.Name, entry.URL), entry.URL)
+	}
+
+	added := 0
+	for _, entry := range entries {
+		name := valueOr(entry.Name, entry.URL)
+		if known.has(name, entry.URL) {
+			continue
+		}
+		known.add(name, entry.URL)
+		f.Targets = append(f.Targets, entry)
+		added++
+	}
+	return added
+}
+
+// MergeTargetsYAML adds entries to the YAML of a targets file like Merge,
+// appending them to its targets list while keeping comments and formatting.
+// Empty data starts a new file. Files of an older version are migrated (see
+// MigrateTargetsFile). It returns the new YAML and how many entries were
+// added.
+func MergeTargetsYAML(data []byte, entries []TargetEntry) ([]byte, int, error) {
+	data, _, err := MigrateTargetsFile(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	var file TargetsFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && err != io.EOF {
+		return nil, 0, err
+	}
+	existing := len(file.Targets)
+	added := file.Merge(entries)
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+	if len(doc.Content) == 0 {
+		file.Version = TargetsFileVersion
+		doc = yaml.Node{Kind: yaml.DocumentNode}
+		root := &yaml.Node{}
+		if err := root.Encode(file); err != nil {
+			return nil, 0, err
+		}
+		doc.Content = []*yaml.Node{root}
+	} else if added > 0 {
+		root := doc.Content[0]
+		targets := mappingValue(root, "targets")
+		if targets == nil {
+			targets = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "targets"}, targets)
+		} else if targets.Kind != yaml.SequenceNode {
+			*targets = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"} // An empty "targets:"
+		}
+		for _, entry := range file.Targets[existing:] {
+			node := &yaml.Node{}
+			if err := node.Encode(entry); err != nil {
+				return nil, 0, err
+			}
+			targets.Content = append(targets.Content, node)
+		}
+	}
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, 0, err
+	}
+	return b.Bytes(), added, nil
+}
@@ -0,0 +1,93 @@
+package pingpong
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTargetList(t *testing.T) {
+	entries, err := ParseTargetList(strings.NewReader(`
+# inventory
+https://a.example.com/health
+
+https://b.example.com
+`))
+	if err != nil || len(entries) != 2 || entries[1].URL != "https://b.example.com" {
+		t.Errorf("Expected two plain URLs, got %+v (%v)", entries, err)
+	}
+
+	entries, err = ParseTargetList(strings.NewReader(`url,name,interval,tags
+https://a.example.com,a,30s,team=payments;critical
+https://b.example.com,,5000,
+`))
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Expected two CSV entries, got %+v (%v)", entries, err)
+	}
+	if a := entries[0]; a.Name != "a" || a.Interval != Duration(30*time.Second) || a.Labels["team"] != "payments" || a.Labels["critical"] != "true" {
+		t.Errorf("Unexpected first entry: %+v", a)
+	}
+	if b := entries[1]; b.Interval != Duration(5*time.Second) || b.Labels != nil {
+		t.Errorf("Unexpected second entry: %+v", b)
+	}
+
+	_, err = ParseTargetList(strings.NewReader("url,owner\nnot a url,me\nhttps://c.example.com,you\n"))
+	if err == nil || !strings.Contains(err.Error(), `unknown column "owner"`) || !strings.Contains(err.Error(), "line 2: invalid url") {
+		t.Errorf("Expected every problem to be reported, got %v", err)
+	}
+}
+
+func TestTargetsFile_Merge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.yaml")
+	file := TargetsFile{
+		Defaults: TargetSettings{Interval: Duration(time.Minute)},
+		Groups:   []TargetGroup{{Name: "api", Targets: []TargetEntry{{URL: "https://a.example.com"}}}},
+	}
+	added := file.Merge([]TargetEntry{{URL: "https://a.example.com"}, {URL: "https://b.example.com"}, {URL: "https://b.example.com"}})
+	if added != 1 || len(file.Targets) != 1 {
+		t.Errorf("Expected only the new URL to be added once, got %d: %+v", added, file.Targets)
+	}
+	added = file.Merge([]TargetEntry{{Name: "renamed", URL: "https://a.example.com"}, {Name: "https://b.example.com", URL: "https://c.example.com"}})
+	if added != 0 {
+		t.Errorf("Expected entries with a known URL or name to be skipped, got %+v", file.Targets)
+	}
+
+	if err := file.Write(path); err != nil {
+		t.Fatal(err)
+	}
+	targets, err := LoadTargets(path, nil)
+	if err != nil || len(targets) != 2 || targets[1].Interval != time.Minute {
+		t.Errorf("Expected the written file to load with inherited settings, got %+v (%v)", targets, err)
+	}
+}
+
+func TestMergeTargetsYAML(t *testing.T) {
+	entries := []TargetEntry{{URL: "https://a.example.com"}, {URL: "https://b.example.com"}}
+	data := "version: 1\n# Production targets\ntargets:\n  - url: https://a.example.com # the API\n"
+	merged, added, err := MergeTargetsYAML([]byte(data), entries)
+	if err != nil || added != 1 {
+		t.Fatalf("Expected one target to be added, got %d (%v)", added, err)
+	}
+	for _, want := range []string{"# Production targets", "# the API", "- url: https://b.example.com"} {
+		if !strings.Contains(string(merged), want) {
+			t.Errorf("Expected %q in the merged file, got:\n%s", want, merged)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "targets.yaml")
+	for _, data := range []string{"", "# Only defaults\ndefaults:\n  interval: 1m\n", "targets:\n"} {
+		merged, added, err := MergeTargetsYAML([]byte(data), entries)
+		if err != nil || added != 2 {
+			t.Fatalf("Expected both targets to be added to %q, got %d (%v)", data, added, err)
+		}
+		if err := os.WriteFile(path, merged, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		file, err := ReadTargetsFile(path)
+		if err != nil || len(file.Targets) != 2 {
+			t.Errorf("Expected the merged file to have both targets, got %+v (%v):\n%s", file.Targets, err, merged)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package pingpong
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MinTargetInterval is the shortest interval a targets file or the admin API
+// may set, so that a mistyped value cannot hammer a target
+const MinTargetInterval = 100 * time.Millisecond
+
+// TargetSettings are the settings a target inherits from the defaults
+// block and its group in a targets file. Maps are merged key by key; other
+// settings replace the inherited value when set.
+type TargetSettings struct {
+	Interval     Duration          `yaml:"interval,omitempty" json:"interval,omitempty"`
+	Timeout      Duration          `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	SoftTimeout  Duration          `yaml:"soft_timeout,omitempty" json:"soft_timeout,omitempty"`
+	Severity     Severity          `yaml:"severity,omitempty" json:"severity,omitempty"`
+	ExpectedBody string            `yaml:"expected_body,omitempty" json:"expected_body,omitempty"`
+	Headers      map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Labels       map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Notifiers    []string          `yaml:"notifiers,omitempty" json:"notifiers,omitempty"` // Names of the notifiers alerted about the target
+	HashBody     *bool             `yaml:"hash_body,omitempty" json:"hash_body,omitempty"` // The most specific level setting it wins, so a target can opt out of its group
+}
+
+// TargetsFile is the structure of a targets file (see LoadTargets)
+type TargetsFile struct {
+	Version  int            `yaml:"version"` // Schema version the file was written with (see TargetsFileVersion)
+	Defaults TargetSettings `yaml:"defaults,omitempty"`
+	Groups   []TargetGroup  `yaml:"groups,omitempty"`
+	Targets  []TargetEntry  `yaml:"targets,omitempty"`
+}
+
+// TargetGroup is a set of similar targets sharing settings. The group name
+// is attached to its targets as the "group" label.
+type TargetGroup struct {
+	Name           string `yaml:"name"`
+	TargetSettings `yaml:",inline"`
+	Targets        []TargetEntry `yaml:"targets"`
+}
+
+// TargetEntry is a single target in a targets file, and the body of
+// requests adding targets through the admin API
+type TargetEntry struct {
+	Name           string `yaml:"name,omitempty" json:"name,omitempty"`
+	URL            string `yaml:"url" json:"url"`
+	TargetSettings `yaml:",inline"`
+}
+
+// inherit returns the settings with unset values taken from parent
+func (s TargetSettings) inherit(parent TargetSettings) TargetSettings {
+	merged := parent
+	if s.Interval != 0 {
+		merged.Interval = s.Interval
+	}
+	if s.Timeout != 0 {
+		merged.Timeout = s.Timeout
+	}
+	if s.SoftTimeout != 0 {
+		merged.SoftTimeout = s.SoftTimeout
+	}
+	if s.Severity != "" {
+		merged.Severity = s.Severity
+	}
+	if s.ExpectedBody != "" {
+		merged.ExpectedBody = s.ExpectedBody
+	}
+	if s.Notifiers != nil {
+		merged.Notifiers = s.Notifiers
+	}
+	if s.HashBody != nil {
+		merged.HashBody = s.HashBody
+	}
+	merged.Headers = mergeLabels(maps.Clone(parent.Headers), s.Headers)
+	merged.Labels = mergeLabels(maps.Clone(parent.Labels), s.Labels)
+	return merged
+}
+
+// LoadTargets reads a YAML targets file. Targets inherit the defaults block
+// and the settings of their group, and refer to notifiers by their name in
+// notifiers. Every problem found is reported, not just the first.
+func LoadTargets(path string, notifiers map[string]Sink) ([]Target, error) {
+	file, err := ReadTargetsFile(path)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := file.Resolve(notifiers)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return targets, nil
+}
+
+// ReadTargetsFile parses a targets file without resolving its targets,
+// rejecting unknown keys. Files of an older version are migrated in memory
+// (see MigrateTargetsFile), and Version is left at the version read.
+func ReadTargetsFile(path string) (TargetsFile, error) {
+	var file TargetsFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, err
+	}
+	data, version, err := MigrateTargetsFile(data)
+	if err != nil {
+		return file, fmt.Errorf("%s: %w", path, err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && err != io.EOF {
+		return file, fmt.Errorf("%s: %w", path, err)
+	}
+	file.Version = version
+	return file, nil
+}
+
+// Write saves the targets file to path at TargetsFileVersion, replacing it
+// atomically. Comments in an existing file are not preserved.
+func (f TargetsFile) Write(path string) error {
+	f.Version = TargetsFileVersion
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(f); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, b.Bytes())
+}
+
+// Resolve applies inheritance and validates the targets of the file
+func (f TargetsFile) Resolve(notifiers map[string]Sink) ([]Target, error) {
+	var targets []Target
+	var errs []error
+	seen := make(map[string]bool)
+	add := func(where string, entry TargetEntry, settings TargetSettings) {
+		target, err := entry.resolve(settings, notifiers)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", where, err))
+			return
+		}
+		name := valueOr(target.Name, target.URL)
+		if seen[name] {
+			errs = append(errs, fmt.Errorf("%s: duplicate target %q", where, name))
+			return
+		}
+		seen[name] = true
+		targets = append(targets, target)
+	}
+
+	for i, group := range f.Groups {
+		where := fmt.Sprintf("group %d", i+1)
+		if group.Name == "" {
+			errs = append(errs, fmt.Errorf("%s: missing name", where))
+			continue
+		}
+		settings := group.TargetSettings.inherit(f.Defaults)
+		settings.Labels = mergeLabels(settings.Labels, map[string]string{"group": group.Name})
+		for j, entry := range group.Targets {
+			add(fmt.Sprintf("group %q target %d", group.Name, j+1), entry, settings)
+		}
+	}
+	for i, entry := range f.Targets {
+		add(fmt.Sprintf("target %d", i+1), entry, f.Defaults)
+	}
+	return targets, errors.Join(errs...)
+}
+
+// resolve validates an entry and turns it into a Target
+func (e TargetEntry) resolve(parent TargetSettings, notifiers map[string]Sink) (Target, error) {
+	settings := e.TargetSettings.inherit(parent)
+	if e.URL == "" {
+		return Target{}, errors.New("missing url")
+	}
+	if u, err := url.Parse(e.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Target{}, fmt.Errorf("invalid url %q", e.URL)
+	}
+	switch settings.Severity {
+	case "", SeverityInfo, SeverityWarning, SeverityCritical:
+	default:
+		return Target{}, fmt.Errorf("invalid severity %q", settings.Severity)
+	}
+	if settings.Interval < 0 || settings.Timeout < 0 || settings.SoftTimeout < 0 {
+		return Target{}, errors.New("durations must not be negative")
+	}
+	if settings.Interval > 0 && time.Duration(settings.Interval) < MinTargetInterval {
+		return Target{}, fmt.Errorf("interval %s is shorter than the minimum of %s", settings.Interval, MinTargetInterval)
+	}
+	if settings.SoftTimeout > 0 && settings.Timeout > 0 && settings.SoftTimeout >= settings.Timeout {
+		return Target{}, fmt.Errorf("soft_timeout %s must be shorter than timeout %s", settings.SoftTimeout, settings.Timeout)
+	}
+
+	target := Target{
+		Name:         e.Name,
+		URL:          e.URL,
+		ExpectedBody: settings.ExpectedBody,
+		Severity:     settings.Severity,
+		Labels:       settings.Labels,
+		SoftTimeout:  time.Duration(settings.SoftTimeout),
+		Timeout:      time.Duration(settings.Timeout),
+		Interval:     time.Duration(settings.Interval),
+		Headers:      settings.Headers,
+		HashBody:     settings.HashBody,
+	}
+	if settings.Notifiers != nil {
+		target.Notifiers = []Sink{}
+		for _, name := range settings.Notifiers {
+			notifier, ok := notifiers[name]
+			if !ok {
+				return Target{}, fmt.Errorf("unknown notifier %q", name)
+			}
+			target.Notifiers = append(target.Notifiers, notifier)
+		}
+	}
+	return target, nil
+}
@@ -0,0 +1,127 @@
+package pingpong
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadTargets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.yaml")
+	os.WriteFile(path, []byte(`
+version: 1
+defaults:
+  interval: 30s
+  timeout: 5s
+  headers: {User-Agent: pingpong}
+  labels: {team: platform}
+  notifiers: [ntfy]
+groups:
+  - name: api
+    interval: 10s
+    severity: critical
+    headers: {Authorization: Bearer token}
+    notifiers: [ntfy, twilio]
+    hash_body: true
+    targets:
+      - url: https://a.example.com/health
+      - name: b
+        url: https://b.example.com/health
+        interval: 5000
+        labels: {team: payments}
+        hash_body: false
+targets:
+  - url: https://docs.example.com
+`), 0o644)
+	ntfy, twilio := &recordingSink{}, &recordingSink{}
+
+	targets, err := LoadTargets(path, map[string]Sink{"ntfy": ntfy, "twilio": twilio})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 3 {
+		t.Fatalf("Expected 3 targets, got %+v", targets)
+	}
+	a, b, docs := targets[0], targets[1], targets[2]
+	if a.Interval != 10*time.Second || a.Timeout != 5*time.Second || a.Severity != SeverityCritical {
+		t.Errorf("Expected a to inherit from its group and the defaults, got %+v", a)
+	}
+	if a.Headers["User-Agent"] != "pingpong" || a.Headers["Authorization"] != "Bearer token" {
+		t.Errorf("Expected headers to be merged, got %v", a.Headers)
+	}
+	if a.Labels["group"] != "api" || a.Labels["team"] != "platform" || len(a.Notifiers) != 2 {
+		t.Errorf("Unexpected labels or notifiers of a: %+v", a)
+	}
+	if b.Name != "b" || b.Interval != 5*time.Second || b.Labels["team"] != "payments" {
+		t.Errorf("Expected b to override its group, got %+v", b)
+	}
+	if !a.hashBody() || b.hashBody() || docs.HashBody != nil {
+		t.Errorf("Expected the most specific hash_body to win, got %v, %v and %v", a.HashBody, b.HashBody, docs.HashBody)
+	}
+	if target := newTarget(Config{HashBody: true}, b); target.hashBody() {
+		t.Error("Expected a target turning hash_body off to override Config.HashBody")
+	}
+	if target := newTarget(Config{HashBody: true}, docs); !target.hashBody() {
+		t.Error("Expected a target without hash_body to inherit Config.HashBody")
+	}
+	if docs.Interval != 30*time.Second || docs.Severity != "" || docs.Labels["group"] != "" || len(docs.Notifiers) != 1 || docs.Notifiers[0] != ntfy {
+		t.Errorf("Expected docs to use the defaults, got %+v", docs)
+	}
+	if docs.Headers["Authorization"] != "" {
+		t.Errorf("Group headers leaked into other targets: %v", docs.Headers)
+	}
+}
+
+func TestLoadTargets_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.yaml")
+	os.WriteFile(path, []byte(`
+groups:
+  - name: api
+    targets:
+      - url: ftp://a.example.com
+      - url: https://b.example.com
+        severity: urgent
+targets:
+  - url: https://b.example.com
+    notifiers: [pager]
+  - name: dup
+    url: https://c.example.com
+  - name: dup
+    url: https://d.example.com
+`), 0o644)
+	_, err := LoadTargets(path, nil)
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	for _, want := range []string{`invalid url "ftp://a.example.com"`, `invalid severity "urgent"`, `unknown notifier "pager"`, `duplicate target "dup"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %s, got %v", want, err)
+		}
+	}
+
+	os.WriteFile(path, []byte("targets:\n  - url: https://a.example.com\n    interval: 2µs\n"), 0o644)
+	if _, err := LoadTargets(path, nil); err == nil || !strings.Contains(err.Error(), "shorter than the minimum") {
+		t.Errorf("Expected an interval below the minimum to be rejected, got %v", err)
+	}
+
+	os.WriteFile(path, []byte("targets:\n  - url: https://a.example.com\n    intervall: 5s\n"), 0o644)
+	if _, err := LoadTargets(path, nil); err == nil || !strings.Contains(err.Error(), "intervall") {
+		t.Errorf("Expected unknown fields to be rejected, got %v", err)
+	}
+}
+
+func TestService_TargetNotifiers(t *testing.T) {
+	global, own := &recordingSink{}, &recordingSink{}
+	service := NewService(Config{
+		Targets:   []Target{{Name: "a", URL: "http://a"}, {Name: "b", URL: "http://b", Notifiers: []Sink{own}}},
+		Notifiers: []Sink{global},
+		Logger:    &TestLogger{},
+	})
+	service.deliver(Event{Type: EventStateChange, Target: "a", Time: time.Now()})
+	service.deliver(Event{Type: EventStateChange, Target: "b", Time: time.Now()})
+	if len(global.events) != 1 || global.events[0].Target != "a" || len(own.events) != 1 || own.events[0].Target != "b" {
+		t.Errorf("Expected events to be routed to target notifiers, got %+v and %+v", global.events, own.events)
+	}
+}
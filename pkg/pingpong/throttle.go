@@ -0,0 +1,116 @@
+package pingpong
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QuietHours is a daily window, in minutes since midnight, during which
+// non-critical notifications are suppressed. Windows may wrap past midnight.
+type QuietHours struct {
+	Start int
+	End   int
+}
+
+// ParseQuietHours parses a window such as "22:00-07:00"
+func ParseQuietHours(value string) (QuietHours, error) {
+	var startH, startM, endH, endM int
+	var rest rune
+	// Scanning one rune past the window catches trailing input
+	if n, _ := fmt.Sscanf(value, "%d:%d-%d:%d%c", &startH, &startM, &endH, &endM, &rest); n != 4 {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours %q, expected HH:MM-HH:MM", value)
+	}
+	if startH > 23 || endH > 23 || startM > 59 || endM > 59 || startH < 0 || endH < 0 || startM < 0 || endM < 0 {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours %q, expected HH:MM-HH:MM", value)
+	}
+	return QuietHours{Start: startH*60 + startM, End: endH*60 + endM}, nil
+}
+
+// contains reports whether t falls within the window
+func (q QuietHours) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if q.Start <= q.End {
+		return minute >= q.Start && minute < q.End
+	}
+	return minute >= q.Start || minute < q.End
+}
+
+// ThrottledNotifier wraps a notifier with a delivery rate limit and quiet
+// hours, so a large simultaneous outage cannot burn through SMS credits or
+// spam a channel. Critical events are still delivered during quiet hours but
+// count towards the rate limit.
+type ThrottledNotifier struct {
+	Notifier   Sink
+	Limit      int            // Maximum notifications per Period (0 means unlimited)
+	Period     time.Duration  // Defaults to one hour
+	QuietHours []QuietHours   // Windows during which non-critical notifications are dropped
+	Location   *time.Location // Time zone of QuietHours (defaults to local time)
+
+	mu         sync.Mutex
+	sent       []time.Time
+	suppressed int
+}
+
+// Send forwards the event unless it is rate limited or falls in quiet hours
+func (t *ThrottledNotifier) Send(ctx context.Context, event Event) error {
+	if !notifiable(event, true) {
+		return t.Notifier.Send(ctx, event)
+	}
+
+	now := event.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if !t.allow(now, event.Severity == SeverityCritical) {
+		return nil
+	}
+	return t.Notifier.Send(ctx, event)
+}
+
+// Suppressed returns the number of notifications dropped so far
+func (t *ThrottledNotifier) Suppressed() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.suppressed
+}
+
+// allow records a delivery at now if it is within limits
+func (t *ThrottledNotifier) allow(now time.Time, critical bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !critical {
+		location := t.Location
+		if location == nil {
+			location = time.Local
+		}
+		for _, quiet := range t.QuietHours {
+			if quiet.contains(now.In(location)) {
+				t.suppressed++
+				return false
+			}
+		}
+	}
+
+	if t.Limit > 0 {
+		period := t.Period
+		if period <= 0 {
+			period = time.Hour
+		}
+		recent := t.sent[:0]
+		for _, sent := range t.sent {
+			if now.Sub(sent) < period {
+				recent = append(recent, sent)
+			}
+		}
+		t.sent = recent
+		if len(t.sent) >= t.Limit {
+			t.suppressed++
+			return false
+		}
+		t.sent = append(t.sent, now)
+	}
+	return true
+}
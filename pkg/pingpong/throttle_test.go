@@ -0,0 +1,57 @@
+package pingpong
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestThrottledNotifier_RateLimit(t *testing.T) {
+	inner := &recordingSink{}
+	notifier := &ThrottledNotifier{Notifier: inner, Limit: 2, Period: time.Minute}
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		event := Event{Type: EventStateChange, State: StateDown, PreviousState: StateUp, Time: start.Add(time.Duration(i) * time.Second)}
+		notifier.Send(context.Background(), event)
+	}
+	notifier.Send(context.Background(), Event{Type: EventStateChange, State: StateUp, PreviousState: StateDown, Time: start.Add(time.Minute)})
+
+	if delivered := len(inner.ofType(EventStateChange)); delivered != 3 {
+		t.Errorf("Expected 3 delivered notifications, got %d", delivered)
+	}
+	if notifier.Suppressed() != 3 {
+		t.Errorf("Expected 3 suppressed notifications, got %d", notifier.Suppressed())
+	}
+}
+
+func TestThrottledNotifier_QuietHours(t *testing.T) {
+	quiet, err := ParseQuietHours("22:00-07:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := &recordingSink{}
+	notifier := &ThrottledNotifier{Notifier: inner, QuietHours: []QuietHours{quiet}, Location: time.UTC}
+
+	night := time.Date(2024, 1, 1, 23, 30, 0, 0, time.UTC)
+	morning := time.Date(2024, 1, 2, 7, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Type: EventStateChange, State: StateDown, PreviousState: StateUp, Time: night, Severity: SeverityWarning},
+		{Type: EventStateChange, State: StateDown, PreviousState: StateUp, Time: night, Severity: SeverityCritical},
+		{Type: EventStateChange, State: StateDown, PreviousState: StateUp, Time: morning, Severity: SeverityWarning},
+	}
+	for _, event := range events {
+		notifier.Send(context.Background(), event)
+	}
+
+	delivered := inner.ofType(EventStateChange)
+	if len(delivered) != 2 || delivered[0].Severity != SeverityCritical {
+		t.Errorf("Expected critical night and morning notifications, got %+v", delivered)
+	}
+
+	for _, value := range []string{"25:00-07:00", "22:00-07:00xyz", "22:00-07:00 ", "22:00"} {
+		if _, err := ParseQuietHours(value); err == nil {
+			t.Errorf("Expected error for invalid quiet hours %q", value)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package pingpong
+
+import "time"
+
+// timestamp returns the current time in UTC. Results, events, reports and
+// state are stamped with it, so their times serialize as RFC 3339 with a Z
+// suffix whatever the time zone of the host.
+func timestamp() time.Time {
+	return time.Now().UTC()
+}
+
+// displayTime formats a time for log messages read by people, in
+// Config.DisplayLocation
+func (s *Service) displayTime(t time.Time) string {
+	location := s.config.DisplayLocation
+	if location == nil {
+		location = time.Local
+	}
+	return t.In(location).Format(time.RFC3339)
+}
@@ -0,0 +1,42 @@
+package pingpong
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestTimestamps_UTC(t *testing.T) {
+	service := NewService(Config{ServerURL: "http://example.com", Logger: &TestLogger{}})
+	target := service.targets[0]
+	result := service.newResult(target)
+	service.recordResult(target, Result{Success: true, Time: result.Time})
+
+	data, err := json.Marshal(struct {
+		Event  Event
+		Status Status
+	}{service.newEvent(target, EventResult, &result), service.Status()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rfc3339UTC := regexp.MustCompile(`^"\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(\.\d+)?Z"$`)
+	fields := regexp.MustCompile(`"(time|last_success)":("[^"]*")`).FindAllStringSubmatch(string(data), -1)
+	if len(fields) < 4 {
+		t.Fatalf("Expected event, result and status times, got %s", data)
+	}
+	for _, field := range fields {
+		if !rfc3339UTC.MatchString(field[2]) {
+			t.Errorf("Expected %s to be RFC 3339 in UTC, got %s", field[1], field[2])
+		}
+	}
+}
+
+func TestService_DisplayTime(t *testing.T) {
+	location := time.FixedZone("UTC+2", 2*60*60)
+	service := NewService(Config{ServerURL: "http://example.com", DisplayLocation: location, Logger: &TestLogger{}})
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	if got := service.displayTime(at); got != "2024-01-01T12:00:00+02:00" {
+		t.Errorf("Expected the time in the display location, got %s", got)
+	}
+}
@@ -0,0 +1,64 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// TwilioNotifier sends an SMS to every recipient when the target goes down or
+// recovers, and optionally places a voice call for critical outages. It is
+// meant for teams without a paging product.
+type TwilioNotifier struct {
+	AccountSID string
+	AuthToken  string
+	From       string   // Twilio phone number messages and calls originate from
+	To         []string // Recipient phone numbers
+	Call       bool     // Also call recipients when a critical target goes down
+	Degraded   bool     // Also text recipients when a target turns degraded or recovers from it
+	APIURL     string   // Defaults to https://api.twilio.com
+	Client     *http.Client
+}
+
+// Send notifies recipients about state changes and acknowledgements
+func (t *TwilioNotifier) Send(ctx context.Context, event Event) error {
+	if !notifiable(event, t.Degraded) {
+		return nil
+	}
+
+	// One failing number must not keep the page from the others
+	message := event.Summary()
+	var errs []error
+	for _, to := range t.To {
+		if err := t.post(ctx, "Messages.json", url.Values{"To": {to}, "From": {t.From}, "Body": {message}}); err != nil {
+			errs = append(errs, fmt.Errorf("SMS to %s: %w", to, err))
+		}
+		if t.Call && isOutage(event) && event.Severity == SeverityCritical {
+			var twiml strings.Builder
+			twiml.WriteString("<Response><Say>")
+			xml.EscapeText(&twiml, []byte(message))
+			twiml.WriteString("</Say></Response>")
+			if err := t.post(ctx, "Calls.json", url.Values{"To": {to}, "From": {t.From}, "Twiml": {twiml.String()}}); err != nil {
+				errs = append(errs, fmt.Errorf("call to %s: %w", to, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// post sends a form request to a Twilio account resource
+func (t *TwilioNotifier) post(ctx context.Context, resource string, form url.Values) error {
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/%s", valueOr(t.APIURL, "https://api.twilio.com"), t.AccountSID, resource)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create Twilio request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
+
+	return doRequest(t.Client, req)
+}
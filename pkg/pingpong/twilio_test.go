@@ -0,0 +1,62 @@
+package pingpong
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTwilioNotifier(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if user, _, _ := r.BasicAuth(); user != "AC1" {
+			t.Errorf("Expected basic auth with account SID, got %q", user)
+		}
+		requests = append(requests, r.URL.Path+" "+r.PostForm.Get("To"))
+		if r.PostForm.Get("To") == "+300" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "Calls.json") && !strings.Contains(r.PostForm.Get("Twiml"), "<Say>http://api is DOWN") {
+			t.Errorf("Unexpected TwiML: %s", r.PostForm.Get("Twiml"))
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	notifier := &TwilioNotifier{AccountSID: "AC1", AuthToken: "tok", From: "+100", To: []string{"+200"}, Call: true, APIURL: server.URL}
+	events := []Event{
+		{Type: EventStateChange, Target: "http://api", State: StateUp, PreviousState: StateUnknown},
+		{Type: EventStateChange, Target: "http://api", State: StateDegraded, PreviousState: StateUp},
+		{Type: EventStateChange, Target: "http://api", State: StateUp, PreviousState: StateDegraded},
+		{Type: EventStateChange, Target: "http://api", State: StateDown, PreviousState: StateUp, Severity: SeverityWarning},
+		{Type: EventStateChange, Target: "http://api", State: StateDown, PreviousState: StateUp, Severity: SeverityCritical},
+	}
+	for _, event := range events {
+		if err := notifier.Send(context.Background(), event); err != nil {
+			t.Fatalf("Send returned error: %v", err)
+		}
+	}
+
+	expected := []string{
+		"/2010-04-01/Accounts/AC1/Messages.json +200",
+		"/2010-04-01/Accounts/AC1/Messages.json +200",
+		"/2010-04-01/Accounts/AC1/Calls.json +200",
+	}
+	if strings.Join(requests, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected requests:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(requests, "\n"))
+	}
+
+	// A failing recipient does not keep the others from being paged
+	requests = nil
+	notifier.To, notifier.Call = []string{"+300", "+200"}, false
+	if err := notifier.Send(context.Background(), events[len(events)-1]); err == nil || !strings.Contains(err.Error(), "+300") {
+		t.Errorf("Expected an error naming the failing recipient, got %v", err)
+	}
+	if len(requests) != 2 {
+		t.Errorf("Expected every recipient to be texted, got %v", requests)
+	}
+}
@@ -0,0 +1,106 @@
+package pingpong
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// notifyChanged wakes everyone waiting in WaitHealthy
+func (s *Service) notifyChanged() {
+	s.mu.Lock()
+	close(s.changed)
+	s.changed = make(chan struct{})
+	s.mu.Unlock()
+}
+
+// WaitHealthy blocks until every target, or only the target with that name
+// if name is not empty, is up or degraded according to a check finished
+// after the wait began. It returns an error describing why it is still
+// unhealthy once ctx is done or the service shuts down.
+func (s *Service) WaitHealthy(ctx context.Context, name string) error {
+	since := time.Now()
+	for {
+		s.mu.Lock()
+		changed := s.changed
+		s.mu.Unlock()
+
+		healthy, reason := s.upSince(name, since)
+		if healthy {
+			return nil
+		}
+
+		// Targets added or discovered during the wait change without a new
+		// result, so check again at least every second
+		select {
+		case <-changed:
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return errors.New(reason)
+		case <-s.done:
+			return errors.New("Shutting down: " + reason)
+		}
+	}
+}
+
+// upSince reports whether the target with the given name, or every target if
+// name is empty, passes targetUpSince
+func (s *Service) upSince(name string, since time.Time) (bool, string) {
+	if name != "" {
+		t := s.targetNamed(name)
+		if t == nil {
+			return false, fmt.Sprintf("Unknown target %q", name)
+		}
+		return targetUpSince(t, since)
+	}
+	targets := s.targetList()
+	if len(targets) == 0 {
+		return false, "No targets"
+	}
+	for _, t := range targets {
+		if healthy, reason := targetUpSince(t, since); !healthy {
+			return false, s.describe(t, reason)
+		}
+	}
+	return true, ""
+}
+
+// targetUpSince reports whether the last check of a target finished after
+// since and found it up or degraded. A check already running when the wait
+// began counts once it finishes. Unlike targetHealth, neither an older
+// success nor the startup grace period count, so a deploy waiting on a
+// dependency does not proceed on stale results.
+func targetUpSince(t *target, since time.Time) (bool, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case t.lastResult == nil || t.lastFinished.Before(since):
+		return false, "No check finished since the wait began"
+	case t.state != StateUp && t.state != StateDegraded:
+		return false, fmt.Sprintf("Target is %s", t.state)
+	}
+	return true, ""
+}
+
+// healthWaitHandler blocks until the service, or ?target, is healthy or
+// ?timeout (default 30s) expires
+func (s *Service) healthWaitHandler(w http.ResponseWriter, r *http.Request) {
+	timeout := 30 * time.Second
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		var err error
+		if timeout, err = ParseDuration(value); err != nil || timeout <= 0 {
+			http.Error(w, "Invalid timeout", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	if err := s.WaitHealthy(ctx, r.URL.Query().Get("target")); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "Ping-Pong-Go Server is healthy")
+}
@@ -0,0 +1,132 @@
+package pingpong
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// WatchdogAction is what the watchdog does about a stalled target
+type WatchdogAction string
+
+const (
+	// WatchdogLog logs the stall as an error (the default)
+	WatchdogLog WatchdogAction = "log"
+	// WatchdogRestart logs the stall and replaces the ping routine of the target
+	WatchdogRestart WatchdogAction = "restart"
+	// WatchdogExit shuts the service down with ShutdownWatchdog, so a
+	// supervisor can restart the process
+	WatchdogExit WatchdogAction = "exit"
+)
+
+// Watchdog notices ping routines that stopped completing cycles, for example
+// because of a deadlock or a DNS lookup that never returns. Every target runs
+// its own cycle and there is no shared scheduler loop to watch: a stuck
+// MaxConcurrentChecks scheduler shows up as the targets waiting on it
+// stalling.
+type Watchdog struct {
+	Multiple int            // Intervals a target may go without completing a cycle before it counts as stalled (default 5)
+	Action   WatchdogAction // What to do about a stalled target (default log)
+}
+
+// withDefaults returns the watchdog settings with defaults applied
+func (w Watchdog) withDefaults() Watchdog {
+	if w.Multiple <= 0 {
+		w.Multiple = 5
+	}
+	if w.Action == "" {
+		w.Action = WatchdogLog
+	}
+	return w
+}
+
+// watchdogPeriod is how often the watchdog looks for stalled targets
+const watchdogPeriod = time.Second
+
+// watchdogStopTimeout is how long a restart waits for the stalled routine to
+// stop before logging that it is still waiting
+const watchdogStopTimeout = 10 * time.Second
+
+// heartbeat records that the ping routine of a target completed a cycle
+func (t *target) heartbeat(now time.Time) {
+	atomic.StoreInt64(&t.lastCycle, now.UnixNano())
+}
+
+// runWatchdog looks for stalled targets until the service shuts down
+func (s *Service) runWatchdog(ctx context.Context) {
+	ticker := time.NewTicker(watchdogPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.checkWatchdog(ctx, now)
+		}
+	}
+}
+
+// checkWatchdog acts on every target that has not completed a cycle within
+// Watchdog.Multiple intervals. A target is reported once per stall.
+func (s *Service) checkWatchdog(ctx context.Context, now time.Time) {
+	watchdog := s.config.Watchdog.withDefaults()
+	for _, t := range s.targetList() {
+		if t.parked.Load() {
+			continue // Stopped on purpose
+		}
+		last := time.Unix(0, atomic.LoadInt64(&t.lastCycle))
+		if now.Sub(last) <= time.Duration(watchdog.Multiple)*t.Interval {
+			t.stalled.Store(false)
+			continue
+		}
+		if t.stalled.Swap(true) {
+			continue // Already reported
+		}
+
+		s.watchdogStalls.Add(1)
+		s.logger.Error("Watchdog: %s has not completed a check in %s", t.Name, now.Sub(last).Round(time.Second))
+		switch watchdog.Action {
+		case WatchdogRestart:
+			s.logger.Warn("Watchdog: restarting the ping routine of %s", t.Name)
+			go s.restartTarget(ctx, t)
+		case WatchdogExit:
+			s.shutdown(ShutdownWatchdog)
+			return
+		}
+	}
+}
+
+// restartTarget cancels the ping routine of a stalled target and starts a new
+// one once it has stopped, so the two never check the target side by side. A
+// target removed or replaced in the meantime is left alone.
+func (s *Service) restartTarget(ctx context.Context, t *target) {
+	s.mu.Lock()
+	cancel, stopped := t.cancel, t.stopped
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		select {
+		case <-stopped:
+		case <-time.After(watchdogStopTimeout):
+			s.logger.Error("Watchdog: the ping routine of %s has not stopped after %s, restarting it once it does", t.Name, watchdogStopTimeout)
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				return
+			case <-s.done:
+				return
+			}
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+	if s.targetNamed(t.Name) != t {
+		return
+	}
+	t.stalled.Store(false)
+	s.startTarget(ctx, t)
+}
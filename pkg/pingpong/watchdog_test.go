@@ -0,0 +1,101 @@
+package pingpong
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestService_Watchdog(t *testing.T) {
+	for _, action := range []WatchdogAction{WatchdogLog, WatchdogRestart, WatchdogExit} {
+		t.Run(string(action), func(t *testing.T) {
+			var reason ShutdownReason
+			service := NewService(Config{
+				ServerURL:    "http://example.com",
+				PingInterval: time.Hour,
+				Watchdog:     &Watchdog{Multiple: 2, Action: action},
+				OnShutdown:   func(r ShutdownReason) { reason = r },
+				Logger:       &TestLogger{},
+			})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			target := service.targets[0]
+			service.startTarget(ctx, target)
+			started := time.Now()
+
+			// Two missed intervals are tolerated
+			service.checkWatchdog(ctx, started.Add(2*time.Hour-time.Minute))
+			if service.watchdogStalls.Load() != 0 {
+				t.Fatal("Expected no stall within the allowed intervals")
+			}
+
+			service.mu.Lock()
+			stopped := target.stopped
+			service.mu.Unlock()
+			service.checkWatchdog(ctx, started.Add(3*time.Hour))
+			if action != WatchdogRestart {
+				service.checkWatchdog(ctx, started.Add(3*time.Hour+time.Second))
+			}
+			if stalls := service.watchdogStalls.Load(); stalls != 1 {
+				t.Errorf("Expected the stall to be reported once, got %d", stalls)
+			}
+
+			switch action {
+			case WatchdogRestart:
+				<-stopped // The stalled routine was cancelled
+				if !restarted(service, target, stopped) {
+					t.Error("Expected a new ping routine")
+				}
+			case WatchdogExit:
+				if reason != ShutdownWatchdog {
+					t.Errorf("Expected a watchdog shutdown, got %q", reason)
+				}
+			default:
+				if reason != "" {
+					t.Errorf("Expected no shutdown, got %q", reason)
+				}
+			}
+		})
+	}
+}
+
+// restarted reports whether the ping routine of a target is replaced within a
+// second, its previous routine being the one closing stopped
+func restarted(service *Service, target *target, stopped chan struct{}) bool {
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(time.Millisecond) {
+		service.mu.Lock()
+		replaced := target.stopped != stopped
+		service.mu.Unlock()
+		if replaced {
+			return true
+		}
+	}
+	return false
+}
+
+func TestService_WatchdogRestartWaitsForStop(t *testing.T) {
+	service := NewService(Config{
+		ServerURL:    "http://example.com",
+		PingInterval: time.Hour,
+		Watchdog:     &Watchdog{Multiple: 2, Action: WatchdogRestart},
+		Logger:       &TestLogger{},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer service.shutdown(ShutdownStopped)
+
+	// A routine stuck in a check that ignores cancellation
+	target := service.targets[0]
+	stuck := make(chan struct{})
+	target.cancel, target.stopped = func() {}, stuck
+	target.heartbeat(time.Now())
+
+	service.checkWatchdog(ctx, time.Now().Add(3*time.Hour))
+	if restarted(service, target, stuck) {
+		t.Fatal("Expected no new routine while the stalled one is still running")
+	}
+	close(stuck)
+	if !restarted(service, target, stuck) {
+		t.Error("Expected a new routine once the stalled one stopped")
+	}
+}
@@ -0,0 +1,118 @@
+package pingpong
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CloudEventsMode selects how a WebhookSink encodes events as CloudEvents
+type CloudEventsMode string
+
+const (
+	// CloudEventsStructured sends the whole CloudEvent as an application/cloudevents+json body
+	CloudEventsStructured CloudEventsMode = "structured"
+	// CloudEventsBinary sends CloudEvents attributes as ce-* headers and the event as the body
+	CloudEventsBinary CloudEventsMode = "binary"
+)
+
+// cloudEventTypePrefix prefixes the CloudEvents type attribute of every event
+const cloudEventTypePrefix = "io.github.sumonrayy.pingpong."
+
+// WebhookSink posts events as JSON to an HTTP endpoint, optionally in
+// CloudEvents 1.0 format so they can be routed by Knative, EventBridge and
+// similar event routers without custom adapters
+type WebhookSink struct {
+	URL         string
+	Headers     map[string]string
+	CloudEvents CloudEventsMode // Empty sends the plain event JSON
+	Source      string          // CloudEvents source attribute (defaults to "pingpong/<instance id>")
+	Secrets     []string        // HMAC keys signing each delivery in the X-Pingpong-Signature header (see SignWebhook)
+	Client      *http.Client    // Set to NewMTLSClient to authenticate with a client certificate
+}
+
+// cloudEvent is the structured-mode CloudEvents 1.0 envelope
+type cloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+	Data            Event     `json:"data"`
+}
+
+// Send posts the event to the webhook URL
+func (w *WebhookSink) Send(ctx context.Context, event Event) error {
+	source := w.Source
+	if source == "" {
+		source = "pingpong/" + event.InstanceID
+	}
+
+	var body any = event
+	contentType := "application/json"
+	if w.CloudEvents == CloudEventsStructured {
+		body = cloudEvent{
+			SpecVersion:     "1.0",
+			ID:              event.ID,
+			Source:          source,
+			Type:            cloudEventTypePrefix + string(event.Type),
+			Subject:         event.Target,
+			Time:            event.Time,
+			DataContentType: "application/json",
+			Data:            event,
+		}
+		contentType = "application/cloudevents+json"
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if w.CloudEvents == CloudEventsBinary {
+		req.Header.Set("ce-specversion", "1.0")
+		req.Header.Set("ce-id", event.ID)
+		req.Header.Set("ce-source", source)
+		req.Header.Set("ce-type", cloudEventTypePrefix+string(event.Type))
+		req.Header.Set("ce-subject", event.Target)
+		req.Header.Set("ce-time", event.Time.Format(time.RFC3339Nano))
+	}
+	for key, value := range w.Headers {
+		req.Header.Set(key, value)
+	}
+	if len(w.Secrets) > 0 {
+		req.Header.Set(SignatureHeader, SignWebhook(payload, time.Now(), w.Secrets))
+	}
+
+	return doRequest(w.Client, req)
+}
+
+// doRequest sends req and treats any non-2xx response as an error
+func doRequest(client *http.Client, req *http.Request) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s returned status %d: %s", req.Method, req.URL.Redacted(), resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package pingpong
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookSink_CloudEvents(t *testing.T) {
+	var req *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	event := Event{ID: "abc", Type: EventStateChange, Time: time.Now(), InstanceID: "probe-1", Target: "http://example.com", State: StateDown}
+
+	sink := &WebhookSink{URL: server.URL, CloudEvents: CloudEventsBinary}
+	if err := sink.Send(context.Background(), event); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if req.Header.Get("ce-id") != "abc" || req.Header.Get("ce-type") != "io.github.sumonrayy.pingpong.state_change" ||
+		req.Header.Get("ce-source") != "pingpong/probe-1" {
+		t.Errorf("Unexpected binary mode headers: %v", req.Header)
+	}
+	var data Event
+	if err := json.Unmarshal(body, &data); err != nil || data.State != StateDown {
+		t.Errorf("Expected event as binary mode body, got %s (%v)", body, err)
+	}
+
+	sink = &WebhookSink{URL: server.URL, CloudEvents: CloudEventsStructured, Source: "urn:test"}
+	if err := sink.Send(context.Background(), event); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "application/cloudevents+json" {
+		t.Errorf("Expected structured content type, got %q", ct)
+	}
+	var ce cloudEvent
+	if err := json.Unmarshal(body, &ce); err != nil {
+		t.Fatalf("Failed to decode structured event: %v", err)
+	}
+	if ce.SpecVersion != "1.0" || ce.Source != "urn:test" || ce.Subject != "http://example.com" || ce.Data.ID != "abc" {
+		t.Errorf("Unexpected structured event: %+v", ce)
+	}
+}
+
+func TestWebhookSink_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	sink := &WebhookSink{URL: server.URL}
+	if err := sink.Send(context.Background(), Event{}); err == nil {
+		t.Error("Expected error for non-2xx response")
+	}
+}
+
+func TestWebhookSink_Signature(t *testing.T) {
+	var header string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get(SignatureHeader)
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	// While rotating, deliveries are signed with both the old and the new key
+	sink := &WebhookSink{URL: server.URL, Secrets: []string{"new", "old"}}
+	if err := sink.Send(context.Background(), Event{ID: "abc"}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	for _, secrets := range [][]string{{"new"}, {"old"}, {"other", "old"}} {
+		if err := VerifyWebhook(header, body, secrets, time.Minute); err != nil {
+			t.Errorf("Expected signature %q to verify with %v: %v", header, secrets, err)
+		}
+	}
+	if err := VerifyWebhook(header, body, []string{"other"}, time.Minute); err == nil {
+		t.Error("Expected signature to fail with an unknown secret")
+	}
+	if err := VerifyWebhook(header, append(body, ' '), []string{"new"}, time.Minute); err == nil {
+		t.Error("Expected signature to fail for a modified body")
+	}
+
+	stale := SignWebhook(body, time.Now().Add(-time.Hour), []string{"new"})
+	if err := VerifyWebhook(stale, body, []string{"new"}, time.Minute); err == nil {
+		t.Error("Expected an old signature to be rejected")
+	}
+}